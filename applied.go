@@ -0,0 +1,172 @@
+package gqlfetch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// introspectionAppliedDirective is a directive applied to a schema member, as reported
+// by the appliedDirectives introspection extension of graphql-java and Hot Chocolate.
+// Argument values are GraphQL literals.
+type introspectionAppliedDirective struct {
+	Name string `json:"name"`
+	Args []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"args"`
+}
+
+// printedSeparately are the directives printed from dedicated introspection fields.
+var printedSeparately = []string{"deprecated", "specifiedBy", "oneOf"}
+
+// withDefinedAppliedDirectives leaves out the applied directives the schema doesn't
+// define, which servers may apply without exposing, as the SDL wouldn't load, and those
+// printed from dedicated introspection fields. An applied @oneOf marks its input object
+// as oneOf.
+func withDefinedAppliedDirectives(schema introspectionSchema) (introspectionSchema, error) {
+	if !hasAppliedDirectives(schema) {
+		return schema, nil
+	}
+	defined := map[string]bool{"include": true, "skip": true}
+	for _, directive := range schema.Directives {
+		defined[directive.Name] = true
+	}
+	for _, name := range printedSeparately {
+		defined[name] = false
+	}
+	filter := func(applied []introspectionAppliedDirective) []introspectionAppliedDirective {
+		var kept []introspectionAppliedDirective
+		for _, directive := range applied {
+			if defined[directive.Name] {
+				kept = append(kept, directive)
+			}
+		}
+		return kept
+	}
+	filterInputFields := func(fields []introspectionInputField) []introspectionInputField {
+		if fields == nil {
+			return nil
+		}
+		filtered := make([]introspectionInputField, len(fields))
+		for i, field := range fields {
+			field.AppliedDirectives = filter(field.AppliedDirectives)
+			filtered[i] = field
+		}
+		return filtered
+	}
+
+	types := make([]introspectionTypeDefinition, len(schema.Types))
+	for i, typ := range schema.Types {
+		for _, directive := range typ.AppliedDirectives {
+			if directive.Name == "oneOf" {
+				typ.IsOneOf = true
+			}
+		}
+		typ.AppliedDirectives = filter(typ.AppliedDirectives)
+		if typ.Fields != nil {
+			fields := make([]introspectedTypeField, len(typ.Fields))
+			for j, field := range typ.Fields {
+				field.AppliedDirectives = filter(field.AppliedDirectives)
+				field.Args = filterInputFields(field.Args)
+				fields[j] = field
+			}
+			typ.Fields = fields
+		}
+		typ.InputFields = filterInputFields(typ.InputFields)
+
+		if len(typ.EnumValues) > 0 && string(typ.EnumValues) != "null" {
+			var values []introspectionEnumValue
+			if err := json.Unmarshal(typ.EnumValues, &values); err != nil {
+				return introspectionSchema{}, fmt.Errorf("cannot unmarshal enum values: %w\n%v", err, typ.EnumValues)
+			}
+			for j := range values {
+				values[j].AppliedDirectives = filter(values[j].AppliedDirectives)
+			}
+			encoded, err := json.Marshal(values)
+			if err != nil {
+				return introspectionSchema{}, fmt.Errorf("cannot marshal enum values: %w", err)
+			}
+			typ.EnumValues = encoded
+		}
+		types[i] = typ
+	}
+	schema.Types = types
+	return schema, nil
+}
+
+// hasAppliedDirectives reports whether any member of schema may have applied directives,
+// sparing the copy of schemas fetched without them.
+func hasAppliedDirectives(schema introspectionSchema) bool {
+	for _, typ := range schema.Types {
+		if len(typ.AppliedDirectives) > 0 || bytes.Contains(typ.EnumValues, []byte(`"appliedDirectives"`)) {
+			return true
+		}
+		for _, field := range typ.Fields {
+			if len(field.AppliedDirectives) > 0 || hasAppliedInputDirectives(field.Args) {
+				return true
+			}
+		}
+		if hasAppliedInputDirectives(typ.InputFields) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAppliedInputDirectives(fields []introspectionInputField) bool {
+	for _, field := range fields {
+		if len(field.AppliedDirectives) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// printAppliedDirectives prints directives applied to a field, argument, input field,
+// enum value or scalar, each preceded by a space.
+func printAppliedDirectives(sb *strings.Builder, applied []introspectionAppliedDirective) {
+	for _, directive := range applied {
+		sb.WriteString(" @" + directive.Name)
+		if len(directive.Args) == 0 {
+			continue
+		}
+		args := make([]string, len(directive.Args))
+		for i, arg := range directive.Args {
+			args[i] = arg.Name + ": " + arg.Value
+		}
+		sb.WriteString("(" + strings.Join(args, ", ") + ")")
+	}
+}
+
+// printTypeDirectives prints directives applied to a type, followed by a space so the
+// opening brace can be written directly after them.
+func printTypeDirectives(sb *strings.Builder, applied []introspectionAppliedDirective) {
+	if len(applied) == 0 {
+		return
+	}
+	printed := &strings.Builder{}
+	printAppliedDirectives(printed, applied)
+	sb.WriteString(strings.TrimPrefix(printed.String(), " ") + " ")
+}
+
+// appliedDirectiveList converts applied directives to AST directives.
+func appliedDirectiveList(applied []introspectionAppliedDirective) (ast.DirectiveList, error) {
+	var directives ast.DirectiveList
+	for _, directive := range applied {
+		astDirective := &ast.Directive{Name: directive.Name}
+		for _, arg := range directive.Args {
+			value := arg.Value
+			astValue, err := parseDefaultValue(&value)
+			if err != nil {
+				return nil, fmt.Errorf("parse argument %s of directive @%s: %w", arg.Name, directive.Name, err)
+			}
+			astDirective.Arguments = append(astDirective.Arguments, &ast.Argument{Name: arg.Name, Value: astValue})
+		}
+		directives = append(directives, astDirective)
+	}
+	return directives, nil
+}
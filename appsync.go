@@ -0,0 +1,275 @@
+package gqlfetch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AppSyncEndpoint is the GraphQL endpoint of the AWS AppSync API apiID in region.
+func AppSyncEndpoint(apiID, region string) string {
+	return fmt.Sprintf("https://%s.appsync-api.%s.amazonaws.com/graphql", apiID, region)
+}
+
+// appSyncHTTPEndpoint maps the real-time endpoint of an AppSync API, which only accepts
+// subscriptions with credentials encoded in the URL, to its HTTP endpoint, which
+// answers introspection queries.
+func appSyncHTTPEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "wss" && u.Scheme != "ws") {
+		return endpoint
+	}
+	u.Scheme = "https"
+	u.RawQuery = ""
+	u.Host = strings.Replace(u.Host, ".appsync-realtime-api.", ".appsync-api.", 1)
+	// Custom domains serve the real-time endpoint at /graphql/realtime.
+	u.Path = strings.TrimSuffix(u.Path, "/realtime")
+	return u.String()
+}
+
+// AppSyncOptions returns options introspecting an AWS AppSync API. Endpoint is the
+// HTTP or real-time endpoint of the API, or of its custom domain, and is derived from
+// apiID and region when empty. Requests are authenticated with apiKey when set, and
+// otherwise signed with the default AWS credentials, as loaded by
+// LoadAWSCredentials. region defaults to AWS_REGION or AWS_DEFAULT_REGION.
+func AppSyncOptions(endpoint, apiID, region, apiKey string) (BuildClientSchemaOptions, error) {
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if endpoint == "" {
+		if apiID == "" || region == "" {
+			return BuildClientSchemaOptions{}, errors.New("an AppSync endpoint, or API ID and region, is required")
+		}
+		endpoint = AppSyncEndpoint(apiID, region)
+	}
+	options := BuildClientSchemaOptions{Endpoint: appSyncHTTPEndpoint(endpoint)}
+
+	if apiKey != "" {
+		options.Auth = APIKey{Key: apiKey}
+		return options, nil
+	}
+	if region == "" {
+		return BuildClientSchemaOptions{}, errors.New("an AWS region is required to sign AppSync requests")
+	}
+	credentials, err := LoadAWSCredentials(os.Getenv("AWS_PROFILE"))
+	if err != nil {
+		return BuildClientSchemaOptions{}, err
+	}
+	credentials.Region = region
+	options.Auth = credentials
+	return options, nil
+}
+
+// LoadAWSCredentials loads AWS credentials from the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables, falling back to
+// profile, "default" when empty, of the shared credentials file. Without a profile, the
+// credentials of the task role of ECS containers and of the instance role of EC2
+// instances, through IMDSv2, are tried next. Credentials of SSO sessions and of roles
+// assumed by profiles aren't supported.
+func LoadAWSCredentials(profile string) (AWSSigV4, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return AWSSigV4{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	credentials, err := loadSharedAWSCredentials(profile)
+	if err == nil || profile != "" {
+		return credentials, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), awsCredentialsTimeout)
+	defer cancel()
+	if os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "" {
+		return loadContainerAWSCredentials(ctx)
+	}
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return AWSSigV4{}, err
+	}
+	credentials, imdsErr := loadInstanceAWSCredentials(ctx)
+	if imdsErr != nil {
+		return AWSSigV4{}, fmt.Errorf("%v, and no instance credentials: %w", err, imdsErr)
+	}
+	return credentials, nil
+}
+
+// awsCredentialsTimeout bounds the requests for container and instance credentials,
+// so machines outside AWS don't wait on the unreachable metadata service.
+const awsCredentialsTimeout = 2 * time.Second
+
+// loadSharedAWSCredentials loads profile, "default" when empty, of the shared
+// credentials file.
+func loadSharedAWSCredentials(profile string) (AWSSigV4, error) {
+	if profile == "" {
+		profile = "default"
+	}
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return AWSSigV4{}, fmt.Errorf("failed to find the AWS credentials file: %w", err)
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return AWSSigV4{}, fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+	defer file.Close()
+
+	var credentials AWSSigV4
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		i := strings.Index(line, "=")
+		if section != profile || i < 0 {
+			continue
+		}
+		value := strings.TrimSpace(line[i+1:])
+		switch strings.TrimSpace(line[:i]) {
+		case "aws_access_key_id":
+			credentials.AccessKeyID = value
+		case "aws_secret_access_key":
+			credentials.SecretAccessKey = value
+		case "aws_session_token":
+			credentials.SessionToken = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return AWSSigV4{}, fmt.Errorf("failed to read AWS credentials: %w", err)
+	}
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return AWSSigV4{}, fmt.Errorf("no AWS credentials found for profile %q in %s", profile, path)
+	}
+	return credentials, nil
+}
+
+// awsRoleCredentials are the temporary credentials served by the container and
+// instance metadata endpoints.
+type awsRoleCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	Token           string
+}
+
+// loadContainerAWSCredentials loads the credentials of the task role of an ECS
+// container from AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or
+// AWS_CONTAINER_CREDENTIALS_FULL_URI, authorized with
+// AWS_CONTAINER_AUTHORIZATION_TOKEN or the token in AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE.
+func loadContainerAWSCredentials(ctx context.Context) (AWSSigV4, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		endpoint = "http://169.254.170.2" + relative
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return AWSSigV4{}, fmt.Errorf("failed to create container credentials request: %w", err)
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if path := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return AWSSigV4{}, fmt.Errorf("failed to read container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	var credentials awsRoleCredentials
+	if err := awsMetadataRequest(req, &credentials); err != nil {
+		return AWSSigV4{}, fmt.Errorf("failed to load container credentials: %w", err)
+	}
+	return credentials.sigV4()
+}
+
+// loadInstanceAWSCredentials loads the credentials of the instance role of an EC2
+// instance through IMDSv2, at AWS_EC2_METADATA_SERVICE_ENDPOINT when set.
+func loadInstanceAWSCredentials(ctx context.Context) (AWSSigV4, error) {
+	endpoint := strings.TrimSuffix(os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), "/")
+	if endpoint == "" {
+		endpoint = "http://169.254.169.254"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return AWSSigV4{}, fmt.Errorf("failed to create instance metadata token request: %w", err)
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	var token string
+	if err := awsMetadataRequest(req, &token); err != nil {
+		return AWSSigV4{}, fmt.Errorf("failed to get instance metadata token: %w", err)
+	}
+
+	get := func(path string, value interface{}) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/latest/meta-data/iam/security-credentials/"+path, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create instance credentials request: %w", err)
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+		return awsMetadataRequest(req, value)
+	}
+	var roles string
+	if err := get("", &roles); err != nil {
+		return AWSSigV4{}, fmt.Errorf("failed to get instance role: %w", err)
+	}
+	role := strings.TrimSpace(strings.SplitN(roles, "\n", 2)[0])
+	if role == "" {
+		return AWSSigV4{}, errors.New("no instance role attached")
+	}
+	var credentials awsRoleCredentials
+	if err := get(role, &credentials); err != nil {
+		return AWSSigV4{}, fmt.Errorf("failed to load instance credentials: %w", err)
+	}
+	return credentials.sigV4()
+}
+
+// awsMetadataClient reaches the metadata endpoints directly, never through the proxy
+// of the environment.
+var awsMetadataClient = &http.Client{Transport: &http.Transport{}}
+
+// awsMetadataRequest sends req, decoding the JSON response into value, or reading it
+// as text when value is a *string.
+func awsMetadataRequest(req *http.Request, value interface{}) error {
+	res, err := awsMetadataClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	if text, ok := value.(*string); ok {
+		*text = string(body)
+		return nil
+	}
+	return json.Unmarshal(body, value)
+}
+
+func (c awsRoleCredentials) sigV4() (AWSSigV4, error) {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return AWSSigV4{}, errors.New("no credentials in the metadata response")
+	}
+	return AWSSigV4{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.Token}, nil
+}
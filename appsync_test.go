@@ -0,0 +1,130 @@
+package gqlfetch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_appSyncHTTPEndpoint(t *testing.T) {
+	tests := map[string]struct {
+		endpoint string
+		expect   string
+	}{
+		"HTTP endpoint": {
+			endpoint: "https://abc.appsync-api.eu-west-1.amazonaws.com/graphql",
+			expect:   "https://abc.appsync-api.eu-west-1.amazonaws.com/graphql",
+		},
+		"real-time endpoint": {
+			endpoint: "wss://abc.appsync-realtime-api.eu-west-1.amazonaws.com/graphql?header=e30=&payload=e30=",
+			expect:   "https://abc.appsync-api.eu-west-1.amazonaws.com/graphql",
+		},
+		"custom domain real-time endpoint": {
+			endpoint: "wss://api.example.com/graphql/realtime",
+			expect:   "https://api.example.com/graphql",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := appSyncHTTPEndpoint(tt.endpoint); got != tt.expect {
+				t.Errorf("expect %v got: %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestAppSyncOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	credentials := "[default]\naws_access_key_id = DEFAULTKEY\naws_secret_access_key = defaultsecret\n\n" +
+		"# deploy role\n[deploy]\naws_access_key_id=DEPLOYKEY\naws_secret_access_key=deploysecret\naws_session_token=token\n"
+	if err := os.WriteFile(path, []byte(credentials), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", path)
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	role := AWSSigV4{Region: "eu-west-1", AccessKeyID: "ROLEKEY", SecretAccessKey: "rolesecret", SessionToken: "roletoken"}
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		credentials := `{"Code": "Success", "AccessKeyId": "ROLEKEY", "SecretAccessKey": "rolesecret", "Token": "roletoken", "Expiration": "2030-01-01T00:00:00Z"}`
+		switch {
+		case r.URL.Path == "/container" && r.Header.Get("Authorization") == "container-token":
+			w.Write([]byte(credentials))
+		case r.URL.Path == "/latest/api/token" && r.Method == http.MethodPut && r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") != "":
+			w.Write([]byte("imds-token"))
+		case r.Header.Get("X-aws-ec2-metadata-token") != "imds-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			w.Write([]byte("app-role"))
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/app-role":
+			w.Write([]byte(credentials))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer metadata.Close()
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := map[string]struct {
+		env       map[string]string
+		apiKey    string
+		expect    Auth
+		expectErr bool
+	}{
+		"API key": {apiKey: "da2-key", expect: APIKey{Key: "da2-key"}},
+		"environment credentials": {
+			env:    map[string]string{"AWS_ACCESS_KEY_ID": "ENVKEY", "AWS_SECRET_ACCESS_KEY": "envsecret"},
+			expect: AWSSigV4{Region: "eu-west-1", AccessKeyID: "ENVKEY", SecretAccessKey: "envsecret"},
+		},
+		"default profile": {
+			expect: AWSSigV4{Region: "eu-west-1", AccessKeyID: "DEFAULTKEY", SecretAccessKey: "defaultsecret"},
+		},
+		"named profile": {
+			env:    map[string]string{"AWS_PROFILE": "deploy"},
+			expect: AWSSigV4{Region: "eu-west-1", AccessKeyID: "DEPLOYKEY", SecretAccessKey: "deploysecret", SessionToken: "token"},
+		},
+		"unknown profile": {env: map[string]string{"AWS_PROFILE": "missing"}, expectErr: true},
+		"container credentials": {
+			env: map[string]string{
+				"AWS_SHARED_CREDENTIALS_FILE":        missing,
+				"AWS_CONTAINER_CREDENTIALS_FULL_URI": metadata.URL + "/container",
+				"AWS_CONTAINER_AUTHORIZATION_TOKEN":  "container-token",
+			},
+			expect: role,
+		},
+		"instance credentials": {
+			env: map[string]string{
+				"AWS_SHARED_CREDENTIALS_FILE":       missing,
+				"AWS_EC2_METADATA_DISABLED":         "",
+				"AWS_EC2_METADATA_SERVICE_ENDPOINT": metadata.URL,
+			},
+			expect: role,
+		},
+		"no credentials": {env: map[string]string{"AWS_SHARED_CREDENTIALS_FILE": missing}, expectErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			options, err := AppSyncOptions("", "abc", "", tt.apiKey)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+			if expect := "https://abc.appsync-api.eu-west-1.amazonaws.com/graphql"; options.Endpoint != expect {
+				t.Errorf("expect endpoint %v got: %v", expect, options.Endpoint)
+			}
+			if !reflect.DeepEqual(options.Auth, tt.expect) {
+				t.Errorf("expect auth %+v got: %+v", tt.expect, options.Auth)
+			}
+		})
+	}
+}
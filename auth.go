@@ -0,0 +1,269 @@
+package gqlfetch
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Auth authenticates the requests sent to the endpoint. Apply is called before every
+// attempt, so implementations can refresh credentials between retries.
+type Auth interface {
+	Apply(req *http.Request) error
+}
+
+// AuthFunc adapts a function to the Auth interface.
+type AuthFunc func(req *http.Request) error
+
+func (f AuthFunc) Apply(req *http.Request) error { return f(req) }
+
+// APIKey sends a static key in a header, such as the x-api-key header of AWS AppSync.
+type APIKey struct {
+	// Header defaults to X-Api-Key.
+	Header string
+	Key    string
+}
+
+func (a APIKey) Apply(req *http.Request) error {
+	header := a.Header
+	if header == "" {
+		header = "X-Api-Key"
+	}
+	req.Header.Set(header, a.Key)
+	return nil
+}
+
+// Token is an access token and the time it expires, zero when it doesn't.
+type Token struct {
+	AccessToken string
+	Expiry      time.Time
+	// RefreshToken obtains a new token once this one expired, when the provider
+	// issued one.
+	RefreshToken string
+}
+
+// tokenExpiryDelta refreshes tokens slightly before they expire, so they don't expire
+// while the request is in flight.
+const tokenExpiryDelta = 10 * time.Second
+
+func (t Token) valid() bool {
+	return t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Add(tokenExpiryDelta).Before(t.Expiry))
+}
+
+// BearerToken sends the token returned by Source as a bearer token, calling Source
+// again once the previous token expired.
+type BearerToken struct {
+	Source func(ctx context.Context) (Token, error)
+
+	mu    sync.Mutex
+	token Token
+}
+
+func (a *BearerToken) Apply(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.token.valid() {
+		token, err := a.Source(req.Context())
+		if err != nil {
+			return fmt.Errorf("failed to refresh token: %w", err)
+		}
+		a.token = token
+	}
+	req.Header.Set("Authorization", "Bearer "+a.token.AccessToken)
+	return nil
+}
+
+// OAuth2ClientCredentials authenticates with a bearer token obtained through the OAuth2
+// client credentials flow, requesting a new token once the previous one expired.
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// HTTPClient sends the token requests, http.DefaultClient when nil.
+	HTTPClient *http.Client
+
+	once   sync.Once
+	bearer *BearerToken
+}
+
+func (a *OAuth2ClientCredentials) Apply(req *http.Request) error {
+	a.once.Do(func() {
+		a.bearer = &BearerToken{Source: a.fetchToken}
+	})
+	return a.bearer.Apply(req)
+}
+
+func (a *OAuth2ClientCredentials) fetchToken(ctx context.Context) (Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(a.ClientID), url.QueryEscape(a.ClientSecret))
+
+	client := a.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return Token{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return Token{}, fmt.Errorf("token request failed: %s: %s", res.Status, body)
+	}
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return Token{}, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if response.AccessToken == "" {
+		return Token{}, fmt.Errorf("token response has no access_token")
+	}
+	token := Token{AccessToken: response.AccessToken}
+	if response.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// AWSSigV4 signs requests with AWS Signature Version 4, as required by AWS AppSync
+// endpoints using IAM authorization.
+type AWSSigV4 struct {
+	Region string
+	// Service defaults to appsync.
+	Service         string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// now is overridden by tests.
+	now func() time.Time
+}
+
+func (a AWSSigV4) Apply(req *http.Request) error {
+	service := a.Service
+	if service == "" {
+		service = "appsync"
+	}
+	now := time.Now
+	if a.now != nil {
+		now = a.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	payload := []byte{}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		payload, err = io.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if a.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	if req.Host != "" {
+		headers["host"] = req.Host
+	}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hashHex(payload),
+	}, "\n")
+
+	scope := strings.Join([]string{date, a.Region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+a.SecretAccessKey), date)
+	for _, part := range []string{a.Region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", a.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalQuery sorts the query parameters and escapes them the way SigV4 expects,
+// encoding spaces as %20 rather than +.
+func canonicalQuery(values url.Values) string {
+	var params []string
+	for name, vals := range values {
+		for _, value := range vals {
+			params = append(params, sigV4Escape(name)+"="+sigV4Escape(value))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+func sigV4Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
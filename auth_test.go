@@ -0,0 +1,183 @@
+package gqlfetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestBuildClientSchemaWithOptions_Auth(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	_, err = BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint: server.URL,
+		Auth:     APIKey{Key: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := map[string]struct {
+		expiry        time.Duration
+		expectFetches int
+	}{
+		"valid token is reused":      {expiry: time.Hour, expectFetches: 1},
+		"expired token is refreshed": {expiry: time.Second, expectFetches: 3},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fetches := 0
+			auth := &BearerToken{Source: func(ctx context.Context) (Token, error) {
+				fetches++
+				return Token{AccessToken: fmt.Sprintf("token-%d", fetches), Expiry: time.Now().Add(tt.expiry)}, nil
+			}}
+			for i := 0; i < 3; i++ {
+				req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+				if err := auth.Apply(req); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got, expect := req.Header.Get("Authorization"), fmt.Sprintf("Bearer token-%d", fetches); got != expect {
+					t.Errorf("expect Authorization: %v got: %v", expect, got)
+				}
+			}
+			if fetches != tt.expectFetches {
+				t.Errorf("expect %d token fetches got: %d", tt.expectFetches, fetches)
+			}
+		})
+	}
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	requests := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "schema:read api" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token": "issued", "token_type": "Bearer", "expires_in": 3600}`))
+	}))
+	defer tokenServer.Close()
+
+	auth := &OAuth2ClientCredentials{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"schema:read", "api"},
+	}
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com", nil)
+		if err := auth.Apply(req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := req.Header.Get("Authorization"); got != "Bearer issued" {
+			t.Errorf("expect issued bearer token got: %v", got)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expect the token to be requested once got: %d", requests)
+	}
+}
+
+// TestAWSSigV4 checks the signer against the get-vanilla case of the AWS Signature
+// Version 4 test suite.
+func TestAWSSigV4(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	auth := AWSSigV4{
+		Region:          "us-east-1",
+		Service:         "service",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		now:             func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) },
+	}
+	if err := auth.Apply(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expect := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != expect {
+		t.Errorf("expect Authorization:\n%v\ngot:\n%v", expect, got)
+	}
+}
+
+func TestBuildClientSchemaWithOptions_Login(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("password") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "sessionid", Value: "session", Path: "/"})
+	})
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("sessionid"); err != nil || cookie.Value != "session" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := map[string]struct {
+		password  string
+		expectErr bool
+	}{
+		"logged in":      {password: "secret"},
+		"login rejected": {password: "wrong", expectErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint: server.URL + "/graphql",
+				Login: func(ctx context.Context, client *http.Client) error {
+					res, err := client.PostForm(server.URL+"/login", url.Values{"password": {tt.password}})
+					if err != nil {
+						return err
+					}
+					res.Body.Close()
+					if res.StatusCode != http.StatusOK {
+						return fmt.Errorf("login failed: %s", res.Status)
+					}
+					return nil
+				},
+			})
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expect error: %v got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package gqlfetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+// CachedSchema is a schema stored in a SchemaCache.
+type CachedSchema struct {
+	Schema string `json:"schema"`
+	// ETag is the entity tag of the response the schema was fetched from, if any.
+	ETag      string    `json:"etag,omitempty"`
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// SchemaCache stores fetched schemas, keyed by a hash of the endpoint, headers and
+// options affecting the result.
+type SchemaCache interface {
+	// Get returns false when no schema is cached for key.
+	Get(key string) (CachedSchema, bool, error)
+	Put(key string, schema CachedSchema) error
+}
+
+// FileCache is a SchemaCache storing every schema as a JSON file in Dir.
+type FileCache struct {
+	Dir string
+}
+
+func (c FileCache) Get(key string) (CachedSchema, bool, error) {
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return CachedSchema{}, false, nil
+	}
+	if err != nil {
+		return CachedSchema{}, false, fmt.Errorf("failed to read cached schema: %w", err)
+	}
+	var schema CachedSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return CachedSchema{}, false, fmt.Errorf("failed to decode cached schema: %w", err)
+	}
+	return schema, true, nil
+}
+
+// Put replaces the cached file atomically, so concurrent builds never read a partially
+// written schema.
+func (c FileCache) Put(key string, schema CachedSchema) error {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached schema: %w", err)
+	}
+	return writeFileAtomic(c.path(key), data)
+}
+
+func (c FileCache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+// errNotModified is returned by execute when a conditional request hits.
+var errNotModified = errors.New("schema not modified")
+
+// cacheKeyIgnored are the options that only change how the schema is fetched, left
+// out of cache keys along with functions and interfaces, which can't be hashed. Every
+// other option is part of the key, so new options can't be forgotten.
+var cacheKeyIgnored = map[string]bool{
+	"PersistedQueries": true,
+	"RetryPolicy":      true,
+	"IgnoreRetryAfter": true,
+	"RateLimit":        true,
+	"Timeout":          true,
+	"HTTPClient":       true,
+	"TLS":              true,
+	"Connection":       true,
+	"ProxyURL":         true,
+	"Middleware":       true,
+	"CacheTTL":         true,
+	"MaxResponseBytes": true,
+}
+
+// cacheKey hashes every option that can change the schema returned for options.
+func cacheKey(options BuildClientSchemaOptions) string {
+	if options.Headers != nil {
+		headers := make(http.Header, len(options.Headers))
+		for name, values := range options.Headers {
+			headers[http.CanonicalHeaderKey(name)] = append(headers[http.CanonicalHeaderKey(name)], values...)
+		}
+		options.Headers = headers
+	}
+
+	hash := sha256.New()
+	value := reflect.ValueOf(options)
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		switch field.Type.Kind() {
+		case reflect.Func, reflect.Interface, reflect.Chan:
+			continue
+		}
+		if field.PkgPath != "" || cacheKeyIgnored[field.Name] {
+			continue
+		}
+		// encoding/json sorts map keys, so maps hash the same whatever their order.
+		encoded, err := json.Marshal(value.Field(i).Interface())
+		if err != nil {
+			encoded = []byte(fmt.Sprintf("%#v", value.Field(i).Interface()))
+		}
+		fmt.Fprintf(hash, "%s=%s\n", field.Name, encoded)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// buildCachedSchema serves the schema from options.Cache while it's fresher than
+// CacheTTL, and otherwise revalidates it with a conditional request when it has an ETag.
+func buildCachedSchema(ctx context.Context, options BuildClientSchemaOptions) (string, error) {
+	cache := options.Cache
+	options.Cache = nil
+	key := cacheKey(options)
+
+	cached, ok, err := cache.Get(key)
+	if err != nil {
+		return "", err
+	}
+	if ok && options.CacheTTL > 0 && time.Since(cached.FetchedAt) < options.CacheTTL {
+		options.debug("serving cached schema", "endpoint", options.Endpoint, "age", time.Since(cached.FetchedAt))
+		return cached.Schema, nil
+	}
+
+	if ok && cached.ETag != "" {
+		options.Headers = options.Headers.Clone()
+		if options.Headers == nil {
+			options.Headers = make(http.Header)
+		}
+		options.Headers.Set("If-None-Match", cached.ETag)
+	}
+	var etag string
+	onResponse := options.OnResponse
+	options.OnResponse = func(info ResponseInfo) {
+		etag = info.Header.Get("ETag")
+		if onResponse != nil {
+			onResponse(info)
+		}
+	}
+
+	schema, err := buildClientSchema(ctx, options)
+	if ok && errors.Is(err, errNotModified) {
+		schema, etag, err = cached.Schema, cached.ETag, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := cache.Put(key, CachedSchema{Schema: schema, ETag: etag, FetchedAt: time.Now()}); err != nil {
+		return "", err
+	}
+	return schema, nil
+}
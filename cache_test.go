@@ -0,0 +1,126 @@
+package gqlfetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildClientSchemaWithOptions_Cache(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	tests := map[string]struct {
+		ttl               time.Duration
+		etag              string
+		expectRequests    int
+		expectNotModified int
+	}{
+		"fresh cache skips the fetch":        {ttl: time.Hour, etag: `"v1"`, expectRequests: 1},
+		"stale cache is revalidated":         {etag: `"v1"`, expectRequests: 2, expectNotModified: 1},
+		"stale cache without etag refetches": {expectRequests: 2},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests, notModified int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if tt.etag != "" && r.Header.Get("If-None-Match") == tt.etag {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(fixture)
+			}))
+			defer server.Close()
+
+			options := BuildClientSchemaOptions{
+				Endpoint: server.URL,
+				Cache:    FileCache{Dir: t.TempDir()},
+				CacheTTL: tt.ttl,
+			}
+			first, err := BuildClientSchemaWithOptions(context.Background(), options)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			second, err := BuildClientSchemaWithOptions(context.Background(), options)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if first != second {
+				t.Errorf("expect the cached schema to match the fetched one")
+			}
+			if requests != tt.expectRequests || notModified != tt.expectNotModified {
+				t.Errorf("expect %d requests and %d not modified got: %d and %d", tt.expectRequests, tt.expectNotModified, requests, notModified)
+			}
+		})
+	}
+}
+
+func Test_cacheKey(t *testing.T) {
+	base := BuildClientSchemaOptions{Endpoint: "https://example.com/graphql", Headers: http.Header{"Authorization": {"a"}}}
+	withHeader := base
+	withHeader.Headers = http.Header{"Authorization": {"b"}}
+	withoutBuiltins := base
+	withoutBuiltins.WithoutBuiltins = true
+
+	if cacheKey(base) != cacheKey(base) {
+		t.Errorf("expect the same key for the same options")
+	}
+	if cacheKey(base) == cacheKey(withHeader) || cacheKey(base) == cacheKey(withoutBuiltins) {
+		t.Errorf("expect headers and output options to change the key")
+	}
+	lowercase := base
+	lowercase.Headers = http.Header{"authorization": {"a"}}
+	if cacheKey(base) != cacheKey(lowercase) {
+		t.Errorf("expect header names to be canonicalized")
+	}
+
+	// Every option that isn't ignored must change the key, such as Source.
+	options := reflect.ValueOf(&base).Elem()
+	for i := 0; i < options.NumField(); i++ {
+		field := options.Type().Field(i)
+		switch field.Type.Kind() {
+		case reflect.Func, reflect.Interface, reflect.Chan:
+			continue
+		}
+		if cacheKeyIgnored[field.Name] {
+			continue
+		}
+		changed := base
+		value := reflect.ValueOf(&changed).Elem().Field(i)
+		switch value.Kind() {
+		case reflect.String:
+			value.SetString(value.String() + "x")
+		case reflect.Bool:
+			value.SetBool(!value.Bool())
+		case reflect.Int, reflect.Int64:
+			value.SetInt(value.Int() + 1)
+		case reflect.Slice:
+			value.Set(reflect.Append(value, reflect.Zero(value.Type().Elem())))
+		case reflect.Map:
+			value.Set(reflect.MakeMap(value.Type()))
+			value.SetMapIndex(reflect.ValueOf("x"), reflect.Zero(value.Type().Elem()))
+		case reflect.Struct:
+			value.Field(0).Set(reflect.ValueOf("x").Convert(value.Field(0).Type()))
+		case reflect.Ptr:
+			value.Set(reflect.New(value.Type().Elem()))
+		default:
+			t.Fatalf("unexpected kind %s of option %s", value.Kind(), field.Name)
+		}
+		if cacheKey(base) == cacheKey(changed) {
+			t.Errorf("expect option %s to change the key", field.Name)
+		}
+	}
+}
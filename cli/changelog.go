@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+
+	"github.com/zucchinho/gqlfetch/diff"
+)
+
+// runChangelog implements "gqlfetch changelog --from <file>", printing Markdown release
+// notes for the changes from an SDL file to the schema of the endpoint, or to the SDL
+// file set with --to.
+func runChangelog(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gqlfetch changelog", flag.ContinueOnError)
+	request := addRequestFlags(flags)
+	from := flags.String("from", "", "SDL file of the previous schema version")
+	to := flags.String("to", "", "SDL file of the new schema version, fetched from --endpoint when empty")
+	title := flags.String("title", "Schema changelog", "heading of the changelog")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" {
+		return errors.New("usage: gqlfetch changelog --from <file> (--to <file> | --endpoint <url>)")
+	}
+
+	var changes []diff.Change
+	if *to != "" {
+		oldSchema, err := diff.LoadSchemaFile(*from)
+		if err != nil {
+			return err
+		}
+		newSchema, err := diff.LoadSchemaFile(*to)
+		if err != nil {
+			return err
+		}
+		changes = diff.Schemas(oldSchema, newSchema)
+	} else {
+		options, err := request.options()
+		if err != nil {
+			return err
+		}
+		if changes, err = diff.EndpointAgainstFile(ctx, options, *from); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(stdout, diff.Changelog(changes, *title))
+	return err
+}
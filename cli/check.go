@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/zucchinho/gqlfetch/diff"
+)
+
+// runCheck implements "gqlfetch check --against <file>", printing the changes of the
+// schema relative to a baseline file and failing when any of them is breaking.
+func runCheck(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gqlfetch check", flag.ContinueOnError)
+	request := addRequestFlags(flags)
+	against := flags.String("against", "", "baseline SDL file to compare the schema to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *against == "" {
+		return errors.New("usage: gqlfetch check --against <file> --endpoint <url>")
+	}
+
+	options, err := request.options()
+	if err != nil {
+		return err
+	}
+	changes, err := diff.Check(ctx, options, *against)
+	for _, change := range changes {
+		fmt.Fprintln(stdout, change)
+	}
+	return err
+}
@@ -0,0 +1,385 @@
+// Package cli implements the gqlfetch command line tool.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/zucchinho/gqlfetch"
+	"github.com/zucchinho/gqlfetch/export"
+	"github.com/zucchinho/gqlfetch/internal/envexpand"
+)
+
+// stringFlags collects the values of a repeated flag, such as --header.
+type stringFlags []string
+
+func (h *stringFlags) String() string { return strings.Join(*h, ", ") }
+
+func (h *stringFlags) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+// Run runs gqlfetch with the given arguments, excluding the program name, writing the
+// schema to standard output unless --output is set, the definition of a single type
+// with "gqlfetch type <name>", or the schema of an AWS AppSync API with
+// "gqlfetch appsync". "gqlfetch view" serves GraphQL Voyager for the schema and
+// "gqlfetch lint" checks it against naming and design rules, while "gqlfetch check"
+// fails on breaking changes relative to a baseline file. "gqlfetch changelog" prints
+// release notes for the changes since a previous version and "gqlfetch compose" a
+// federation supergraph. "gqlfetch probe" checks that the endpoint allows
+// introspection and "gqlfetch login" logs in with an SSO provider, saving a token
+// sent to the endpoint by later runs. ${VAR} and $VAR references in the endpoint and
+// header values are expanded from the environment, so go:generate directives don't
+// depend on a shell.
+func Run(ctx context.Context, args []string) error {
+	return run(ctx, args, os.Stdout)
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) > 0 && args[0] == "type" {
+		return runType(ctx, args[1:], stdout)
+	}
+	if len(args) > 0 && args[0] == "appsync" {
+		return runAppSync(ctx, args[1:], stdout)
+	}
+	if len(args) > 0 && args[0] == "view" {
+		return runView(ctx, args[1:], stdout)
+	}
+	if len(args) > 0 && args[0] == "lint" {
+		return runLint(ctx, args[1:], stdout)
+	}
+	if len(args) > 0 && args[0] == "check" {
+		return runCheck(ctx, args[1:], stdout)
+	}
+	if len(args) > 0 && args[0] == "changelog" {
+		return runChangelog(ctx, args[1:], stdout)
+	}
+	if len(args) > 0 && args[0] == "compose" {
+		return runCompose(ctx, args[1:], stdout)
+	}
+	if len(args) > 0 && args[0] == "probe" {
+		return runProbe(ctx, args[1:], stdout)
+	}
+	if len(args) > 0 && args[0] == "login" {
+		return runLogin(ctx, args[1:], stdout)
+	}
+
+	flags := flag.NewFlagSet("gqlfetch", flag.ContinueOnError)
+	request := addRequestFlags(flags)
+	format := addFormatFlags(flags)
+	withoutBuiltins := flags.Bool("without-builtins", false, "leave out built-in scalars and directives")
+	var keepDirectives, keepScalars stringFlags
+	flags.Var(&keepDirectives, "keep-directive", "built-in directive printed despite --without-builtins, may be repeated")
+	flags.Var(&keepScalars, "keep-scalar", "built-in scalar printed despite --without-builtins, may be repeated")
+	lenient := flags.Bool("lenient", false, "leave out types of unknown kinds, printed as comments, instead of failing")
+	typePrefix := flags.String("type-prefix", "", "prefix of the type names other than root operation types and built-ins, such as Billing_")
+	output := flags.String("output", "", "write the schema to this file instead of standard output")
+	gqlparserFormatter := flags.Bool("gqlparser-formatter", false, "print the SDL with gqlparser's formatter once validated, instead of the built-in printer")
+	stats := flags.Bool("stats", false, "print schema statistics as JSON instead of the schema, which is still written to --output")
+	hash := flags.String("hash", "", `emit the schema hash as a trailing "comment", or to a .sha256 "file" next to --output`)
+	exportFormat := flags.String("export", "", "write the types as jsonschema, openapi, proto or typescript, documentation as markdown or html, a field inventory as csv or jsonl, or a type graph as dot or mermaid, instead of SDL")
+	graphRoot := flags.String("graph-root", "", "type the dot and mermaid graphs start from, the root operation types by default")
+	graphDepth := flags.Int("graph-depth", 0, "maximum number of relationships the dot and mermaid graphs follow from the root, 0 for no limit")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case *hash != "" && *hash != "comment" && *hash != "file":
+		return fmt.Errorf("invalid --hash %q, expected comment or file", *hash)
+	case *hash == "file" && *output == "":
+		return errors.New("--hash file requires --output")
+	}
+
+	options, err := request.options()
+	if err != nil {
+		return err
+	}
+	options.WithoutBuiltins = *withoutBuiltins
+	options.KeepDirectives, options.KeepScalars = keepDirectives, keepScalars
+	options.Lenient = *lenient
+	options.TypePrefix = *typePrefix
+	options.GQLParserFormatter = *gqlparserFormatter
+	if options.SDLFormat, err = format.format(); err != nil {
+		return err
+	}
+	if *exportFormat != "" {
+		graph := export.GraphOptions{Root: *graphRoot, Depth: *graphDepth}
+		return runExport(ctx, options, *exportFormat, graph, *output, stdout)
+	}
+
+	if *output != "" {
+		err := writeSchema(ctx, options, *output, *hash)
+		if err != nil || !*stats {
+			return err
+		}
+		sdl, err := os.ReadFile(*output)
+		if err != nil {
+			return err
+		}
+		schema, err := gqlfetch.LoadSchema(&ast.Source{Name: *output, Input: string(sdl)})
+		if err != nil {
+			return err
+		}
+		return writeStats(stdout, schema)
+	}
+	if *stats {
+		schema, err := gqlfetch.BuildClientSchemaAST(ctx, options)
+		if err != nil {
+			return err
+		}
+		return writeStats(stdout, schema)
+	}
+	schema, err := gqlfetch.BuildClientSchemaWithOptions(ctx, options)
+	if err != nil {
+		return err
+	}
+	if *hash == "comment" {
+		schema = withHashComment(schema, gqlfetch.SchemaHash(schema))
+	}
+	_, err = io.WriteString(stdout, schema)
+	return err
+}
+
+// runExport writes the types of the schema in another type system.
+func runExport(ctx context.Context, options gqlfetch.BuildClientSchemaOptions, format string, graph export.GraphOptions, output string, stdout io.Writer) error {
+	exporters := map[string]func(*ast.Schema) ([]byte, error){
+		"jsonschema": export.JSONSchema,
+		"openapi": func(schema *ast.Schema) ([]byte, error) {
+			return export.OpenAPI(schema, "GraphQL schema", "1.0.0")
+		},
+		"proto": func(schema *ast.Schema) ([]byte, error) {
+			return export.Proto(schema, "")
+		},
+		"typescript": export.TypeScript,
+		"markdown": func(schema *ast.Schema) ([]byte, error) {
+			return export.Markdown(schema, "GraphQL schema")
+		},
+		"html": func(schema *ast.Schema) ([]byte, error) {
+			return export.HTML(schema, "GraphQL schema")
+		},
+		"csv":   export.CSV,
+		"jsonl": export.JSONL,
+		"dot": func(schema *ast.Schema) ([]byte, error) {
+			return export.DOT(schema, graph)
+		},
+		"mermaid": func(schema *ast.Schema) ([]byte, error) {
+			return export.Mermaid(schema, graph)
+		},
+	}
+	exporter, ok := exporters[format]
+	if !ok {
+		return fmt.Errorf("invalid --export %q, expected jsonschema, openapi, proto, typescript, markdown, html, csv, jsonl, dot or mermaid", format)
+	}
+	schema, err := gqlfetch.BuildClientSchemaAST(ctx, options)
+	if err != nil {
+		return err
+	}
+	out, err := exporter(schema)
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		_, err := gqlfetch.WriteSchemaFile(output, string(out))
+		return err
+	}
+	_, err = stdout.Write(out)
+	return err
+}
+
+// writeSchema writes the schema to output, with its hash as a trailing comment or in
+// output.sha256 depending on hash.
+func writeSchema(ctx context.Context, options gqlfetch.BuildClientSchemaOptions, output, hash string) error {
+	if hash == "" {
+		_, err := gqlfetch.BuildClientSchemaToFile(ctx, options, output)
+		return err
+	}
+	schema, err := gqlfetch.BuildClientSchemaWithOptions(ctx, options)
+	if err != nil {
+		return err
+	}
+	sum := gqlfetch.SchemaHash(schema)
+	if hash == "comment" {
+		_, err := gqlfetch.WriteSchemaFile(output, withHashComment(schema, sum))
+		return err
+	}
+	if _, err := gqlfetch.WriteSchemaFile(output, schema); err != nil {
+		return err
+	}
+	_, err = gqlfetch.WriteSchemaFile(output+".sha256", sum+"\n")
+	return err
+}
+
+func withHashComment(schema, hash string) string {
+	return strings.TrimRight(schema, "\n") + "\n\n# sha256: " + hash + "\n"
+}
+
+// runType implements "gqlfetch type <name>", printing the definition of a single type.
+func runType(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gqlfetch type", flag.ContinueOnError)
+	request := addRequestFlags(flags)
+	format := addFormatFlags(flags)
+
+	// Allow the type name before the flags, as in "gqlfetch type User --endpoint ...".
+	var typeName string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		typeName, args = args[0], args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if typeName == "" {
+		typeName = flags.Arg(0)
+	}
+	if typeName == "" {
+		return errors.New("usage: gqlfetch type <name> --endpoint <url>")
+	}
+
+	options, err := request.options()
+	if err != nil {
+		return err
+	}
+	if options.SDLFormat, err = format.format(); err != nil {
+		return err
+	}
+	sdl, err := gqlfetch.GetTypeSDL(ctx, options, typeName)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdout, sdl)
+	return err
+}
+
+// runAppSync implements "gqlfetch appsync", introspecting an AWS AppSync API with an
+// API key or the default AWS credentials.
+func runAppSync(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gqlfetch appsync", flag.ContinueOnError)
+	endpoint := flags.String("endpoint", "", "HTTP or real-time endpoint of the API, derived from --api-id and --region when empty")
+	apiID := flags.String("api-id", "", "AppSync API ID")
+	region := flags.String("region", "", "AWS region, AWS_REGION when empty")
+	apiKey := flags.String("api-key", "", "API key, requests are signed with the default AWS credentials when empty")
+	withoutBuiltins := flags.Bool("without-builtins", false, "leave out built-in scalars and directives")
+	output := flags.String("output", "", "write the schema to this file instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	key, err := envexpand.Expand(*apiKey)
+	if err != nil {
+		return err
+	}
+	options, err := gqlfetch.AppSyncOptions(*endpoint, *apiID, *region, key)
+	if err != nil {
+		return err
+	}
+	options.WithoutBuiltins = *withoutBuiltins
+
+	if *output != "" {
+		_, err := gqlfetch.BuildClientSchemaToFile(ctx, options, *output)
+		return err
+	}
+	schema, err := gqlfetch.BuildClientSchemaWithOptions(ctx, options)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(stdout, schema)
+	return err
+}
+
+// requestFlags are the flags describing the introspection request.
+type requestFlags struct {
+	endpoint *string
+	method   *string
+	preset   *string
+	headers  stringFlags
+}
+
+func addRequestFlags(flags *flag.FlagSet) *requestFlags {
+	request := &requestFlags{
+		endpoint: flags.String("endpoint", "", "GraphQL endpoint URL, a unix:///path.sock:/graphql socket URL, a file:// URL or - to read an introspection result"),
+		method:   flags.String("method", http.MethodPost, "HTTP method of the introspection request"),
+		preset:   flags.String("preset", "", "well-known API, such as github, gitlab or shopify, filling in the endpoint and headers"),
+	}
+	flags.Var(&request.headers, "header", `request header as "Name: value", may be repeated`)
+	return request
+}
+
+func (f *requestFlags) options() (gqlfetch.BuildClientSchemaOptions, error) {
+	options := gqlfetch.BuildClientSchemaOptions{
+		Method: *f.method,
+		Preset: *f.preset,
+	}
+	var err error
+	if options.Endpoint, err = envexpand.Expand(*f.endpoint); err != nil {
+		return options, err
+	}
+	if options.Endpoint == "" && options.Preset == "" {
+		return options, errors.New("--endpoint or --preset is required")
+	}
+	if options.Headers, err = parseHeaders(f.headers); err != nil {
+		return options, err
+	}
+	return withSavedLogin(options)
+}
+
+// formatFlags are the flags laying out the printed SDL.
+type formatFlags struct {
+	indent         *string
+	trailingCommas *bool
+	wrapWidth      *int
+}
+
+func addFormatFlags(flags *flag.FlagSet) *formatFlags {
+	return &formatFlags{
+		indent:         flags.String("indent", "tab", `indentation of the SDL, "tab" or a number of spaces`),
+		trailingCommas: flags.Bool("trailing-commas", false, "end arguments printed on their own line with a comma"),
+		wrapWidth:      flags.Int("wrap-width", 0, "print arguments on the line of their field when it fits in this many characters, 0 for one argument per line"),
+	}
+}
+
+func (f *formatFlags) format() (gqlfetch.SDLFormat, error) {
+	format := gqlfetch.SDLFormat{TrailingCommas: *f.trailingCommas, WrapWidth: *f.wrapWidth}
+	if *f.indent != "tab" {
+		width, err := strconv.Atoi(*f.indent)
+		if err != nil || width < 1 {
+			return format, fmt.Errorf("invalid --indent %q, expected tab or a number of spaces", *f.indent)
+		}
+		format.Indent = strings.Repeat(" ", width)
+	}
+	if format.WrapWidth < 0 {
+		return format, fmt.Errorf("invalid --wrap-width %d", format.WrapWidth)
+	}
+	return format, nil
+}
+
+// parseHeaders parses "Name: value" flags, expanding environment variables in values.
+func parseHeaders(headers []string) (http.Header, error) {
+	parsed := make(http.Header)
+	for _, header := range headers {
+		i := strings.Index(header, ":")
+		if i < 1 {
+			return nil, fmt.Errorf("invalid header %q, expected \"Name: value\"", header)
+		}
+		value, err := envexpand.Expand(strings.TrimSpace(header[i+1:]))
+		if err != nil {
+			return nil, err
+		}
+		parsed.Add(strings.TrimSpace(header[:i]), value)
+	}
+	return parsed, nil
+}
+
+func writeStats(stdout io.Writer, schema *ast.Schema) error {
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(gqlfetch.Stats(schema))
+}
@@ -0,0 +1,408 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/zucchinho/gqlfetch"
+)
+
+func TestRun_ExpandsEnvironment(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	t.Setenv("GQLFETCH_TEST_URL", server.URL)
+	t.Setenv("GQLFETCH_TEST_TOKEN", "secret")
+
+	tests := map[string]struct {
+		args      []string
+		expectErr bool
+	}{
+		"expanded": {
+			args: []string{"--endpoint", "${GQLFETCH_TEST_URL}", "--header", "Authorization: Bearer $GQLFETCH_TEST_TOKEN"},
+		},
+		"unset variable": {
+			args:      []string{"--endpoint", "${GQLFETCH_TEST_URL}", "--header", "Authorization: Bearer ${GQLFETCH_TEST_UNSET}"},
+			expectErr: true,
+		},
+		"invalid header": {
+			args:      []string{"--endpoint", "${GQLFETCH_TEST_URL}", "--header", "Authorization"},
+			expectErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			err := run(context.Background(), tt.args, stdout)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if !tt.expectErr && !strings.Contains(stdout.String(), "type User implements Node") {
+				t.Errorf("expect the schema on standard output got:\n%v", stdout)
+			}
+		})
+	}
+}
+
+func TestRun_Stats(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "schema.graphql")
+	tests := map[string]struct {
+		args []string
+	}{
+		"standard output": {args: []string{"--endpoint", server.URL, "--stats"}},
+		"output file":     {args: []string{"--endpoint", server.URL, "--stats", "--output", output}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			if err := run(context.Background(), tt.args, stdout); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var stats gqlfetch.SchemaStats
+			if err := json.Unmarshal([]byte(stdout.String()), &stats); err != nil {
+				t.Fatalf("expect JSON statistics got: %v\n%v", err, stdout)
+			}
+			if stats.Types[ast.Object] == 0 || stats.MaxDepth == 0 {
+				t.Errorf("expect object types and a depth got: %+v", stats)
+			}
+		})
+	}
+	if _, err := os.Stat(output); err != nil {
+		t.Errorf("expect the schema to be written to --output got: %v", err)
+	}
+}
+
+func TestRun_Type(t *testing.T) {
+	tests := map[string]struct {
+		args      []string
+		expectErr bool
+	}{
+		"name first": {args: []string{"type", "Role", "--endpoint", "file://../testdata/introspection.json"}},
+		"name last":  {args: []string{"type", "--endpoint", "file://../testdata/introspection.json", "Role"}},
+		"no name":    {args: []string{"type", "--endpoint", "file://../testdata/introspection.json"}, expectErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			err := run(context.Background(), tt.args, stdout)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if !tt.expectErr && !strings.HasPrefix(stdout.String(), "enum Role {") {
+				t.Errorf("expect the Role enum got:\n%v", stdout)
+			}
+		})
+	}
+}
+
+func TestRun_Format(t *testing.T) {
+	tests := map[string]struct {
+		args      []string
+		expected  string
+		expectErr bool
+	}{
+		"tabs":          {args: []string{"type", "User"}, expected: "\tid: ID!"},
+		"spaces":        {args: []string{"type", "User", "--indent", "2"}, expected: "\n  id: ID!"},
+		"invalid width": {args: []string{"type", "User", "--indent", "two"}, expectErr: true},
+		"kept built-in": {args: []string{"--without-builtins", "--keep-scalar", "ID"}, expected: "\nscalar ID\n"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			err := run(context.Background(), append(tt.args, "--endpoint", "file://../testdata/introspection.json"), stdout)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if !strings.Contains(stdout.String(), tt.expected) {
+				t.Errorf("expect %q got:\n%v", tt.expected, stdout)
+			}
+		})
+	}
+}
+
+func TestRun_Hash(t *testing.T) {
+	const endpoint = "file://../testdata/introspection.json"
+	dir := t.TempDir()
+	tests := map[string]struct {
+		args      []string
+		read      string
+		expectErr bool
+	}{
+		"trailing comment": {args: []string{"--endpoint", endpoint, "--hash", "comment"}},
+		"comment in output file": {
+			args: []string{"--endpoint", endpoint, "--hash", "comment", "--output", filepath.Join(dir, "comment.graphql")},
+			read: filepath.Join(dir, "comment.graphql"),
+		},
+		"sha256 file": {
+			args: []string{"--endpoint", endpoint, "--hash", "file", "--output", filepath.Join(dir, "schema.graphql")},
+			read: filepath.Join(dir, "schema.graphql.sha256"),
+		},
+		"file without output": {args: []string{"--endpoint", endpoint, "--hash", "file"}, expectErr: true},
+		"unknown mode":        {args: []string{"--endpoint", endpoint, "--hash", "md5"}, expectErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			err := run(context.Background(), tt.args, stdout)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+			got := stdout.String()
+			if tt.read != "" {
+				data, err := os.ReadFile(tt.read)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				got = string(data)
+			}
+			schema, err := gqlfetch.BuildClientSchemaWithOptions(context.Background(), gqlfetch.BuildClientSchemaOptions{Endpoint: endpoint})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if hash := gqlfetch.SchemaHash(schema); !strings.HasSuffix(got, hash+"\n") {
+				t.Errorf("expect the output to end with hash %s got:\n%v", hash, got)
+			}
+		})
+	}
+}
+
+func TestRun_Export(t *testing.T) {
+	tests := map[string]struct {
+		format    string
+		expect    string
+		expectErr bool
+	}{
+		"JSON Schema": {format: "jsonschema", expect: `"$schema": "https://json-schema.org/draft/2020-12/schema"`},
+		"OpenAPI":     {format: "openapi", expect: `"openapi": "3.1.0"`},
+		"protobuf":    {format: "proto", expect: `syntax = "proto3";`},
+		"TypeScript":  {format: "typescript", expect: "export interface User {"},
+		"Markdown":    {format: "markdown", expect: "### User\n"},
+		"HTML":        {format: "html", expect: `<section id="user">`},
+		"CSV":         {format: "csv", expect: "type,kind,field,return_type,"},
+		"JSON Lines":  {format: "jsonl", expect: `"type":"User"`},
+		"DOT":         {format: "dot", expect: "digraph schema {"},
+		"Mermaid":     {format: "mermaid", expect: "flowchart LR\n"},
+		"unknown":     {format: "xsd", expectErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			err := run(context.Background(), []string{"--endpoint", "file://../testdata/introspection.json", "--export", tt.format}, stdout)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if !strings.Contains(stdout.String(), tt.expect) {
+				t.Errorf("expect output to contain %q got:\n%v", tt.expect, stdout)
+			}
+		})
+	}
+}
+
+func TestRun_Lint(t *testing.T) {
+	tests := map[string]struct {
+		args      []string
+		expect    string
+		expectErr bool
+	}{
+		"warnings":       {expect: "warning Role.GUEST: enum value Role.GUEST is deprecated without a reason (deprecation-reasons)\n"},
+		"JSON":           {args: []string{"--rule", "descriptions=off", "--format", "json"}, expect: `"rule": "deprecation-reasons"`},
+		"errors fail":    {args: []string{"--rule", "deprecation-reasons=error"}, expect: "error Role.GUEST", expectErr: true},
+		"invalid rule":   {args: []string{"--rule", "deprecation-reasons"}, expectErr: true},
+		"invalid format": {args: []string{"--format", "xml"}, expectErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			args := append([]string{"lint", "--endpoint", "file://../testdata/introspection.json"}, tt.args...)
+			err := run(context.Background(), args, stdout)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if !strings.Contains(stdout.String(), tt.expect) {
+				t.Errorf("expect output to contain %q got:\n%v", tt.expect, stdout)
+			}
+		})
+	}
+}
+
+func TestRun_Check(t *testing.T) {
+	stdout := &strings.Builder{}
+	if err := run(context.Background(), []string{"--endpoint", "file://../testdata/introspection.json"}, stdout); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sdl := stdout.String()
+
+	tests := map[string]struct {
+		baseline  string
+		expect    string
+		expectErr bool
+	}{
+		"unchanged":     {baseline: sdl},
+		"field removed": {baseline: strings.Replace(sdl, "type Query {\n", "type Query {\n\tlegacy: String\n", 1), expect: "REMOVED Query.legacy: field Query.legacy was removed (breaking)\n", expectErr: true},
+		"type removed":  {baseline: sdl + "\ntype Group {\n\tid: ID!\n}\n", expect: "REMOVED Group: object Group was removed (breaking)\n", expectErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			baseline := filepath.Join(t.TempDir(), "schema.graphql")
+			if err := os.WriteFile(baseline, []byte(tt.baseline), 0o644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			stdout := &strings.Builder{}
+			err := run(context.Background(), []string{"check", "--endpoint", "file://../testdata/introspection.json", "--against", baseline}, stdout)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if stdout.String() != tt.expect {
+				t.Errorf("expect output %q got: %q", tt.expect, stdout)
+			}
+		})
+	}
+}
+
+func TestRun_Changelog(t *testing.T) {
+	dir := t.TempDir()
+	from, to := filepath.Join(dir, "v1.graphql"), filepath.Join(dir, "v2.graphql")
+	if err := os.WriteFile(from, []byte("type Query {\n\tuser: String\n}\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.WriteFile(to, []byte("type Query {\n\tuser: String\n\tusers: [String!]\n}\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		args      []string
+		expect    string
+		expectErr bool
+	}{
+		"files":        {args: []string{"--from", from, "--to", to, "--title", "v2"}, expect: "# v2\n\n## Query\n\n### Added\n\n- field Query.users was added\n"},
+		"endpoint":     {args: []string{"--from", from, "--endpoint", "file://../testdata/introspection.json"}, expect: "# Schema changelog\n"},
+		"missing from": {args: []string{"--to", to}, expectErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			err := run(context.Background(), append([]string{"changelog"}, tt.args...), stdout)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if !strings.HasPrefix(stdout.String(), tt.expect) {
+				t.Errorf("expect output to start with %q got:\n%v", tt.expect, stdout)
+			}
+		})
+	}
+}
+
+func TestRun_Compose(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var params struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&params)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(params.Query, "_service") {
+			w.Write([]byte(`{"errors":[{"message":"Cannot query field \"_service\" on type \"Query\"."}]}`))
+			return
+		}
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	tests := map[string]struct {
+		args      []string
+		expect    string
+		expectErr bool
+	}{
+		"introspected subgraph": {
+			args:   []string{"--subgraph", "users=" + server.URL, "--header", "Authorization: Bearer token"},
+			expect: `USERS @join__graph(name: "users", url: "` + server.URL + `")`,
+		},
+		"invalid subgraph": {args: []string{"--subgraph", server.URL}, expectErr: true},
+		"no subgraphs":     {expectErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			err := run(context.Background(), append([]string{"compose"}, tt.args...), stdout)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if !strings.Contains(stdout.String(), tt.expect) {
+				t.Errorf("expect output to contain %q got:\n%v", tt.expect, stdout)
+			}
+		})
+	}
+}
+
+func TestRun_Probe(t *testing.T) {
+	tests := map[string]struct {
+		response  string
+		expected  string
+		expectErr bool
+	}{
+		"introspectable": {
+			response: `{"data": {"__typename": "Query", "__schema": {"queryType": {"name": "Query"}}}}`,
+			expected: `"introspectable": true`,
+		},
+		"graphql errors": {
+			response:  `{"data": {"__typename": "Query"}, "errors": [{"message": "Introspection is disabled"}]}`,
+			expected:  `"reason": "graphql_errors"`,
+			expectErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			stdout := &strings.Builder{}
+			err := run(context.Background(), []string{"probe", "--endpoint", server.URL}, stdout)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if !strings.Contains(stdout.String(), tt.expected) {
+				t.Errorf("expect %q got:\n%v", tt.expected, stdout)
+			}
+		})
+	}
+}
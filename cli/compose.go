@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zucchinho/gqlfetch"
+	"github.com/zucchinho/gqlfetch/compose"
+	"github.com/zucchinho/gqlfetch/internal/envexpand"
+)
+
+// runCompose implements "gqlfetch compose", fetching every subgraph and printing the
+// federation v2 supergraph composed from them.
+func runCompose(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gqlfetch compose", flag.ContinueOnError)
+	var subgraphFlags, headers stringFlags
+	flags.Var(&subgraphFlags, "subgraph", `subgraph as "name=url", may be repeated`)
+	flags.Var(&headers, "header", `request header sent to every subgraph as "Name: value", may be repeated`)
+	output := flags.String("output", "", "write the supergraph to this file instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if len(subgraphFlags) == 0 {
+		return errors.New("usage: gqlfetch compose --subgraph <name>=<url> ...")
+	}
+
+	header, err := parseHeaders(headers)
+	if err != nil {
+		return err
+	}
+	var subgraphs []compose.Subgraph
+	for _, subgraph := range subgraphFlags {
+		i := strings.Index(subgraph, "=")
+		if i < 1 {
+			return fmt.Errorf("invalid subgraph %q, expected \"name=url\"", subgraph)
+		}
+		url, err := envexpand.Expand(subgraph[i+1:])
+		if err != nil {
+			return err
+		}
+		subgraphs = append(subgraphs, compose.Subgraph{
+			Name:    subgraph[:i],
+			URL:     url,
+			Options: gqlfetch.BuildClientSchemaOptions{Headers: header},
+		})
+	}
+
+	supergraph, err := compose.Compose(ctx, subgraphs)
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		_, err := gqlfetch.WriteSchemaFile(*output, supergraph)
+		return err
+	}
+	_, err = io.WriteString(stdout, supergraph)
+	return err
+}
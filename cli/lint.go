@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zucchinho/gqlfetch/lint"
+)
+
+// runLint implements "gqlfetch lint", printing the lint findings of the schema and
+// failing when any has the error severity.
+func runLint(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gqlfetch lint", flag.ContinueOnError)
+	request := addRequestFlags(flags)
+	var rules stringFlags
+	flags.Var(&rules, "rule", `rule severity as "name=error|warning|info|off", may be repeated`)
+	format := flags.String("format", "text", "print the findings as text or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("invalid --format %q, expected text or json", *format)
+	}
+
+	config := lint.Config{Severities: map[string]lint.Severity{}}
+	for _, rule := range rules {
+		i := strings.Index(rule, "=")
+		if i < 1 {
+			return fmt.Errorf("invalid rule %q, expected \"name=severity\"", rule)
+		}
+		config.Severities[rule[:i]] = lint.Severity(rule[i+1:])
+	}
+	options, err := request.options()
+	if err != nil {
+		return err
+	}
+	findings, err := lint.Endpoint(ctx, options, config)
+	if err != nil {
+		return err
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if findings == nil {
+			findings = []lint.Finding{}
+		}
+		if err := encoder.Encode(findings); err != nil {
+			return err
+		}
+	} else {
+		for _, finding := range findings {
+			fmt.Fprintln(stdout, finding)
+		}
+	}
+
+	failed := 0
+	for _, finding := range findings {
+		if finding.Severity == lint.Error {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("lint found %d errors", failed)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/zucchinho/gqlfetch"
+)
+
+// tokenStore saves the logins of "gqlfetch login". It is replaced by tests.
+var tokenStore gqlfetch.TokenStore = gqlfetch.Keychain{}
+
+// openBrowser opens url in the default browser. It is replaced by tests.
+var openBrowser = func(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+// runLogin implements "gqlfetch login", logging in with an SSO provider through the
+// OAuth2 device flow or a browser and saving the token in the keychain, where later
+// fetches of the endpoint find it.
+func runLogin(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gqlfetch login", flag.ContinueOnError)
+	request := addRequestFlags(flags)
+	clientID := flags.String("client-id", "", "OAuth2 client ID of gqlfetch registered with the SSO provider")
+	tokenURL := flags.String("token-url", "", "OAuth2 token endpoint of the SSO provider")
+	authorizationURL := flags.String("authorization-url", "", "OAuth2 authorization endpoint opened in a browser")
+	deviceURL := flags.String("device-url", "", "OAuth2 device authorization endpoint, for the device flow")
+	device := flags.Bool("device", false, "log in with the device flow, entering a code in a browser possibly on another machine")
+	logout := flags.Bool("logout", false, "delete the saved login instead of logging in")
+	var scopes stringFlags
+	flags.Var(&scopes, "scope", "OAuth2 scope requested, may be repeated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options, err := request.options()
+	if err != nil {
+		return err
+	}
+	key := loginKey(options)
+	if *logout {
+		if err := tokenStore.Delete(key); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Logged out of %s\n", key)
+		return nil
+	}
+	switch {
+	case *clientID == "" || *tokenURL == "":
+		return errors.New("--client-id and --token-url are required")
+	case *device && *deviceURL == "":
+		return errors.New("--device requires --device-url")
+	case !*device && *authorizationURL == "":
+		return errors.New("--authorization-url, or --device with --device-url, is required")
+	}
+
+	provider := gqlfetch.OAuth2Login{
+		ClientID:               *clientID,
+		Scopes:                 scopes,
+		TokenURL:               *tokenURL,
+		DeviceAuthorizationURL: *deviceURL,
+		AuthorizationURL:       *authorizationURL,
+	}
+	var token gqlfetch.Token
+	if *device {
+		token, err = provider.Device(ctx, func(authorization gqlfetch.DeviceAuthorization) error {
+			if authorization.VerificationURIComplete != "" {
+				_, err := fmt.Fprintf(stdout, "Open %s to log in, checking that it shows the code %s\n", authorization.VerificationURIComplete, authorization.UserCode)
+				return err
+			}
+			_, err := fmt.Fprintf(stdout, "Open %s and enter the code %s to log in\n", authorization.VerificationURI, authorization.UserCode)
+			return err
+		})
+	} else {
+		token, err = provider.Browser(ctx, func(authorizationURL string) error {
+			fmt.Fprintf(stdout, "Opening %s to log in\n", authorizationURL)
+			if err := openBrowser(authorizationURL); err != nil {
+				fmt.Fprintf(stdout, "Failed to open a browser, open the URL above instead: %v\n", err)
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	login := gqlfetch.SavedLogin{Token: token, TokenURL: *tokenURL, ClientID: *clientID, Scopes: scopes}
+	if err := tokenStore.Save(key, login); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "Logged in to %s\n", key)
+	return err
+}
+
+// loginKey is the key the login for the endpoint of options is saved under.
+func loginKey(options gqlfetch.BuildClientSchemaOptions) string {
+	if options.Endpoint == "" {
+		return "preset:" + options.Preset
+	}
+	return options.Endpoint
+}
+
+// withSavedLogin authenticates the requests of options with the login saved for its
+// endpoint, unless they already carry an Authorization header or no login is saved.
+func withSavedLogin(options gqlfetch.BuildClientSchemaOptions) (gqlfetch.BuildClientSchemaOptions, error) {
+	if options.Headers.Get("Authorization") != "" || options.Endpoint == gqlfetch.StdinEndpoint || strings.HasPrefix(options.Endpoint, "file://") {
+		return options, nil
+	}
+	key := loginKey(options)
+	_, err := tokenStore.Load(key)
+	if errors.Is(err, gqlfetch.ErrNotLoggedIn) || errors.Is(err, gqlfetch.ErrNoKeychain) {
+		return options, nil
+	}
+	if err != nil {
+		return options, err
+	}
+	options.Auth = &gqlfetch.StoredLogin{Store: tokenStore, Key: key}
+	return options, nil
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/zucchinho/gqlfetch"
+)
+
+// TestMain keeps the tests away from the keychain of the machine running them.
+func TestMain(m *testing.M) {
+	tokenStore = memoryTokenStore{}
+	openBrowser = func(string) error { return errors.New("no browser in tests") }
+	os.Exit(m.Run())
+}
+
+// memoryTokenStore is a TokenStore keeping logins in memory.
+type memoryTokenStore map[string]gqlfetch.SavedLogin
+
+func (s memoryTokenStore) Load(key string) (gqlfetch.SavedLogin, error) {
+	login, ok := s[key]
+	if !ok {
+		return gqlfetch.SavedLogin{}, gqlfetch.ErrNotLoggedIn
+	}
+	return login, nil
+}
+
+func (s memoryTokenStore) Save(key string, login gqlfetch.SavedLogin) error {
+	s[key] = login
+	return nil
+}
+
+func (s memoryTokenStore) Delete(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func TestRun_Login(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sso-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "abc" || r.FormValue("code_verifier") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "sso-token", "expires_in": 3600})
+	}))
+	defer provider.Close()
+
+	browser := openBrowser
+	defer func() { openBrowser = browser }()
+	openBrowser = func(authorizationURL string) error {
+		parsed, err := url.Parse(authorizationURL)
+		if err != nil {
+			return err
+		}
+		query := parsed.Query()
+		res, err := http.Get(query.Get("redirect_uri") + "?" + url.Values{"code": {"abc"}, "state": {query.Get("state")}}.Encode())
+		if err != nil {
+			return err
+		}
+		return res.Body.Close()
+	}
+
+	loginArgs := []string{"login", "--endpoint", server.URL, "--client-id", "gqlfetch", "--token-url", provider.URL, "--authorization-url", provider.URL + "/authorize"}
+	tests := map[string]struct {
+		args         []string
+		expectOutput string
+		expectErr    string
+	}{
+		"missing client": {
+			args:      []string{"login", "--endpoint", server.URL, "--authorization-url", provider.URL},
+			expectErr: "--client-id and --token-url are required",
+		},
+		"device without URL": {
+			args:      []string{"login", "--endpoint", server.URL, "--client-id", "gqlfetch", "--token-url", provider.URL, "--device"},
+			expectErr: "--device requires --device-url",
+		},
+		"not logged in": {
+			args:      []string{"--endpoint", server.URL},
+			expectErr: "401",
+		},
+		"logged in": {
+			args:         loginArgs,
+			expectOutput: "Logged in to " + server.URL,
+		},
+		"fetch with the saved login": {
+			args:         []string{"--endpoint", server.URL},
+			expectOutput: "type User implements Node",
+		},
+		"explicit header": {
+			args:      []string{"--endpoint", server.URL, "--header", "Authorization: Bearer other"},
+			expectErr: "401",
+		},
+		"logged out": {
+			args:         []string{"login", "--endpoint", server.URL, "--logout"},
+			expectOutput: "Logged out of " + server.URL,
+		},
+	}
+	// The cases share the token store, so they run in order.
+	for _, name := range []string{"missing client", "device without URL", "not logged in", "logged in", "fetch with the saved login", "explicit header", "logged out", "not logged in"} {
+		tt := tests[name]
+		t.Run(name, func(t *testing.T) {
+			stdout := &strings.Builder{}
+			err := run(context.Background(), tt.args, stdout)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stdout.String(), tt.expectOutput) {
+				t.Errorf("expect output containing %q got:\n%v", tt.expectOutput, stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/zucchinho/gqlfetch"
+)
+
+// runProbe implements "gqlfetch probe", printing whether the endpoint is reachable,
+// speaks GraphQL and allows introspection as JSON, and failing unless it does.
+func runProbe(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gqlfetch probe", flag.ContinueOnError)
+	request := addRequestFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options, err := request.options()
+	if err != nil {
+		return err
+	}
+	result := gqlfetch.Probe(ctx, options)
+	report := struct {
+		gqlfetch.ProbeResult
+		Error string `json:"error,omitempty"`
+	}{ProbeResult: result}
+	if result.Err != nil {
+		report.Error = result.Err.Error()
+	}
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	if !result.Introspectable {
+		return fmt.Errorf("probe of %s failed: %s", result.Endpoint, result.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/zucchinho/gqlfetch"
+)
+
+// runView implements "gqlfetch view", serving GraphQL Voyager for the schema of the
+// endpoint on a local port until ctx is done.
+func runView(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("gqlfetch view", flag.ContinueOnError)
+	request := addRequestFlags(flags)
+	listen := flags.String("listen", "localhost:8800", "address to serve GraphQL Voyager on")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options, err := request.options()
+	if err != nil {
+		return err
+	}
+	options.OutputFormat = gqlfetch.OutputVoyagerJSON
+	introspection, err := gqlfetch.BuildClientSchemaWithOptions(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: voyagerHandler(options.Endpoint, introspection)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	fmt.Fprintf(stdout, "Serving GraphQL Voyager for %s at http://%s\n", options.Endpoint, listener.Addr())
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return ctx.Err()
+}
+
+// voyagerPage loads GraphQL Voyager from a CDN, so the browser needs network access.
+var voyagerPage = template.Must(template.New("voyager").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.}} - GraphQL Voyager</title>
+<style>body { height: 100vh; margin: 0; overflow: hidden; } #voyager { height: 100vh; }</style>
+<link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/graphql-voyager@2/dist/voyager.css">
+<script src="https://cdn.jsdelivr.net/npm/graphql-voyager@2/dist/voyager.standalone.js"></script>
+</head>
+<body>
+<div id="voyager">Loading…</div>
+<script type="module">
+const response = await fetch("introspection.json");
+const introspection = await response.json();
+GraphQLVoyager.renderVoyager(document.getElementById("voyager"), { introspection });
+</script>
+</body>
+</html>
+`))
+
+// voyagerHandler serves the Voyager page and the introspection result it renders.
+func voyagerHandler(endpoint, introspection string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		voyagerPage.Execute(w, endpoint)
+	})
+	mux.HandleFunc("/introspection.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, introspection)
+	})
+	return mux
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zucchinho/gqlfetch"
+)
+
+func Test_voyagerHandler(t *testing.T) {
+	introspection, err := gqlfetch.BuildClientSchemaWithOptions(context.Background(), gqlfetch.BuildClientSchemaOptions{
+		Endpoint:     "file://../testdata/introspection.json",
+		OutputFormat: gqlfetch.OutputVoyagerJSON,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server := httptest.NewServer(voyagerHandler("https://example.com/graphql", introspection))
+	defer server.Close()
+
+	tests := map[string]struct {
+		path   string
+		expect string
+	}{
+		"page":          {path: "/", expect: "GraphQLVoyager.renderVoyager"},
+		"introspection": {path: "/introspection.json", expect: `"__schema"`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, err := server.Client().Get(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer res.Body.Close()
+			body, _ := io.ReadAll(res.Body)
+			if !strings.Contains(string(body), tt.expect) {
+				t.Errorf("expect %s to contain %q got:\n%s", tt.path, tt.expect, body)
+			}
+		})
+	}
+
+	var response struct {
+		Data struct {
+			Schema struct {
+				Types []interface{} `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(introspection), &response); err != nil || len(response.Data.Schema.Types) == 0 {
+		t.Errorf("expect an introspection response with types got: %v", err)
+	}
+}
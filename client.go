@@ -0,0 +1,269 @@
+package gqlfetch
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSOptions configures the TLS connections to the endpoint, such as internal services
+// requiring mutual TLS.
+type TLSOptions struct {
+	// CertFile and KeyFile are the PEM encoded client certificate and key presented to
+	// the server.
+	CertFile string
+	KeyFile  string
+	// CAFile is a PEM bundle of certificate authorities trusted in addition to the
+	// system ones.
+	CAFile             string
+	InsecureSkipVerify bool
+}
+
+func (o *TLSOptions) config() (*tls.Config, error) {
+	if o == nil {
+		return nil, nil
+	}
+	config := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.New("both a TLS client certificate and key are required")
+		}
+		certificate, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	if o.CAFile != "" {
+		bundle, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("CA bundle %s contains no certificates", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// HTTPVersion is the HTTP version of the connections to the endpoint.
+type HTTPVersion int
+
+const (
+	// HTTPVersionAuto uses HTTP/2 when the server negotiates it over TLS, and HTTP/1.1
+	// otherwise.
+	HTTPVersionAuto HTTPVersion = iota
+	// HTTP1 always uses HTTP/1.1, for proxies that break HTTP/2.
+	HTTP1
+	// HTTP2 fails requests the server doesn't answer over HTTP/2.
+	HTTP2
+)
+
+// ConnectionOptions configures the HTTP connections to the endpoint.
+type ConnectionOptions struct {
+	HTTPVersion HTTPVersion
+	// DialTimeout bounds establishing a connection, 30 seconds when zero.
+	DialTimeout time.Duration
+	// KeepAlive is the interval of TCP keep-alive probes, 30 seconds when zero. A
+	// negative KeepAlive disables them.
+	KeepAlive time.Duration
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool
+	// IdleConnTimeout closes connections left idle for this long, 90 seconds when zero.
+	IdleConnTimeout time.Duration
+}
+
+// apply configures transport with the connection options, returning the round tripper
+// to use, which checks the protocol of responses when HTTP/2 is required.
+func (o *ConnectionOptions) apply(transport *http.Transport) (http.RoundTripper, error) {
+	if o == nil {
+		return transport, nil
+	}
+	if o.DialTimeout != 0 || o.KeepAlive != 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if o.DialTimeout != 0 {
+			dialer.Timeout = o.DialTimeout
+		}
+		if o.KeepAlive != 0 {
+			dialer.KeepAlive = o.KeepAlive
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	transport.DisableKeepAlives = o.DisableKeepAlives
+	if o.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = o.IdleConnTimeout
+	}
+
+	switch o.HTTPVersion {
+	case HTTPVersionAuto:
+		return transport, nil
+	case HTTP1:
+		// A non-nil empty TLSNextProto disables HTTP/2.
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		return transport, nil
+	case HTTP2:
+		transport.ForceAttemptHTTP2 = true
+		return requireHTTP2{transport}, nil
+	default:
+		return nil, fmt.Errorf("unknown HTTP version %d", o.HTTPVersion)
+	}
+}
+
+// requireHTTP2 fails the responses that weren't received over HTTP/2.
+type requireHTTP2 struct {
+	next http.RoundTripper
+}
+
+func (t requireHTTP2) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.ProtoMajor != 2 {
+		res.Body.Close()
+		return nil, fmt.Errorf("endpoint responded over %s while HTTP/2 is required", res.Proto)
+	}
+	return res, nil
+}
+
+// transportKey is the configuration of the transports built by httpClient, the zero
+// TLSOptions and ConnectionOptions behaving like nil ones.
+type transportKey struct {
+	tls        TLSOptions
+	connection ConnectionOptions
+	proxyURL   string
+	socket     string
+}
+
+// transports are the transports built by httpClient, shared by every request with the
+// same configuration so retries, probes and later fetches reuse their connections, and
+// client certificates are only read once.
+var transports = struct {
+	sync.Mutex
+	byKey map[transportKey]http.RoundTripper
+}{byKey: map[transportKey]http.RoundTripper{}}
+
+// httpClient returns options.HTTPClient, or a client configured with options.TLS,
+// options.Connection, options.ProxyURL and options.CookieJar, dialing the socket of
+// unix:// endpoints. Those options can't be combined with HTTPClient.
+func (options BuildClientSchemaOptions) httpClient() (*http.Client, error) {
+	if options.HTTPClient != nil {
+		switch {
+		case options.TLS != nil:
+			return nil, errors.New("the TLS options can't be combined with HTTPClient, configure its transport instead")
+		case options.Connection != nil:
+			return nil, errors.New("the connection options can't be combined with HTTPClient, configure its transport instead")
+		case options.ProxyURL != "":
+			return nil, errors.New("a proxy URL can't be combined with HTTPClient, configure its transport instead")
+		case options.CookieJar != nil:
+			return nil, errors.New("a cookie jar can't be combined with HTTPClient, set its Jar instead")
+		case isUnixEndpoint(options.Endpoint):
+			return nil, errors.New("unix:// endpoints can't be combined with HTTPClient, dial the socket from its transport instead")
+		}
+		return options.HTTPClient, nil
+	}
+
+	key := transportKey{proxyURL: options.ProxyURL}
+	if options.TLS != nil {
+		key.tls = *options.TLS
+	}
+	if options.Connection != nil {
+		key.connection = *options.Connection
+	}
+	if isUnixEndpoint(options.Endpoint) {
+		socket, _, err := parseUnixEndpoint(options.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		key.socket = socket
+	}
+	if key == (transportKey{}) {
+		return &http.Client{Jar: options.CookieJar}, nil
+	}
+
+	transports.Lock()
+	defer transports.Unlock()
+	transport, ok := transports.byKey[key]
+	if !ok {
+		var err error
+		if transport, err = newTransport(key); err != nil {
+			return nil, err
+		}
+		transports.byKey[key] = transport
+	}
+	return &http.Client{Transport: transport, Jar: options.CookieJar}, nil
+}
+
+// newTransport builds the transport configured by key.
+func newTransport(key transportKey) (http.RoundTripper, error) {
+	config, err := key.tls.config()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config != nil {
+		transport.TLSClientConfig = config
+	}
+	if key.proxyURL != "" {
+		proxy, err := url.Parse(key.proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q, expected http, https or socks5", proxy.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	roundTripper, err := key.connection.apply(transport)
+	if err != nil {
+		return nil, err
+	}
+	if key.socket != "" {
+		dialUnixSocket(transport, key.socket)
+	}
+	return roundTripper, nil
+}
+
+// login runs options.Login with the client used for the introspection request, first
+// giving options a cookie jar if it has none, so the session cookies it receives are
+// sent with the request.
+func login(ctx context.Context, options BuildClientSchemaOptions) (BuildClientSchemaOptions, error) {
+	if options.Login == nil {
+		return options, nil
+	}
+	if options.CookieJar == nil && options.HTTPClient == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return options, err
+		}
+		options.CookieJar = jar
+	}
+	client, err := options.httpClient()
+	if err != nil {
+		return options, err
+	}
+	if err := options.Login(ctx, client); err != nil {
+		return options, fmt.Errorf("failed to log in: %w", err)
+	}
+	options.Login = nil
+	return options, nil
+}
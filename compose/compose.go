@@ -0,0 +1,279 @@
+// Package compose composes the schemas of Apollo Federation subgraphs into a
+// federation v2 supergraph, annotated with the join__ directives routers plan queries
+// with. It covers the common cases of rover supergraph compose: entities with @key,
+// @external, @requires, @provides, @override and @shareable fields, and value types
+// shared between subgraphs.
+package compose
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/zucchinho/gqlfetch"
+)
+
+// Subgraph is a federated service composed into the supergraph.
+type Subgraph struct {
+	Name string
+	// URL is the routing URL recorded in the supergraph, Options.Endpoint when empty.
+	URL string
+	// SDL is the schema of the subgraph, fetched with Options when empty.
+	SDL     string
+	Options gqlfetch.BuildClientSchemaOptions
+}
+
+// Conflict describes a type or field the subgraphs disagree on.
+type Conflict struct {
+	// Path is the conflicting type or "Type.field".
+	Path   string
+	Reason string
+}
+
+// Error reports every conflict found by Supergraph.
+type Error struct {
+	Conflicts []Conflict
+}
+
+func (e *Error) Error() string {
+	var conflicts []string
+	for _, conflict := range e.Conflicts {
+		conflicts = append(conflicts, fmt.Sprintf("%s: %s", conflict.Path, conflict.Reason))
+	}
+	return "failed to compose supergraph: " + strings.Join(conflicts, ", ")
+}
+
+// Compose fetches the subgraphs without SDL and composes them with Supergraph.
+func Compose(ctx context.Context, subgraphs []Subgraph) (string, error) {
+	subgraphs, err := Fetch(ctx, subgraphs)
+	if err != nil {
+		return "", err
+	}
+	return Supergraph(subgraphs)
+}
+
+// Fetch fills in the SDL of the subgraphs without one, through `_service { sdl }` or
+// introspection for servers that don't implement federation. Subgraphs are fetched in
+// parallel, the first failure is returned.
+func Fetch(ctx context.Context, subgraphs []Subgraph) ([]Subgraph, error) {
+	var options []gqlfetch.BuildClientSchemaOptions
+	var pending []int
+	for i, subgraph := range subgraphs {
+		if subgraph.SDL != "" {
+			continue
+		}
+		option := subgraph.Options
+		if option.Endpoint == "" {
+			option.Endpoint = subgraph.URL
+		}
+		option.FederationSDL = true
+		option.WithoutBuiltins = true
+		options = append(options, option)
+		pending = append(pending, i)
+	}
+
+	fetched := append([]Subgraph(nil), subgraphs...)
+	for i, result := range gqlfetch.BuildClientSchemas(ctx, options) {
+		if result.Err != nil {
+			return nil, fmt.Errorf("failed to fetch subgraph %s: %w", subgraphs[pending[i]].Name, result.Err)
+		}
+		fetched[pending[i]].SDL = result.Schema
+	}
+	return fetched, nil
+}
+
+// subgraphSchema is a parsed subgraph.
+type subgraphSchema struct {
+	name  string
+	graph string
+	url   string
+	// fed2 is set for subgraphs linking the federation v2 specification, whose fields
+	// are only shareable when marked @shareable. Fields of other subgraphs always are.
+	fed2  bool
+	types map[string]*subgraphType
+	// directives are the definitions of the custom directives of the subgraph.
+	directives []*ast.DirectiveDefinition
+}
+
+type subgraphType struct {
+	def *ast.Definition
+	// extension is set for types the subgraph only extends, as federation v1 entities.
+	extension bool
+}
+
+var defaultRootNames = map[ast.Operation]string{
+	ast.Query:        "Query",
+	ast.Mutation:     "Mutation",
+	ast.Subscription: "Subscription",
+}
+
+// federationDirectives are declared by subgraph libraries or built in, and left out of
+// the supergraph, which declares the join__ and link directives it uses.
+var federationDirectives = map[string]bool{
+	"key": true, "external": true, "requires": true, "provides": true, "extends": true,
+	"shareable": true, "override": true, "inaccessible": true, "tag": true, "link": true,
+	"composeDirective": true, "interfaceObject": true, "authenticated": true,
+	"requiresScopes": true, "policy": true, "context": true, "fromContext": true, "cost": true,
+	"listSize": true, "deprecated": true, "skip": true, "include": true, "specifiedBy": true,
+	"oneOf": true, "defer": true, "stream": true,
+}
+
+// federationTypes are declared by subgraph libraries and left out of the supergraph.
+var federationTypes = map[string]bool{
+	"_Any": true, "_Entity": true, "_Service": true, "_FieldSet": true, "FieldSet": true,
+	"String": true, "Int": true, "Float": true, "Boolean": true, "ID": true,
+}
+
+func parseSubgraph(subgraph Subgraph) (*subgraphSchema, error) {
+	doc, gqlErr := parser.ParseSchema(&ast.Source{Name: subgraph.Name, Input: subgraph.SDL})
+	if gqlErr != nil {
+		return nil, fmt.Errorf("failed to parse subgraph %s: %w", subgraph.Name, gqlErr)
+	}
+
+	url := subgraph.URL
+	if url == "" {
+		url = subgraph.Options.Endpoint
+	}
+	schema := &subgraphSchema{name: subgraph.Name, graph: graphName(subgraph.Name), url: url, types: map[string]*subgraphType{}}
+
+	roots := map[string]string{}
+	for _, def := range append(append(ast.SchemaDefinitionList{}, doc.Schema...), doc.SchemaExtension...) {
+		for _, operation := range def.OperationTypes {
+			roots[operation.Type] = defaultRootNames[operation.Operation]
+		}
+		for _, link := range def.Directives.ForNames("link") {
+			if url := link.Arguments.ForName("url"); url != nil && url.Value != nil && strings.Contains(url.Value.Raw, "specs.apollo.dev/federation/v2") {
+				schema.fed2 = true
+			}
+		}
+	}
+
+	add := func(def *ast.Definition, extension bool) {
+		if federationTypes[def.Name] || strings.HasPrefix(def.Name, "link__") || strings.HasPrefix(def.Name, "federation__") {
+			return
+		}
+		def = copyDefinition(def)
+		if root, ok := roots[def.Name]; ok {
+			def.Name = root
+		}
+		var fields ast.FieldList
+		for _, field := range def.Fields {
+			if field.Name != "_service" && field.Name != "_entities" && !strings.HasPrefix(field.Name, "__") {
+				fields = append(fields, field)
+			}
+		}
+		def.Fields = fields
+
+		existing := schema.types[def.Name]
+		if existing == nil {
+			schema.types[def.Name] = &subgraphType{def: def, extension: extension}
+			return
+		}
+		existing.def.Directives = append(existing.def.Directives, def.Directives...)
+		existing.def.Interfaces = append(existing.def.Interfaces, def.Interfaces...)
+		existing.def.Fields = append(existing.def.Fields, def.Fields...)
+		existing.def.Types = append(existing.def.Types, def.Types...)
+		existing.def.EnumValues = append(existing.def.EnumValues, def.EnumValues...)
+		if !extension {
+			existing.extension = false
+		}
+	}
+	for _, def := range doc.Definitions {
+		add(def, false)
+	}
+	for _, def := range doc.Directives {
+		if !federationDirectives[def.Name] && !strings.HasPrefix(def.Name, "federation__") && !strings.HasPrefix(def.Name, "link__") && !strings.HasPrefix(def.Name, "join__") {
+			schema.directives = append(schema.directives, def)
+		}
+	}
+	for _, def := range doc.Extensions {
+		add(def, true)
+	}
+	return schema, nil
+}
+
+func copyDefinition(def *ast.Definition) *ast.Definition {
+	copied := *def
+	copied.Directives = append(ast.DirectiveList(nil), def.Directives...)
+	copied.Interfaces = append([]string(nil), def.Interfaces...)
+	copied.Fields = append(ast.FieldList(nil), def.Fields...)
+	copied.Types = append([]string(nil), def.Types...)
+	copied.EnumValues = append(ast.EnumValueList(nil), def.EnumValues...)
+	return &copied
+}
+
+// graphName turns a subgraph name into a join__Graph value, e.g. "product-catalog"
+// into PRODUCT_CATALOG.
+func graphName(name string) string {
+	out := &strings.Builder{}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z':
+			out.WriteRune(r - 'a' + 'A')
+		case r >= 'A' && r <= 'Z', r == '_':
+			out.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				out.WriteRune('_')
+			}
+			out.WriteRune(r)
+		default:
+			out.WriteRune('_')
+		}
+	}
+	return out.String()
+}
+
+// directives returns the directives of list named name, or federation__name as
+// subgraphs importing federation under its namespace write them.
+func directives(list ast.DirectiveList, name string) ast.DirectiveList {
+	var found ast.DirectiveList
+	for _, dir := range list {
+		if dir.Name == name || dir.Name == "federation__"+name {
+			found = append(found, dir)
+		}
+	}
+	return found
+}
+
+func hasDirective(list ast.DirectiveList, name string) bool {
+	return len(directives(list, name)) > 0
+}
+
+// stringArgument returns the string argument name of the first directive of list named
+// directive.
+func stringArgument(list ast.DirectiveList, directive, name string) string {
+	for _, dir := range directives(list, directive) {
+		if arg := dir.Arguments.ForName(name); arg != nil && arg.Value != nil {
+			return arg.Value.Raw
+		}
+	}
+	return ""
+}
+
+// keyFields returns the top-level fields selected by the @key directives of def.
+func keyFields(def *ast.Definition) map[string]bool {
+	fields := map[string]bool{}
+	for _, key := range directives(def.Directives, "key") {
+		arg := key.Arguments.ForName("fields")
+		if arg == nil || arg.Value == nil {
+			continue
+		}
+		depth := 0
+		for _, token := range strings.Fields(strings.NewReplacer("{", " { ", "}", " } ").Replace(arg.Value.Raw)) {
+			switch token {
+			case "{":
+				depth++
+			case "}":
+				depth--
+			default:
+				if depth == 0 {
+					fields[token] = true
+				}
+			}
+		}
+	}
+	return fields
+}
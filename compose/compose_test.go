@@ -0,0 +1,200 @@
+package compose
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+const accountsSDL = `extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@key", "@shareable"])
+
+"Marks fields whose reads are audited, \"quoted\\"
+directive @audit(reason: String = "compliance") repeatable on FIELD_DEFINITION | OBJECT
+
+type Query {
+	"The current user."
+	me: User
+}
+
+"A user."
+type User @key(fields: "id") {
+	id: ID!
+	name: String @deprecated(reason: "use fullName")
+	fullName: String
+}
+
+type PageInfo @shareable {
+	hasNextPage: Boolean!
+}
+`
+
+const reviewsSDL = `extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@key", "@shareable", "@external", "@requires"])
+
+directive @cost(weight: Int!) repeatable on FIELD_DEFINITION
+
+type Query {
+	"Reviews ending in a quote\", and a backslash \\"
+	reviews(first: Int = 10): [Review!]!
+}
+
+type Review {
+	body: String!
+	author: User
+}
+
+type User @key(fields: "id") {
+	id: ID!
+	fullName: String @external
+	reviews: [Review!]! @requires(fields: "fullName")
+}
+
+type PageInfo @shareable {
+	hasNextPage: Boolean!
+}
+`
+
+func TestSupergraph(t *testing.T) {
+	supergraph, err := Supergraph([]Subgraph{
+		{Name: "accounts", URL: "http://accounts/graphql", SDL: accountsSDL},
+		{Name: "reviews", URL: "http://reviews/graphql", SDL: reviewsSDL},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expect := range []string{
+		"schema\n\t@link(url: \"https://specs.apollo.dev/link/v1.0\")\n\t@link(url: \"https://specs.apollo.dev/join/v0.3\", for: EXECUTION)\n{\n\tquery: Query\n}\n",
+		"enum join__Graph {\n\tACCOUNTS @join__graph(name: \"accounts\", url: \"http://accounts/graphql\")\n\tREVIEWS @join__graph(name: \"reviews\", url: \"http://reviews/graphql\")\n}\n",
+		"type PageInfo\n\t@join__type(graph: ACCOUNTS)\n\t@join__type(graph: REVIEWS)\n{\n\thasNextPage: Boolean!\n}\n",
+		"\"\"\"\nMarks fields whose reads are audited, \"quoted\\\n\"\"\"\ndirective @audit(reason: String = \"compliance\") repeatable on FIELD_DEFINITION | OBJECT\n",
+		"\t\"\"\"The current user.\"\"\"\n\tme: User @join__field(graph: ACCOUNTS)\n",
+		"\"\"\"A user.\"\"\"\ntype User\n\t@join__type(graph: ACCOUNTS, key: \"id\")\n\t@join__type(graph: REVIEWS, key: \"id\")\n{\n\tid: ID!\n" +
+			"\tname: String @join__field(graph: ACCOUNTS) @deprecated(reason: \"use fullName\")\n" +
+			"\tfullName: String @join__field(graph: ACCOUNTS) @join__field(graph: REVIEWS, external: true)\n" +
+			"\treviews: [Review!]! @join__field(graph: REVIEWS, requires: \"fullName\")\n}\n",
+	} {
+		if !strings.Contains(supergraph, expect) {
+			t.Errorf("expect supergraph to contain:\n%s\ngot:\n%s", expect, supergraph)
+		}
+	}
+	if strings.Contains(supergraph, "@cost") {
+		t.Errorf("expect federation directive definitions to be left out got:\n%s", supergraph)
+	}
+
+	doc, err := parser.ParseSchema(&ast.Source{Input: supergraph})
+	if err != nil {
+		t.Fatalf("parse supergraph: %v", err)
+	}
+	descriptions := map[string]string{}
+	for _, def := range doc.Directives {
+		descriptions["@"+def.Name] = def.Description
+	}
+	for _, def := range doc.Definitions {
+		for _, field := range def.Fields {
+			descriptions[def.Name+"."+field.Name] = field.Description
+		}
+	}
+	for path, expect := range map[string]string{
+		"@audit":        `Marks fields whose reads are audited, "quoted\`,
+		"Query.reviews": `Reviews ending in a quote", and a backslash \`,
+	} {
+		if descriptions[path] != expect {
+			t.Errorf("expect %s description %q got: %q", path, expect, descriptions[path])
+		}
+	}
+}
+
+func TestSupergraph_Conflicts(t *testing.T) {
+	tests := map[string]struct {
+		sdl    string
+		expect []Conflict
+	}{
+		"field not shareable": {
+			sdl: `extend schema @link(url: "https://specs.apollo.dev/federation/v2.0")
+type Query { products: [String] }
+type User @key(fields: "id") { id: ID! fullName: String }`,
+			expect: []Conflict{
+				{Path: "User.fullName", Reason: "field is resolved by accounts, products but isn't @shareable in accounts"},
+				{Path: "User.fullName", Reason: "field is resolved by accounts, products but isn't @shareable in products"},
+			},
+		},
+		"federation v1 fields are shareable": {
+			sdl: `type Query { products: [String] }
+extend type User @key(fields: "id") { id: ID! @external reviews: [String] }
+type PageInfo { hasNextPage: Boolean! }`,
+		},
+		"kinds differ": {
+			sdl: `type Query { products: [String] }
+enum PageInfo { FIRST }`,
+			expect: []Conflict{{Path: "PageInfo", Reason: "object in accounts but enum in products"}},
+		},
+		"types differ": {
+			sdl: `extend schema @link(url: "https://specs.apollo.dev/federation/v2.0")
+type Query { products: [String] }
+type PageInfo @shareable { hasNextPage: Boolean }`,
+			expect: []Conflict{{Path: "PageInfo.hasNextPage", Reason: "type Boolean! in accounts but Boolean in products"}},
+		},
+		"directives differ": {
+			sdl: `directive @audit(reason: String!) on FIELD_DEFINITION
+type Query { products: [String] }`,
+			expect: []Conflict{{Path: "@audit", Reason: "defined differently in accounts and products"}},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Supergraph([]Subgraph{
+				{Name: "accounts", URL: "http://accounts/graphql", SDL: accountsSDL},
+				{Name: "products", URL: "http://products/graphql", SDL: tt.sdl},
+			})
+			if tt.expect == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			composeErr, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("expect *Error got: %v", err)
+			}
+			if !reflect.DeepEqual(composeErr.Conflicts, tt.expect) {
+				t.Errorf("expect conflicts:\n%v\ngot:\n%v", tt.expect, composeErr.Conflicts)
+			}
+		})
+	}
+}
+
+func TestCompose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&params)
+		if !strings.Contains(params.Query, "_service") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"_service": map[string]string{"sdl": reviewsSDL}},
+		})
+	}))
+	defer server.Close()
+
+	supergraph, err := Compose(context.Background(), []Subgraph{
+		{Name: "accounts", URL: "http://accounts/graphql", SDL: accountsSDL},
+		{Name: "reviews", URL: server.URL},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expect := `REVIEWS @join__graph(name: "reviews", url: "` + server.URL + `")`; !strings.Contains(supergraph, expect) {
+		t.Errorf("expect supergraph to contain %q got:\n%s", expect, supergraph)
+	}
+}
@@ -0,0 +1,545 @@
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/zucchinho/gqlfetch/internal/blockstring"
+)
+
+// supergraphPreamble declares the link and join specifications the supergraph uses.
+const supergraphPreamble = `directive @join__enumValue(graph: join__Graph!) repeatable on ENUM_VALUE
+
+directive @join__field(graph: join__Graph, requires: join__FieldSet, provides: join__FieldSet, type: String, external: Boolean, override: String, usedOverridden: Boolean) repeatable on FIELD_DEFINITION | INPUT_FIELD_DEFINITION
+
+directive @join__graph(name: String!, url: String!) on ENUM_VALUE
+
+directive @join__implements(graph: join__Graph!, interface: String!) repeatable on OBJECT | INTERFACE
+
+directive @join__type(graph: join__Graph!, key: join__FieldSet, extension: Boolean! = false, resolvable: Boolean! = true, isInterfaceObject: Boolean! = false) repeatable on OBJECT | INTERFACE | UNION | ENUM | INPUT_OBJECT | SCALAR
+
+directive @join__unionMember(graph: join__Graph!, member: String!) repeatable on UNION
+
+directive @link(url: String, as: String, for: link__Purpose, import: [link__Import]) repeatable on SCHEMA
+
+scalar join__FieldSet
+
+scalar link__Import
+
+enum link__Purpose {
+	"""
+	` + "`SECURITY`" + ` features provide metadata necessary to securely resolve fields.
+	"""
+	SECURITY
+
+	"""
+	` + "`EXECUTION`" + ` features provide metadata necessary for operation execution.
+	"""
+	EXECUTION
+}
+`
+
+// joinedType is a type of the supergraph with its join__ directives.
+type joinedType struct {
+	kind        ast.DefinitionKind
+	name        string
+	description string
+	interfaces  []string
+	directives  []string
+	fields      []joinedField
+	members     []string
+}
+
+// joinedField is a field, input field or enum value of a joinedType.
+type joinedField struct {
+	name         string
+	description  string
+	args         ast.ArgumentDefinitionList
+	typ          string
+	defaultValue *ast.Value
+	directives   []string
+}
+
+// occurrence is the definition of a type in one subgraph.
+type occurrence struct {
+	subgraph *subgraphSchema
+	typ      *subgraphType
+}
+
+// Supergraph composes the SDL of the subgraphs into a federation v2 supergraph.
+// Disagreements between subgraphs, such as fields resolved by several subgraphs
+// without @shareable or types defined with different kinds, are reported as an *Error.
+func Supergraph(subgraphs []Subgraph) (string, error) {
+	var schemas []*subgraphSchema
+	graphs := map[string]string{}
+	var conflicts []Conflict
+	for _, subgraph := range subgraphs {
+		if subgraph.Name == "" {
+			return "", fmt.Errorf("subgraph %s has no name", subgraph.URL)
+		}
+		schema, err := parseSubgraph(subgraph)
+		if err != nil {
+			return "", err
+		}
+		if other, ok := graphs[schema.graph]; ok {
+			conflicts = append(conflicts, Conflict{Path: subgraph.Name, Reason: fmt.Sprintf("subgraph name clashes with %s", other)})
+			continue
+		}
+		graphs[schema.graph] = subgraph.Name
+		schemas = append(schemas, schema)
+	}
+
+	var names []string
+	occurrences := map[string][]occurrence{}
+	for _, schema := range schemas {
+		for name, typ := range schema.types {
+			if occurrences[name] == nil {
+				names = append(names, name)
+			}
+			occurrences[name] = append(occurrences[name], occurrence{subgraph: schema, typ: typ})
+		}
+	}
+	sort.Strings(names)
+	directives, directiveConflicts := joinDirectives(schemas)
+	conflicts = append(conflicts, directiveConflicts...)
+	if occurrences["Query"] == nil {
+		conflicts = append(conflicts, Conflict{Path: "Query", Reason: "no subgraph defines a Query type"})
+	}
+
+	var types []joinedType
+	for _, name := range names {
+		typ, typeConflicts := joinType(name, occurrences[name])
+		conflicts = append(conflicts, typeConflicts...)
+		types = append(types, typ)
+	}
+	if len(conflicts) > 0 {
+		return "", &Error{Conflicts: conflicts}
+	}
+	return printSupergraph(schemas, directives, types, occurrences), nil
+}
+
+// joinDirectives returns the custom directive definitions of the subgraphs sorted by
+// name, reporting the directives defined differently by several subgraphs.
+func joinDirectives(schemas []*subgraphSchema) ([]*ast.DirectiveDefinition, []Conflict) {
+	var directives []*ast.DirectiveDefinition
+	var conflicts []Conflict
+	definedBy := map[string]*subgraphSchema{}
+	for _, schema := range schemas {
+		for _, def := range schema.directives {
+			first, ok := definedBy[def.Name]
+			if !ok {
+				definedBy[def.Name] = schema
+				directives = append(directives, def)
+				continue
+			}
+			for _, joined := range directives {
+				if joined.Name == def.Name && formatDirectiveDefinition(joined) != formatDirectiveDefinition(def) {
+					conflicts = append(conflicts, Conflict{Path: "@" + def.Name, Reason: fmt.Sprintf("defined differently in %s and %s", first.name, schema.name)})
+				}
+			}
+		}
+	}
+	sort.Slice(directives, func(i, j int) bool { return directives[i].Name < directives[j].Name })
+	return directives, conflicts
+}
+
+func joinType(name string, occurrences []occurrence) (joinedType, []Conflict) {
+	first := occurrences[0]
+	typ := joinedType{kind: first.typ.def.Kind, name: name}
+	var conflicts []Conflict
+	for _, occurrence := range occurrences {
+		def := occurrence.typ.def
+		if def.Kind != typ.kind {
+			conflicts = append(conflicts, Conflict{Path: name, Reason: fmt.Sprintf("%s in %s but %s in %s", kindName(typ.kind), first.subgraph.name, kindName(def.Kind), occurrence.subgraph.name)})
+			return typ, conflicts
+		}
+		if typ.description == "" {
+			typ.description = def.Description
+		}
+		typ.directives = append(typ.directives, joinTypeDirectives(occurrence)...)
+	}
+	if dir := firstDirective(occurrences, "specifiedBy"); dir != "" {
+		typ.directives = append(typ.directives, dir)
+	}
+
+	switch typ.kind {
+	case ast.Object, ast.Interface:
+		for _, occurrence := range occurrences {
+			for _, iface := range occurrence.typ.def.Interfaces {
+				if !contains(typ.interfaces, iface) {
+					typ.interfaces = append(typ.interfaces, iface)
+				}
+				typ.directives = append(typ.directives, fmt.Sprintf("@join__implements(graph: %s, interface: %s)", occurrence.subgraph.graph, strconv.Quote(iface)))
+			}
+		}
+		fields, fieldConflicts := joinOutputFields(name, occurrences)
+		typ.fields = fields
+		conflicts = append(conflicts, fieldConflicts...)
+	case ast.InputObject:
+		fields, fieldConflicts := joinInputFields(name, occurrences)
+		typ.fields = fields
+		conflicts = append(conflicts, fieldConflicts...)
+	case ast.Union:
+		for _, occurrence := range occurrences {
+			for _, member := range occurrence.typ.def.Types {
+				if !contains(typ.members, member) {
+					typ.members = append(typ.members, member)
+				}
+				typ.directives = append(typ.directives, fmt.Sprintf("@join__unionMember(graph: %s, member: %s)", occurrence.subgraph.graph, strconv.Quote(member)))
+			}
+		}
+	case ast.Enum:
+		for _, occurrence := range occurrences {
+			for _, value := range occurrence.typ.def.EnumValues {
+				directive := fmt.Sprintf("@join__enumValue(graph: %s)", occurrence.subgraph.graph)
+				if i := fieldIndex(typ.fields, value.Name); i >= 0 {
+					typ.fields[i].directives = append(typ.fields[i].directives, directive)
+					continue
+				}
+				field := joinedField{name: value.Name, description: value.Description, directives: []string{directive}}
+				if deprecated := value.Directives.ForName("deprecated"); deprecated != nil {
+					field.directives = append(field.directives, formatDirective(deprecated))
+				}
+				typ.fields = append(typ.fields, field)
+			}
+		}
+	}
+	return typ, conflicts
+}
+
+// joinTypeDirectives returns the @join__type directives of a type for one subgraph, one
+// per @key.
+func joinTypeDirectives(occurrence occurrence) []string {
+	graph := occurrence.subgraph.graph
+	var extension string
+	if occurrence.typ.extension {
+		extension = ", extension: true"
+	}
+	var joined []string
+	for _, key := range directives(occurrence.typ.def.Directives, "key") {
+		fields := key.Arguments.ForName("fields")
+		if fields == nil || fields.Value == nil {
+			continue
+		}
+		directive := fmt.Sprintf("@join__type(graph: %s, key: %s%s", graph, strconv.Quote(fields.Value.Raw), extension)
+		if resolvable := key.Arguments.ForName("resolvable"); resolvable != nil && resolvable.Value != nil && resolvable.Value.Raw == "false" {
+			directive += ", resolvable: false"
+		}
+		joined = append(joined, directive+")")
+	}
+	if len(joined) == 0 {
+		joined = append(joined, fmt.Sprintf("@join__type(graph: %s%s)", graph, extension))
+	}
+	return joined
+}
+
+func joinOutputFields(typeName string, occurrences []occurrence) ([]joinedField, []Conflict) {
+	var order []string
+	byName := map[string][]fieldOccurrence{}
+	for _, occurrence := range occurrences {
+		for _, field := range occurrence.typ.def.Fields {
+			if byName[field.Name] == nil {
+				order = append(order, field.Name)
+			}
+			byName[field.Name] = append(byName[field.Name], fieldOccurrence{occurrence: occurrence, field: field})
+		}
+	}
+
+	var fields []joinedField
+	var conflicts []Conflict
+	for _, name := range order {
+		path := typeName + "." + name
+		defined := byName[name]
+		first := defined[0]
+		joined := joinedField{name: name, args: first.field.Arguments, typ: first.field.Type.String()}
+
+		var resolving []fieldOccurrence
+		needsJoin := len(defined) != len(occurrences)
+		for _, field := range defined {
+			if joined.description == "" {
+				joined.description = field.field.Description
+			}
+			if field.field.Type.String() != joined.typ {
+				conflicts = append(conflicts, Conflict{Path: path, Reason: fmt.Sprintf("type %s in %s but %s in %s", joined.typ, first.occurrence.subgraph.name, field.field.Type, field.occurrence.subgraph.name)})
+			}
+			if formatArguments(field.field.Arguments) != formatArguments(joined.args) {
+				conflicts = append(conflicts, Conflict{Path: path, Reason: fmt.Sprintf("arguments differ between %s and %s", first.occurrence.subgraph.name, field.occurrence.subgraph.name)})
+			}
+			if !hasDirective(field.field.Directives, "external") {
+				resolving = append(resolving, field)
+			}
+			for _, directive := range []string{"external", "requires", "provides", "override"} {
+				if hasDirective(field.field.Directives, directive) {
+					needsJoin = true
+				}
+			}
+		}
+
+		if len(resolving) == 0 {
+			conflicts = append(conflicts, Conflict{Path: path, Reason: "field is @external in every subgraph"})
+		}
+		if len(resolving) > 1 {
+			for _, field := range resolving {
+				if !field.shareable() {
+					conflicts = append(conflicts, Conflict{Path: path, Reason: fmt.Sprintf("field is resolved by %s but isn't @shareable in %s", subgraphNames(resolving), field.occurrence.subgraph.name)})
+				}
+			}
+		}
+
+		if needsJoin {
+			for _, field := range defined {
+				joined.directives = append(joined.directives, field.joinField())
+			}
+		}
+		for _, field := range defined {
+			if deprecated := field.field.Directives.ForName("deprecated"); deprecated != nil {
+				joined.directives = append(joined.directives, formatDirective(deprecated))
+				break
+			}
+		}
+		fields = append(fields, joined)
+	}
+	return fields, conflicts
+}
+
+// joinInputFields keeps the input fields defined by every subgraph, as the routers only
+// send those, and reports required fields missing from some.
+func joinInputFields(typeName string, occurrences []occurrence) ([]joinedField, []Conflict) {
+	var fields []joinedField
+	var conflicts []Conflict
+	seen := map[string]bool{}
+	for _, occurrence := range occurrences {
+		for _, field := range occurrence.typ.def.Fields {
+			if seen[field.Name] {
+				continue
+			}
+			seen[field.Name] = true
+			path := typeName + "." + field.Name
+
+			joined := joinedField{name: field.Name, description: field.Description, typ: field.Type.String(), defaultValue: field.DefaultValue}
+			everywhere := true
+			for _, other := range occurrences {
+				otherField := other.typ.def.Fields.ForName(field.Name)
+				switch {
+				case otherField == nil:
+					everywhere = false
+					if field.Type.NonNull && field.DefaultValue == nil {
+						conflicts = append(conflicts, Conflict{Path: path, Reason: fmt.Sprintf("required in %s but missing from %s", occurrence.subgraph.name, other.subgraph.name)})
+					}
+				case otherField.Type.String() != joined.typ:
+					conflicts = append(conflicts, Conflict{Path: path, Reason: fmt.Sprintf("type %s in %s but %s in %s", joined.typ, occurrence.subgraph.name, otherField.Type, other.subgraph.name)})
+				case joined.description == "":
+					joined.description = otherField.Description
+				}
+			}
+			if !everywhere {
+				continue
+			}
+			if deprecated := field.Directives.ForName("deprecated"); deprecated != nil {
+				joined.directives = append(joined.directives, formatDirective(deprecated))
+			}
+			fields = append(fields, joined)
+		}
+	}
+	return fields, conflicts
+}
+
+// fieldOccurrence is the definition of a field in one subgraph.
+type fieldOccurrence struct {
+	occurrence
+	field *ast.FieldDefinition
+}
+
+func (f fieldOccurrence) shareable() bool {
+	def := f.typ.def
+	return !f.subgraph.fed2 || hasDirective(f.field.Directives, "shareable") || hasDirective(def.Directives, "shareable") || keyFields(def)[f.field.Name]
+}
+
+func (f fieldOccurrence) joinField() string {
+	args := []string{"graph: " + f.subgraph.graph}
+	for _, name := range []string{"requires", "provides"} {
+		if fields := stringArgument(f.field.Directives, name, "fields"); fields != "" {
+			args = append(args, name+": "+strconv.Quote(fields))
+		}
+	}
+	if hasDirective(f.field.Directives, "external") {
+		args = append(args, "external: true")
+	}
+	if from := stringArgument(f.field.Directives, "override", "from"); from != "" {
+		args = append(args, "override: "+strconv.Quote(from))
+	}
+	return "@join__field(" + strings.Join(args, ", ") + ")"
+}
+
+func subgraphNames(fields []fieldOccurrence) string {
+	var names []string
+	for _, field := range fields {
+		names = append(names, field.subgraph.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// firstDirective formats the first directive named name applied to the type in any
+// subgraph.
+func firstDirective(occurrences []occurrence, name string) string {
+	for _, occurrence := range occurrences {
+		if dir := occurrence.typ.def.Directives.ForName(name); dir != nil {
+			return formatDirective(dir)
+		}
+	}
+	return ""
+}
+
+func printSupergraph(schemas []*subgraphSchema, directives []*ast.DirectiveDefinition, types []joinedType, occurrences map[string][]occurrence) string {
+	out := &strings.Builder{}
+	out.WriteString("schema\n\t@link(url: \"https://specs.apollo.dev/link/v1.0\")\n\t@link(url: \"https://specs.apollo.dev/join/v0.3\", for: EXECUTION)\n{\n")
+	for _, operation := range []ast.Operation{ast.Query, ast.Mutation, ast.Subscription} {
+		name := defaultRootNames[operation]
+		if occurrences[name] != nil {
+			fmt.Fprintf(out, "\t%s: %s\n", operation, name)
+		}
+	}
+	out.WriteString("}\n\n")
+	out.WriteString(supergraphPreamble)
+
+	out.WriteString("\nenum join__Graph {\n")
+	for _, schema := range schemas {
+		fmt.Fprintf(out, "\t%s @join__graph(name: %s, url: %s)\n", schema.graph, strconv.Quote(schema.name), strconv.Quote(schema.url))
+	}
+	out.WriteString("}\n")
+
+	for _, def := range directives {
+		out.WriteString("\n")
+		printDescription(out, "", def.Description)
+		out.WriteString(formatDirectiveDefinition(def) + "\n")
+	}
+
+	for _, typ := range types {
+		out.WriteString("\n")
+		printDescription(out, "", typ.description)
+		fmt.Fprintf(out, "%s %s", keyword(typ.kind), typ.name)
+		if len(typ.interfaces) > 0 {
+			out.WriteString(" implements " + strings.Join(typ.interfaces, " & "))
+		}
+		for _, directive := range typ.directives {
+			out.WriteString("\n\t" + directive)
+		}
+		switch {
+		case typ.kind == ast.Union:
+			out.WriteString("\n\t= " + strings.Join(typ.members, " | ") + "\n")
+			continue
+		case typ.kind == ast.Scalar:
+			out.WriteString("\n")
+			continue
+		case len(typ.directives) > 0:
+			out.WriteString("\n{\n")
+		default:
+			out.WriteString(" {\n")
+		}
+		for _, field := range typ.fields {
+			printDescription(out, "\t", field.description)
+			out.WriteString("\t" + field.name + formatArguments(field.args))
+			if field.typ != "" {
+				out.WriteString(": " + field.typ)
+			}
+			if field.defaultValue != nil {
+				out.WriteString(" = " + field.defaultValue.String())
+			}
+			for _, directive := range field.directives {
+				out.WriteString(" " + directive)
+			}
+			out.WriteString("\n")
+		}
+		out.WriteString("}\n")
+	}
+	return out.String()
+}
+
+// printDescription prints description as a block string, indenting every line so the
+// parser's indentation removal restores the original value.
+func printDescription(out *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(blockstring.Print(description), "\n") {
+		if line == "" {
+			out.WriteString("\n")
+			continue
+		}
+		out.WriteString(indent + line + "\n")
+	}
+}
+
+func formatArguments(args ast.ArgumentDefinitionList) string {
+	if len(args) == 0 {
+		return ""
+	}
+	var formatted []string
+	for _, arg := range args {
+		s := arg.Name + ": " + arg.Type.String()
+		if arg.DefaultValue != nil {
+			s += " = " + arg.DefaultValue.String()
+		}
+		formatted = append(formatted, s)
+	}
+	return "(" + strings.Join(formatted, ", ") + ")"
+}
+
+func formatDirective(dir *ast.Directive) string {
+	if len(dir.Arguments) == 0 {
+		return "@" + dir.Name
+	}
+	var args []string
+	for _, arg := range dir.Arguments {
+		args = append(args, arg.Name+": "+arg.Value.String())
+	}
+	return "@" + dir.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// formatDirectiveDefinition formats def without its description.
+func formatDirectiveDefinition(def *ast.DirectiveDefinition) string {
+	formatted := "directive @" + def.Name + formatArguments(def.Arguments)
+	if def.IsRepeatable {
+		formatted += " repeatable"
+	}
+	locations := make([]string, len(def.Locations))
+	for i, location := range def.Locations {
+		locations[i] = string(location)
+	}
+	return formatted + " on " + strings.Join(locations, " | ")
+}
+
+func keyword(kind ast.DefinitionKind) string {
+	switch kind {
+	case ast.Object:
+		return "type"
+	case ast.InputObject:
+		return "input"
+	}
+	return strings.ToLower(string(kind))
+}
+
+func kindName(kind ast.DefinitionKind) string {
+	return strings.ToLower(strings.ReplaceAll(string(kind), "_", " "))
+}
+
+func fieldIndex(fields []joinedField, name string) int {
+	for i, field := range fields {
+		if field.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func contains(hay []string, needle string) bool {
+	for _, s := range hay {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
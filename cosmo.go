@@ -0,0 +1,69 @@
+package gqlfetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// cosmoRouterConfig is the part of a Cosmo router execution config holding the
+// supergraph.
+type cosmoRouterConfig struct {
+	EngineConfig *struct {
+		GraphqlSchema string `json:"graphqlSchema"`
+	} `json:"engineConfig"`
+}
+
+// fetchCosmoSupergraph returns the supergraph SDL of the router execution config at
+// options.Endpoint, requested with a GET request sent like the introspection request,
+// with Headers, Auth, retries and limits.
+func fetchCosmoSupergraph(ctx context.Context, options BuildClientSchemaOptions) (string, error) {
+	if options.OutputFormat != OutputSDL {
+		return "", errors.New("the Cosmo supergraph can only be returned as SDL")
+	}
+
+	var config cosmoRouterConfig
+	decode := func(body io.Reader) error {
+		if err := json.NewDecoder(body).Decode(&config); err != nil {
+			return fmt.Errorf("failed to decode router config: %w", err)
+		}
+		return nil
+	}
+	if isLocalEndpoint(options.Endpoint) {
+		data, err := readLocalFile(options.Endpoint)
+		if err != nil {
+			return "", fmt.Errorf("failed to read router config: %w", err)
+		}
+		if err := decode(limitResponse(options, bytes.NewReader(data))); err != nil {
+			return "", err
+		}
+	} else {
+		// The router config is a plain JSON document, requested without a query.
+		options.Method = http.MethodGet
+		options.PersistedQueries = false
+		options.Variables, options.Extensions = nil, nil
+		options.debug("fetching Cosmo router config", "endpoint", options.Endpoint)
+		if err := execute(ctx, options, requestParams{}, decode); err != nil {
+			return "", fmt.Errorf("failed to fetch router config: %w", err)
+		}
+	}
+
+	if config.EngineConfig == nil || config.EngineConfig.GraphqlSchema == "" {
+		return "", errors.New("router config has no engineConfig.graphqlSchema")
+	}
+	return config.EngineConfig.GraphqlSchema, nil
+}
+
+// readLocalFile reads a file:// endpoint or standard input.
+func readLocalFile(endpoint string) ([]byte, error) {
+	if endpoint == StdinEndpoint {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(strings.TrimPrefix(endpoint, fileEndpointPrefix))
+}
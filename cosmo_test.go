@@ -0,0 +1,80 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildClientSchemaWithOptions_CosmoSupergraph(t *testing.T) {
+	const supergraph = "schema @link(url: \"https://specs.apollo.dev/federation/v2.5\", import: [\"@key\"]) {\n\tquery: Query\n}\n\ntype Query {\n\tuser: User\n}\n\ntype User @key(fields: \"id\") {\n\tid: ID!\n}\n"
+	config, err := json.Marshal(map[string]interface{}{
+		"version":      "1",
+		"engineConfig": map[string]interface{}{"graphqlSchema": supergraph},
+		"subgraphs":    []interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	unavailable := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable" && unavailable < 1 {
+			unavailable++
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer graph-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(config)
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, config, 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		endpoint         string
+		token            string
+		maxResponseBytes int64
+		expectErr        string
+	}{
+		"router config on the CDN": {endpoint: server.URL + "/org/graph/routerconfigs/latest.json", token: "graph-token"},
+		"router config file":       {endpoint: "file://" + path},
+		"retried":                  {endpoint: server.URL + "/unavailable", token: "graph-token"},
+		"invalid graph token":      {endpoint: server.URL, token: "wrong", expectErr: "401"},
+		"too large":                {endpoint: server.URL, token: "graph-token", maxResponseBytes: 64, expectErr: ErrResponseTooLarge.Error()},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:         tt.endpoint,
+				Headers:          http.Header{"Authorization": {"Bearer " + tt.token}},
+				Source:           SourceCosmoSupergraph,
+				RetryPolicy:      &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+				MaxResponseBytes: tt.maxResponseBytes,
+			})
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if schema != supergraph {
+				t.Errorf("expect:\n%v\ngot:\n%v", supergraph, schema)
+			}
+		})
+	}
+}
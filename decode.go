@@ -0,0 +1,185 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrResponseTooLarge is returned when a response exceeds MaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// limitReader fails with ErrResponseTooLarge once more than limit bytes are read.
+type limitReader struct {
+	reader io.Reader
+	limit  int64
+	read   int64
+}
+
+func (r *limitReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	if r.read > r.limit {
+		return 0, fmt.Errorf("%w: exceeds %d bytes", ErrResponseTooLarge, r.limit)
+	}
+	return n, err
+}
+
+// limitResponse applies options.MaxResponseBytes to body.
+func limitResponse(options BuildClientSchemaOptions, body io.Reader) io.Reader {
+	if options.MaxResponseBytes <= 0 {
+		return body
+	}
+	return &limitReader{reader: body, limit: options.MaxResponseBytes}
+}
+
+// decodeIntrospection decodes an introspection response token by token, decoding the
+// types one at a time so that the raw JSON of huge schemas is never buffered whole.
+// Decoding stops between types once ctx is done.
+func decodeIntrospection(ctx context.Context, reader io.Reader) (introspectionSchema, error) {
+	decoder := json.NewDecoder(reader)
+	var (
+		schema *introspectionSchema
+		errs   GraphQLErrors
+	)
+	err := decodeObject(decoder, func(key string) error {
+		switch key {
+		case "errors":
+			return decoder.Decode(&errs)
+		case "data":
+			return decodeObject(decoder, func(key string) error {
+				if key != "__schema" {
+					return skipValue(decoder)
+				}
+				return decodeSchema(ctx, decoder, &schema)
+			})
+		case "__schema":
+			return decodeSchema(ctx, decoder, &schema)
+		default:
+			return skipValue(decoder)
+		}
+	})
+	if err != nil {
+		return introspectionSchema{}, err
+	}
+
+	if len(errs) != 0 {
+		return introspectionSchema{}, errs
+	}
+	if schema == nil || schema.Types == nil {
+		return introspectionSchema{}, errors.New("introspection response does not contain a __schema")
+	}
+
+	if err := validateRootOperationTypes(*schema); err != nil {
+		return introspectionSchema{}, err
+	}
+
+	return *schema, nil
+}
+
+// decodeSchema decodes a __schema object, or null, into schema.
+func decodeSchema(ctx context.Context, decoder *json.Decoder, schema **introspectionSchema) error {
+	decoded := &introspectionSchema{}
+	err := decodeObject(decoder, func(key string) error {
+		switch key {
+		case "queryType":
+			return decoder.Decode(&decoded.QueryType)
+		case "mutationType":
+			return decoder.Decode(&decoded.MutationType)
+		case "subscriptionType":
+			return decoder.Decode(&decoded.SubscriptionType)
+		case "directives":
+			return decoder.Decode(&decoded.Directives)
+		case "types":
+			decoded.Types = []introspectionTypeDefinition{}
+			return decodeArray(decoder, func() error {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				var typ introspectionTypeDefinition
+				if err := decoder.Decode(&typ); err != nil {
+					return err
+				}
+				decoded.Types = append(decoded.Types, typ)
+				return nil
+			})
+		default:
+			return skipValue(decoder)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	*schema = decoded
+	return nil
+}
+
+// decodeObject calls field for every key of the next JSON object, which must decode
+// the value. A null object is accepted and has no fields.
+func decodeObject(decoder *json.Decoder, field func(key string) error) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if token != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object, got: %v", token)
+	}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if err := field(key.(string)); err != nil {
+			return err
+		}
+	}
+	_, err = decoder.Token()
+	return err
+}
+
+// decodeArray calls element for every element of the next JSON array. A null array is
+// accepted and has no elements.
+func decodeArray(decoder *json.Decoder, element func() error) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("expected a JSON array, got: %v", token)
+	}
+	for decoder.More() {
+		if err := element(); err != nil {
+			return err
+		}
+	}
+	_, err = decoder.Token()
+	return err
+}
+
+// skipValue discards the next JSON value without decoding it.
+func skipValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package gqlfetch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBuildClientSchemaWithOptions_MaxResponseBytes(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+	fixture, err := os.Stat("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("stat fixture: %v", err)
+	}
+
+	tests := map[string]struct {
+		limit     int64
+		expectErr error
+	}{
+		"no limit":         {limit: 0},
+		"within the limit": {limit: fixture.Size()},
+		"over the limit":   {limit: fixture.Size() - 1, expectErr: ErrResponseTooLarge},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:         server.URL,
+				MaxResponseBytes: tt.limit,
+			})
+			if !errors.Is(err, tt.expectErr) {
+				t.Errorf("expect error: %v got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func Test_decodeIntrospection(t *testing.T) {
+	tests := map[string]struct {
+		response  string
+		expectErr string
+	}{
+		"response":           {response: `{"extensions": {"cost": [1, {"a": null}]}, "data": {"__schema": {"queryType": {"name": "Query"}, "types": [{"kind": "OBJECT", "name": "Query", "fields": []}], "directives": []}}}`},
+		"bare export":        {response: `{"__schema": {"queryType": {"name": "Query"}, "types": [{"kind": "OBJECT", "name": "Query", "fields": []}]}}`},
+		"errors":             {response: `{"errors": [{"message": "boom"}], "data": null}`, expectErr: "boom"},
+		"null schema":        {response: `{"data": {"__schema": null}}`, expectErr: "does not contain a __schema"},
+		"empty object":       {response: `{}`, expectErr: "does not contain a __schema"},
+		"not an object":      {response: `[]`, expectErr: "expected a JSON object"},
+		"truncated response": {response: `{"data": {"__schema": {"types": [`, expectErr: "unexpected end of JSON input"},
+		"types not an array": {response: `{"__schema": {"types": {}}}`, expectErr: "expected a JSON array"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			schema, err := decodeIntrospection(context.Background(), strings.NewReader(tt.response))
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(schema.Types) != 1 || schema.QueryType.Name != "Query" {
+					t.Errorf("expect the Query type got: %+v", schema)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestBuildSchema_Canceled(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	schema, err := decodeIntrospection(context.Background(), strings.NewReader(string(fixture)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := map[string]struct {
+		run func() error
+	}{
+		"decode": {run: func() error {
+			_, err := decodeIntrospection(ctx, strings.NewReader(string(fixture)))
+			return err
+		}},
+		"print": {run: func() error {
+			_, err := printSchema(ctx, schema, builtinFilter{}, SDLFormat{})
+			return err
+		}},
+		"local endpoint": {run: func() error {
+			_, err := BuildClientSchemaWithOptions(ctx, BuildClientSchemaOptions{Endpoint: "file://testdata/introspection.json"})
+			return err
+		}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := tt.run(); !errors.Is(err, context.Canceled) {
+				t.Errorf("expect context.Canceled got: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// changelogSections orders the sections of a changelog entry.
+var changelogSections = []struct {
+	kind    ChangeKind
+	heading string
+}{
+	{Added, "Added"},
+	{Removed, "Removed"},
+	{Changed, "Changed"},
+	{Deprecated, "Deprecated"},
+}
+
+// Changelog renders changes as Markdown release notes under title, with a section per
+// type, or per directive, split into Added, Removed, Changed and Deprecated lists.
+// Breaking changes are flagged in bold.
+func Changelog(changes []Change, title string) string {
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "# %s\n", title)
+	if len(changes) == 0 {
+		out.WriteString("\nNo changes.\n")
+		return out.String()
+	}
+
+	groups := map[string][]Change{}
+	var names []string
+	for _, change := range changes {
+		name := changelogGroup(change.Path)
+		if groups[name] == nil {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], change)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(out, "\n## %s\n", name)
+		for _, section := range changelogSections {
+			var items []string
+			for _, change := range groups[name] {
+				if change.Kind != section.kind {
+					continue
+				}
+				item := "- " + change.Message
+				if change.Breaking {
+					item = "- **Breaking:** " + change.Message
+				}
+				items = append(items, item)
+			}
+			if len(items) > 0 {
+				fmt.Fprintf(out, "\n### %s\n\n%s\n", section.heading, strings.Join(items, "\n"))
+			}
+		}
+	}
+	return out.String()
+}
+
+// changelogGroup returns the type or directive a change path belongs to, "schema" for
+// the root operation types.
+func changelogGroup(path string) string {
+	if i := strings.IndexAny(path, ".("); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
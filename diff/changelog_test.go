@@ -0,0 +1,68 @@
+package diff
+
+import "testing"
+
+func TestChangelog(t *testing.T) {
+	oldSchema := mustLoad(t, `
+type Query {
+	user(id: ID!): User
+}
+type User {
+	id: ID!
+	name: String
+	email: String!
+}
+`)
+	newSchema := mustLoad(t, `
+type Query {
+	user(id: ID!): User
+}
+type User {
+	id: ID!
+	name: String @deprecated(reason: "use fullName")
+	fullName: String
+}
+directive @cost(weight: Int!) on FIELD_DEFINITION
+`)
+
+	tests := map[string]struct {
+		changes []Change
+		expect  string
+	}{
+		"changes": {
+			changes: Schemas(oldSchema, newSchema),
+			expect: `# Release 2.0
+
+## @cost
+
+### Added
+
+- directive @cost was added
+
+## User
+
+### Added
+
+- field User.fullName was added
+
+### Removed
+
+- **Breaking:** field User.email was removed
+
+### Deprecated
+
+- field User.name was deprecated: use fullName
+`,
+		},
+		"no changes": {
+			expect: "# Release 2.0\n\nNo changes.\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := Changelog(tt.changes, "Release 2.0"); got != tt.expect {
+				t.Errorf("expect changelog:\n%s\ngot:\n%s", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,487 @@
+// Package diff compares GraphQL schemas and classifies the differences between them,
+// so deployments can be gated on schema compatibility.
+package diff
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/zucchinho/gqlfetch"
+)
+
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "ADDED"
+	Removed ChangeKind = "REMOVED"
+	Changed ChangeKind = "CHANGED"
+	// Deprecated marks fields, input fields and enum values that were deprecated.
+	Deprecated ChangeKind = "DEPRECATED"
+)
+
+// Change describes a single difference between two schemas.
+type Change struct {
+	Kind ChangeKind
+	// Path locates the changed element, e.g. "User", "User.name", "User.friends(first)"
+	// or "@auth".
+	Path    string
+	Message string
+	// Breaking is set when the change can break existing clients.
+	Breaking bool
+}
+
+func (c Change) String() string {
+	if c.Breaking {
+		return fmt.Sprintf("%s %s: %s (breaking)", c.Kind, c.Path, c.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", c.Kind, c.Path, c.Message)
+}
+
+// HasBreaking reports whether any of the changes is breaking.
+func HasBreaking(changes []Change) bool {
+	for _, change := range changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Endpoints fetches both schemas and compares them.
+func Endpoints(ctx context.Context, oldOptions, newOptions gqlfetch.BuildClientSchemaOptions) ([]Change, error) {
+	oldSchema, err := gqlfetch.BuildClientSchemaAST(ctx, oldOptions)
+	if err != nil {
+		return nil, fmt.Errorf("fetch old schema: %w", err)
+	}
+	newSchema, err := gqlfetch.BuildClientSchemaAST(ctx, newOptions)
+	if err != nil {
+		return nil, fmt.Errorf("fetch new schema: %w", err)
+	}
+	return Schemas(oldSchema, newSchema), nil
+}
+
+// EndpointAgainstFile compares the schema fetched with options against the SDL stored
+// at path, treating the file as the old schema.
+func EndpointAgainstFile(ctx context.Context, options gqlfetch.BuildClientSchemaOptions, path string) ([]Change, error) {
+	oldSchema, err := LoadSchemaFile(path)
+	if err != nil {
+		return nil, err
+	}
+	newSchema, err := gqlfetch.BuildClientSchemaAST(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("fetch schema: %w", err)
+	}
+	return Schemas(oldSchema, newSchema), nil
+}
+
+// BreakingChangesError is returned by Check when the schema has breaking changes.
+type BreakingChangesError struct {
+	Changes []Change
+}
+
+func (e *BreakingChangesError) Error() string {
+	breaking := 0
+	for _, change := range e.Changes {
+		if change.Breaking {
+			breaking++
+		}
+	}
+	return fmt.Sprintf("found %d breaking changes against the baseline schema", breaking)
+}
+
+// Check compares the schema fetched with options against the baseline SDL file and
+// returns every change, with a *BreakingChangesError when any of them is breaking.
+func Check(ctx context.Context, options gqlfetch.BuildClientSchemaOptions, baseline string) ([]Change, error) {
+	changes, err := EndpointAgainstFile(ctx, options, baseline)
+	if err != nil {
+		return nil, err
+	}
+	if HasBreaking(changes) {
+		return changes, &BreakingChangesError{Changes: changes}
+	}
+	return changes, nil
+}
+
+// LoadSchemaFile loads an SDL file, such as one written from BuildClientSchema.
+func LoadSchemaFile(path string) (*ast.Schema, error) {
+	sdl, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := gqlfetch.LoadSchema(&ast.Source{Name: path, Input: string(sdl)})
+	if err != nil {
+		return nil, fmt.Errorf("load schema %s: %w", path, err)
+	}
+	return schema, nil
+}
+
+// Schemas reports the changes needed to turn oldSchema into newSchema, ordered by path.
+func Schemas(oldSchema, newSchema *ast.Schema) []Change {
+	var changes []Change
+
+	for _, name := range typeNames(oldSchema.Types, newSchema.Types) {
+		oldDef, newDef := oldSchema.Types[name], newSchema.Types[name]
+		switch {
+		case isBuiltIn(oldDef) || isBuiltIn(newDef):
+		case newDef == nil:
+			changes = append(changes, Change{Kind: Removed, Path: name, Message: fmt.Sprintf("%s %s was removed", kindName(oldDef.Kind), name), Breaking: true})
+		case oldDef == nil:
+			changes = append(changes, Change{Kind: Added, Path: name, Message: fmt.Sprintf("%s %s was added", kindName(newDef.Kind), name)})
+		case oldDef.Kind != newDef.Kind:
+			changes = append(changes, Change{Kind: Changed, Path: name, Message: fmt.Sprintf("%s changed from %s to %s", name, kindName(oldDef.Kind), kindName(newDef.Kind)), Breaking: true})
+		default:
+			changes = append(changes, diffDefinition(oldDef, newDef)...)
+		}
+	}
+
+	for _, name := range directiveNames(oldSchema.Directives, newSchema.Directives) {
+		oldDir, newDir := oldSchema.Directives[name], newSchema.Directives[name]
+		path := "@" + name
+		switch {
+		case isBuiltInDirective(oldDir) || isBuiltInDirective(newDir):
+		case newDir == nil:
+			changes = append(changes, Change{Kind: Removed, Path: path, Message: fmt.Sprintf("directive %s was removed", path), Breaking: true})
+		case oldDir == nil:
+			changes = append(changes, Change{Kind: Added, Path: path, Message: fmt.Sprintf("directive %s was added", path)})
+		default:
+			changes = append(changes, diffDirective(oldDir, newDir)...)
+		}
+	}
+
+	changes = append(changes, diffRoots(oldSchema, newSchema)...)
+
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func diffRoots(oldSchema, newSchema *ast.Schema) []Change {
+	var changes []Change
+	roots := []struct {
+		operation ast.Operation
+		old, new  *ast.Definition
+	}{
+		{ast.Query, oldSchema.Query, newSchema.Query},
+		{ast.Mutation, oldSchema.Mutation, newSchema.Mutation},
+		{ast.Subscription, oldSchema.Subscription, newSchema.Subscription},
+	}
+	for _, root := range roots {
+		oldName, newName := definitionName(root.old), definitionName(root.new)
+		if oldName == newName {
+			continue
+		}
+		path := "schema." + string(root.operation)
+		switch {
+		case newName == "":
+			changes = append(changes, Change{Kind: Removed, Path: path, Message: fmt.Sprintf("%s root %s was removed", root.operation, oldName), Breaking: true})
+		case oldName == "":
+			changes = append(changes, Change{Kind: Added, Path: path, Message: fmt.Sprintf("%s root %s was added", root.operation, newName)})
+		default:
+			changes = append(changes, Change{Kind: Changed, Path: path, Message: fmt.Sprintf("%s root changed from %s to %s", root.operation, oldName, newName), Breaking: true})
+		}
+	}
+	return changes
+}
+
+func diffDefinition(oldDef, newDef *ast.Definition) []Change {
+	var changes []Change
+
+	switch oldDef.Kind {
+	case ast.Object, ast.Interface:
+		changes = append(changes, diffMembers(oldDef.Name, "interface", oldDef.Interfaces, newDef.Interfaces)...)
+		changes = append(changes, diffOutputFields(oldDef, newDef)...)
+	case ast.InputObject:
+		changes = append(changes, diffInputFields(oldDef, newDef)...)
+	case ast.Union:
+		changes = append(changes, diffMembers(oldDef.Name, "member", oldDef.Types, newDef.Types)...)
+	case ast.Enum:
+		changes = append(changes, diffEnumValues(oldDef, newDef)...)
+	}
+
+	return changes
+}
+
+// diffMembers compares the implemented interfaces of an object or the members of a
+// union, removing either breaks fragments spread on them.
+func diffMembers(typeName, noun string, oldNames, newNames []string) []Change {
+	var changes []Change
+	for _, name := range oldNames {
+		if !contains(newNames, name) {
+			changes = append(changes, Change{Kind: Removed, Path: typeName, Message: fmt.Sprintf("%s %s was removed from %s", noun, name, typeName), Breaking: true})
+		}
+	}
+	for _, name := range newNames {
+		if !contains(oldNames, name) {
+			changes = append(changes, Change{Kind: Added, Path: typeName, Message: fmt.Sprintf("%s %s was added to %s", noun, name, typeName)})
+		}
+	}
+	return changes
+}
+
+func diffOutputFields(oldDef, newDef *ast.Definition) []Change {
+	var changes []Change
+	for _, oldField := range oldDef.Fields {
+		if strings.HasPrefix(oldField.Name, "__") {
+			continue
+		}
+		path := oldDef.Name + "." + oldField.Name
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			changes = append(changes, Change{Kind: Removed, Path: path, Message: fmt.Sprintf("field %s was removed", path), Breaking: true})
+			continue
+		}
+		if oldField.Type.String() != newField.Type.String() {
+			changes = append(changes, Change{
+				Kind:     Changed,
+				Path:     path,
+				Message:  fmt.Sprintf("field %s changed type from %s to %s", path, oldField.Type, newField.Type),
+				Breaking: !isSafeOutputChange(oldField.Type, newField.Type),
+			})
+		}
+		changes = append(changes, diffDeprecation(path, "field", oldField.Directives, newField.Directives)...)
+		changes = append(changes, diffArguments(path, oldField.Arguments, newField.Arguments)...)
+	}
+	for _, newField := range newDef.Fields {
+		if strings.HasPrefix(newField.Name, "__") || oldDef.Fields.ForName(newField.Name) != nil {
+			continue
+		}
+		path := newDef.Name + "." + newField.Name
+		changes = append(changes, Change{Kind: Added, Path: path, Message: fmt.Sprintf("field %s was added", path)})
+	}
+	return changes
+}
+
+func diffInputFields(oldDef, newDef *ast.Definition) []Change {
+	var changes []Change
+	for _, oldField := range oldDef.Fields {
+		path := oldDef.Name + "." + oldField.Name
+		newField := newDef.Fields.ForName(oldField.Name)
+		if newField == nil {
+			changes = append(changes, Change{Kind: Removed, Path: path, Message: fmt.Sprintf("input field %s was removed", path), Breaking: true})
+			continue
+		}
+		changes = append(changes, diffInputValue(path, "input field", oldField.Type, newField.Type, oldField.DefaultValue, newField.DefaultValue)...)
+		changes = append(changes, diffDeprecation(path, "input field", oldField.Directives, newField.Directives)...)
+	}
+	for _, newField := range newDef.Fields {
+		if oldDef.Fields.ForName(newField.Name) != nil {
+			continue
+		}
+		path := newDef.Name + "." + newField.Name
+		changes = append(changes, Change{
+			Kind:     Added,
+			Path:     path,
+			Message:  fmt.Sprintf("input field %s was added", path),
+			Breaking: isRequired(newField.Type, newField.DefaultValue),
+		})
+	}
+	return changes
+}
+
+func diffArguments(parent string, oldArgs, newArgs ast.ArgumentDefinitionList) []Change {
+	var changes []Change
+	for _, oldArg := range oldArgs {
+		path := fmt.Sprintf("%s(%s)", parent, oldArg.Name)
+		newArg := newArgs.ForName(oldArg.Name)
+		if newArg == nil {
+			changes = append(changes, Change{Kind: Removed, Path: path, Message: fmt.Sprintf("argument %s was removed", path), Breaking: true})
+			continue
+		}
+		changes = append(changes, diffInputValue(path, "argument", oldArg.Type, newArg.Type, oldArg.DefaultValue, newArg.DefaultValue)...)
+	}
+	for _, newArg := range newArgs {
+		if oldArgs.ForName(newArg.Name) != nil {
+			continue
+		}
+		path := fmt.Sprintf("%s(%s)", parent, newArg.Name)
+		changes = append(changes, Change{
+			Kind:     Added,
+			Path:     path,
+			Message:  fmt.Sprintf("argument %s was added", path),
+			Breaking: isRequired(newArg.Type, newArg.DefaultValue),
+		})
+	}
+	return changes
+}
+
+func diffInputValue(path, noun string, oldType, newType *ast.Type, oldDefault, newDefault *ast.Value) []Change {
+	var changes []Change
+	if oldType.String() != newType.String() {
+		changes = append(changes, Change{
+			Kind:     Changed,
+			Path:     path,
+			Message:  fmt.Sprintf("%s %s changed type from %s to %s", noun, path, oldType, newType),
+			Breaking: !isSafeInputChange(oldType, newType),
+		})
+	}
+	if valueString(oldDefault) != valueString(newDefault) {
+		changes = append(changes, Change{
+			Kind:    Changed,
+			Path:    path,
+			Message: fmt.Sprintf("%s %s changed default value from %s to %s", noun, path, valueString(oldDefault), valueString(newDefault)),
+		})
+	}
+	return changes
+}
+
+func diffEnumValues(oldDef, newDef *ast.Definition) []Change {
+	var changes []Change
+	for _, oldValue := range oldDef.EnumValues {
+		path := oldDef.Name + "." + oldValue.Name
+		newValue := newDef.EnumValues.ForName(oldValue.Name)
+		if newValue == nil {
+			changes = append(changes, Change{Kind: Removed, Path: path, Message: fmt.Sprintf("enum value %s was removed", path), Breaking: true})
+			continue
+		}
+		changes = append(changes, diffDeprecation(path, "enum value", oldValue.Directives, newValue.Directives)...)
+	}
+	for _, newValue := range newDef.EnumValues {
+		if oldDef.EnumValues.ForName(newValue.Name) == nil {
+			path := newDef.Name + "." + newValue.Name
+			changes = append(changes, Change{Kind: Added, Path: path, Message: fmt.Sprintf("enum value %s was added", path)})
+		}
+	}
+	return changes
+}
+
+func diffDeprecation(path, noun string, oldDirectives, newDirectives ast.DirectiveList) []Change {
+	oldDeprecated, newDeprecated := oldDirectives.ForName("deprecated"), newDirectives.ForName("deprecated")
+	switch {
+	case oldDeprecated == nil && newDeprecated != nil:
+		message := fmt.Sprintf("%s %s was deprecated", noun, path)
+		if reason := newDeprecated.Arguments.ForName("reason"); reason != nil && reason.Value != nil {
+			message += ": " + reason.Value.Raw
+		}
+		return []Change{{Kind: Deprecated, Path: path, Message: message}}
+	case oldDeprecated != nil && newDeprecated == nil:
+		return []Change{{Kind: Changed, Path: path, Message: fmt.Sprintf("%s %s is no longer deprecated", noun, path)}}
+	}
+	return nil
+}
+
+func diffDirective(oldDir, newDir *ast.DirectiveDefinition) []Change {
+	path := "@" + oldDir.Name
+	var changes []Change
+	for _, location := range oldDir.Locations {
+		if !containsLocation(newDir.Locations, location) {
+			changes = append(changes, Change{Kind: Removed, Path: path, Message: fmt.Sprintf("location %s was removed from directive %s", location, path), Breaking: true})
+		}
+	}
+	for _, location := range newDir.Locations {
+		if !containsLocation(oldDir.Locations, location) {
+			changes = append(changes, Change{Kind: Added, Path: path, Message: fmt.Sprintf("location %s was added to directive %s", location, path)})
+		}
+	}
+	return append(changes, diffArguments(path, oldDir.Arguments, newDir.Arguments)...)
+}
+
+// isSafeOutputChange reports whether clients reading a value of type oldType can still
+// read a value of newType, which holds when newType only adds non-null guarantees.
+func isSafeOutputChange(oldType, newType *ast.Type) bool {
+	if oldType.NonNull && !newType.NonNull {
+		return false
+	}
+	if oldType.Elem != nil || newType.Elem != nil {
+		if oldType.Elem == nil || newType.Elem == nil {
+			return false
+		}
+		return isSafeOutputChange(oldType.Elem, newType.Elem)
+	}
+	return oldType.NamedType == newType.NamedType
+}
+
+// isSafeInputChange reports whether every value clients sent as oldType is still
+// accepted as newType, which holds when newType only relaxes non-null requirements.
+func isSafeInputChange(oldType, newType *ast.Type) bool {
+	if newType.NonNull && !oldType.NonNull {
+		return false
+	}
+	if oldType.Elem != nil || newType.Elem != nil {
+		if oldType.Elem == nil || newType.Elem == nil {
+			return false
+		}
+		return isSafeInputChange(oldType.Elem, newType.Elem)
+	}
+	return oldType.NamedType == newType.NamedType
+}
+
+func isRequired(typ *ast.Type, defaultValue *ast.Value) bool {
+	return typ.NonNull && defaultValue == nil
+}
+
+func isBuiltIn(def *ast.Definition) bool {
+	return def != nil && (def.BuiltIn || strings.HasPrefix(def.Name, "__"))
+}
+
+// isBuiltInDirective reports whether dir comes from the prelude, such as @specifiedBy,
+// which gqlfetch only declares when a schema uses it.
+func isBuiltInDirective(dir *ast.DirectiveDefinition) bool {
+	return dir != nil && dir.Position != nil && dir.Position.Src != nil && dir.Position.Src.BuiltIn
+}
+
+func kindName(kind ast.DefinitionKind) string {
+	return strings.ToLower(strings.ReplaceAll(string(kind), "_", " "))
+}
+
+func definitionName(def *ast.Definition) string {
+	if def == nil {
+		return ""
+	}
+	return def.Name
+}
+
+func valueString(value *ast.Value) string {
+	if value == nil {
+		return "none"
+	}
+	return value.String()
+}
+
+func contains(hay []string, needle string) bool {
+	for _, s := range hay {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
+
+func containsLocation(hay []ast.DirectiveLocation, needle ast.DirectiveLocation) bool {
+	for _, location := range hay {
+		if location == needle {
+			return true
+		}
+	}
+	return false
+}
+
+func typeNames(oldTypes, newTypes map[string]*ast.Definition) []string {
+	var names []string
+	for name := range oldTypes {
+		names = append(names, name)
+	}
+	for name := range newTypes {
+		if oldTypes[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func directiveNames(oldDirectives, newDirectives map[string]*ast.DirectiveDefinition) []string {
+	var names []string
+	for name := range oldDirectives {
+		names = append(names, name)
+	}
+	for name := range newDirectives {
+		if oldDirectives[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
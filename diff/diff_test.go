@@ -0,0 +1,127 @@
+package diff
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/zucchinho/gqlfetch"
+)
+
+func mustLoad(t *testing.T, sdl string) *ast.Schema {
+	t.Helper()
+	schema, err := gqlfetch.LoadSchema(&ast.Source{Input: sdl})
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	return schema
+}
+
+func TestSchemas(t *testing.T) {
+	oldSchema := mustLoad(t, `
+type Query {
+	user(id: ID!): User
+	users(first: Int): [User!]!
+}
+type User {
+	id: ID!
+	name: String
+	email: String!
+}
+enum Role { ADMIN USER }
+input Filter { role: Role }
+union Result = User
+`)
+	newSchema := mustLoad(t, `
+type Query {
+	user(id: ID!, tenant: String!): User
+	users(first: Int, after: String): [User!]
+}
+type User {
+	id: ID!
+	name: String!
+	age: Int
+}
+type Group { id: ID! }
+enum Role { ADMIN GUEST }
+input Filter { role: Role, name: String! }
+union Result = User | Group
+`)
+
+	tests := map[string]struct {
+		kind     ChangeKind
+		path     string
+		breaking bool
+	}{
+		"required argument added":    {kind: Added, path: "Query.user(tenant)", breaking: true},
+		"optional argument added":    {kind: Added, path: "Query.users(after)", breaking: false},
+		"output made nullable":       {kind: Changed, path: "Query.users", breaking: true},
+		"output made non-null":       {kind: Changed, path: "User.name", breaking: false},
+		"field removed":              {kind: Removed, path: "User.email", breaking: true},
+		"field added":                {kind: Added, path: "User.age", breaking: false},
+		"type added":                 {kind: Added, path: "Group", breaking: false},
+		"enum value removed":         {kind: Removed, path: "Role.USER", breaking: true},
+		"enum value added":           {kind: Added, path: "Role.GUEST", breaking: false},
+		"required input field added": {kind: Added, path: "Filter.name", breaking: true},
+		"union member added":         {kind: Added, path: "Result", breaking: false},
+	}
+
+	changes := Schemas(oldSchema, newSchema)
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for _, change := range changes {
+				if change.Kind == tt.kind && change.Path == tt.path {
+					if change.Breaking != tt.breaking {
+						t.Errorf("expect %s breaking: %v got: %v", tt.path, tt.breaking, change.Breaking)
+					}
+					return
+				}
+			}
+			t.Errorf("expect %s change at %s got: %v", tt.kind, tt.path, changes)
+		})
+	}
+
+	if !HasBreaking(changes) {
+		t.Errorf("expect breaking changes")
+	}
+	if got := Schemas(oldSchema, oldSchema); len(got) != 0 {
+		t.Errorf("expect no changes comparing a schema to itself got: %v", got)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	options := gqlfetch.BuildClientSchemaOptions{Endpoint: "file://../testdata/introspection.json"}
+	sdl, err := gqlfetch.BuildClientSchemaWithOptions(ctx, options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		baseline       string
+		expectBreaking bool
+	}{
+		"unchanged":     {baseline: sdl},
+		"field removed": {baseline: sdl + "\nextend type Query {\n\tlegacy: String\n}\n", expectBreaking: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "schema.graphql")
+			if err := os.WriteFile(path, []byte(tt.baseline), 0o644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			changes, err := Check(ctx, options, path)
+			var breaking *BreakingChangesError
+			if errors.As(err, &breaking) != tt.expectBreaking {
+				t.Fatalf("expect breaking changes error: %v got: %v", tt.expectBreaking, err)
+			}
+			if tt.expectBreaking && !reflect.DeepEqual(breaking.Changes, changes) {
+				t.Errorf("expect the error to carry the changes %v got: %v", changes, breaking.Changes)
+			}
+		})
+	}
+}
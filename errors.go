@@ -0,0 +1,73 @@
+package gqlfetch
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrIntrospectionDisabled is matched by GraphQLErrors reporting that the server
+// doesn't allow introspection.
+var ErrIntrospectionDisabled = errors.New("introspection is disabled on the endpoint")
+
+// introspectionDisabledMessages are lowercase fragments of the errors servers report
+// when introspection is turned off.
+var introspectionDisabledMessages = []string{
+	"introspection is not allowed",
+	"introspection is disabled",
+	"introspection has been disabled",
+	"introspection queries are not allowed",
+	"introspection not allowed",
+}
+
+// GraphQLError is an error reported in the errors entry of a GraphQL response.
+type GraphQLError struct {
+	Message   string                 `json:"message"`
+	Locations []GraphQLErrorLocation `json:"locations,omitempty"`
+	// Path holds field names as strings and list indices as float64.
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLErrorLocation points at the part of the query an error relates to.
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (e GraphQLError) Error() string {
+	return e.Message
+}
+
+// GraphQLErrors is returned when the endpoint responds with GraphQL errors.
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	var messages []string
+	for _, err := range e {
+		messages = append(messages, err.Message)
+	}
+	return "encountered the following GraphQL errors: " + strings.Join(messages, ",")
+}
+
+// Is reports whether any of the errors says that introspection is disabled, or that a
+// persisted query isn't known to the server.
+func (e GraphQLErrors) Is(target error) bool {
+	switch target {
+	case ErrIntrospectionDisabled:
+		for _, err := range e {
+			message := strings.ToLower(err.Message)
+			for _, fragment := range introspectionDisabledMessages {
+				if strings.Contains(message, fragment) {
+					return true
+				}
+			}
+		}
+	case errPersistedQueryNotFound:
+		for _, err := range e {
+			if err.Message == "PersistedQueryNotFound" || err.Extensions["code"] == "PERSISTED_QUERY_NOT_FOUND" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// docType is a type of the reference documentation.
+type docType struct {
+	Name        string
+	Kind        string
+	Description string
+	// Interfaces the type implements, or the members of a union.
+	Interfaces []string
+	Members    []string
+	Fields     []docField
+	Values     []docField
+}
+
+// docField is a field, argument, input field or enum value.
+type docField struct {
+	Name        string
+	Type        string
+	TypeName    string
+	Description string
+	Default     string
+	Deprecated  bool
+	Reason      string
+	Args        []docField
+}
+
+var docKinds = []struct {
+	kind    ast.DefinitionKind
+	heading string
+}{
+	{ast.Object, "Objects"},
+	{ast.Interface, "Interfaces"},
+	{ast.Union, "Unions"},
+	{ast.Enum, "Enums"},
+	{ast.InputObject, "Input objects"},
+	{ast.Scalar, "Scalars"},
+}
+
+// docSection is a group of types documented under a heading.
+type docSection struct {
+	Heading string
+	Types   []docType
+}
+
+// docSections groups the types of schema by kind, the root operation types first.
+func docSections(schema *ast.Schema) []docSection {
+	var sections []docSection
+	roots := map[string]bool{}
+	var rootTypes []docType
+	for _, root := range []*ast.Definition{schema.Query, schema.Mutation, schema.Subscription} {
+		if root != nil {
+			roots[root.Name] = true
+			rootTypes = append(rootTypes, newDocType(root))
+		}
+	}
+	sections = append(sections, docSection{Heading: "Operations", Types: rootTypes})
+
+	types := exportedTypes(schema)
+	for _, kind := range docKinds {
+		section := docSection{Heading: kind.heading}
+		for _, def := range types {
+			if def.Kind == kind.kind && !roots[def.Name] {
+				section.Types = append(section.Types, newDocType(def))
+			}
+		}
+		if len(section.Types) > 0 {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+func newDocType(def *ast.Definition) docType {
+	typ := docType{
+		Name:        def.Name,
+		Kind:        strings.ToLower(strings.ReplaceAll(string(def.Kind), "_", " ")),
+		Description: def.Description,
+		Interfaces:  def.Interfaces,
+		Members:     def.Types,
+	}
+	for _, field := range def.Fields {
+		if strings.HasPrefix(field.Name, "__") {
+			continue
+		}
+		doc := newDocField(field.Name, field.Type, field.Description, field.DefaultValue, field.Directives)
+		for _, arg := range field.Arguments {
+			doc.Args = append(doc.Args, newDocField(arg.Name, arg.Type, arg.Description, arg.DefaultValue, arg.Directives))
+		}
+		typ.Fields = append(typ.Fields, doc)
+	}
+	for _, value := range def.EnumValues {
+		typ.Values = append(typ.Values, newDocField(value.Name, nil, value.Description, nil, value.Directives))
+	}
+	return typ
+}
+
+func newDocField(name string, typ *ast.Type, description string, defaultValue *ast.Value, directives ast.DirectiveList) docField {
+	field := docField{Name: name, Description: description}
+	if typ != nil {
+		field.Type, field.TypeName = typ.String(), typ.Name()
+		if _, ok := builtinJSONTypes[field.TypeName]; ok {
+			field.TypeName = ""
+		}
+	}
+	if defaultValue != nil {
+		field.Default = defaultValue.String()
+	}
+	if deprecated := directives.ForName("deprecated"); deprecated != nil {
+		field.Deprecated = true
+		if reason := deprecated.Arguments.ForName("reason"); reason != nil && reason.Value != nil {
+			field.Reason = reason.Value.Raw
+		}
+	}
+	return field
+}
+
+// Markdown returns reference documentation of schema as a single Markdown page, with
+// a section per type linked from the types of fields and arguments.
+func Markdown(schema *ast.Schema, title string) ([]byte, error) {
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "# %s\n", title)
+	for _, section := range docSections(schema) {
+		fmt.Fprintf(out, "\n## %s\n", section.Heading)
+		for _, typ := range section.Types {
+			fmt.Fprintf(out, "\n### %s\n\n*%s*", typ.Name, typ.Kind)
+			if len(typ.Interfaces) > 0 {
+				fmt.Fprintf(out, ", implements %s", markdownLinks(typ.Interfaces))
+			}
+			if len(typ.Members) > 0 {
+				fmt.Fprintf(out, " of %s", markdownLinks(typ.Members))
+			}
+			out.WriteString("\n")
+			if typ.Description != "" {
+				fmt.Fprintf(out, "\n%s\n", typ.Description)
+			}
+			if len(typ.Fields) > 0 {
+				out.WriteString("\n")
+			}
+			for _, field := range typ.Fields {
+				fmt.Fprintf(out, "- %s\n", markdownField(field))
+				for _, arg := range field.Args {
+					fmt.Fprintf(out, "  - %s\n", markdownField(arg))
+				}
+			}
+			if len(typ.Values) > 0 {
+				out.WriteString("\n")
+			}
+			for _, value := range typ.Values {
+				fmt.Fprintf(out, "- %s\n", markdownField(value))
+			}
+		}
+	}
+	return []byte(out.String()), nil
+}
+
+func markdownField(field docField) string {
+	out := "`" + field.Name + "`"
+	if field.Type != "" {
+		out += ": " + markdownLink(field.Type, field.TypeName)
+	}
+	if field.Default != "" {
+		out += " = `" + field.Default + "`"
+	}
+	if field.Description != "" {
+		out += " — " + strings.ReplaceAll(field.Description, "\n", " ")
+	}
+	if field.Deprecated {
+		out += " **Deprecated**"
+		if field.Reason != "" {
+			out += ": " + field.Reason
+		}
+	}
+	return out
+}
+
+func markdownLink(text, typeName string) string {
+	if typeName == "" {
+		return "`" + text + "`"
+	}
+	return "[`" + text + "`](#" + strings.ToLower(typeName) + ")"
+}
+
+func markdownLinks(names []string) string {
+	links := make([]string, len(names))
+	for i, name := range names {
+		links[i] = markdownLink(name, name)
+	}
+	return strings.Join(links, ", ")
+}
+
+var htmlTemplate = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: auto; padding: 1em; line-height: 1.5; }
+code { background: #f4f4f4; padding: 0 .2em; }
+.kind { color: #666; font-style: italic; }
+.deprecated { color: #a00; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<nav>
+{{- range .Sections}}
+<h4>{{.Heading}}</h4>
+<ul>{{range .Types}}<li><a href="#{{lower .Name}}">{{.Name}}</a></li>{{end}}</ul>
+{{- end}}
+</nav>
+{{- define "field"}}<code>{{.Name}}</code>{{if .Type}}: {{if .TypeName}}<a href="#{{lower .TypeName}}"><code>{{.Type}}</code></a>{{else}}<code>{{.Type}}</code>{{end}}{{end}}{{if .Default}} = <code>{{.Default}}</code>{{end}}{{if .Description}} — {{.Description}}{{end}}{{if .Deprecated}} <span class="deprecated">Deprecated{{if .Reason}}: {{.Reason}}{{end}}</span>{{end}}{{end}}
+{{- range .Sections}}
+<h2>{{.Heading}}</h2>
+{{- range .Types}}
+<section id="{{lower .Name}}">
+<h3>{{.Name}}</h3>
+<p class="kind">{{.Kind}}{{if .Interfaces}}, implements {{range $i, $name := .Interfaces}}{{if $i}}, {{end}}<a href="#{{lower $name}}">{{$name}}</a>{{end}}{{end}}{{if .Members}} of {{range $i, $name := .Members}}{{if $i}}, {{end}}<a href="#{{lower $name}}">{{$name}}</a>{{end}}{{end}}</p>
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+{{- if .Fields}}
+<ul>
+{{- range .Fields}}
+<li>{{template "field" .}}{{if .Args}}<ul>{{range .Args}}<li>{{template "field" .}}</li>{{end}}</ul>{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Values}}
+<ul>
+{{- range .Values}}
+<li>{{template "field" .}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</section>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// HTML returns reference documentation of schema as a static HTML page, with a table of
+// contents and the same content as Markdown.
+func HTML(schema *ast.Schema, title string) ([]byte, error) {
+	out := &bytes.Buffer{}
+	err := htmlTemplate.Execute(out, struct {
+		Title    string
+		Sections []docSection
+	}{Title: title, Sections: docSections(schema)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render documentation: %w", err)
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/zucchinho/gqlfetch"
+)
+
+func TestDocs(t *testing.T) {
+	schema, err := gqlfetch.LoadSchema(&ast.Source{Input: testSchema + `
+extend type Query {
+	"Lists users, <b>paginated</b>."
+	users(first: Int = 10): [User!]!
+}
+`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		render func(*ast.Schema, string) ([]byte, error)
+		expect []string
+	}{
+		"Markdown": {
+			render: Markdown,
+			expect: []string{
+				"# Users API\n",
+				"## Operations\n\n### Query\n",
+				"- `users`: [`[User!]!`](#user) — Lists users, <b>paginated</b>.\n  - `first`: `Int` = `10`\n",
+				"*object*, implements [`Node`](#node)\n\nA registered user.\n",
+				"- `name`: `String` **Deprecated**: use fullName\n",
+				"*union* of [`User`](#user)\n",
+				"### UserInput\n\n*input object*\n",
+			},
+		},
+		"HTML": {
+			render: HTML,
+			expect: []string{
+				"<title>Users API</title>",
+				`<li><a href="#user">User</a></li>`,
+				`<code>users</code>: <a href="#user"><code>[User!]!</code></a> — Lists users, &lt;b&gt;paginated&lt;/b&gt;.`,
+				`<li><code>first</code>: <code>Int</code> = <code>10</code></li>`,
+				`<span class="deprecated">Deprecated: use fullName</span>`,
+				`<section id="userinput">`,
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			out, err := tt.render(schema, "Users API")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(string(out), expect) {
+					t.Errorf("expect documentation to contain %q got:\n%s", expect, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// GraphOptions selects the types of a type graph.
+type GraphOptions struct {
+	// Root is the type the graph starts from, the root operation types when empty.
+	Root string
+	// Depth bounds the number of relationships followed from the root, zero meaning
+	// no bound.
+	Depth int
+}
+
+// graphEdge is a relationship between two types: the fields of From returning To, From
+// implementing the interface To, or To being a member of the union From.
+type graphEdge struct {
+	From, To string
+	Fields   []string
+	Kind     string
+}
+
+const (
+	edgeField      = "field"
+	edgeImplements = "implements"
+	edgeMember     = "member"
+)
+
+// DOT returns a Graphviz graph of the relationships between the object, interface,
+// union, input object and enum types reachable from options.Root.
+func DOT(schema *ast.Schema, options GraphOptions) ([]byte, error) {
+	nodes, edges, err := typeGraph(schema, options)
+	if err != nil {
+		return nil, err
+	}
+	out := &strings.Builder{}
+	out.WriteString("digraph schema {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, node := range nodes {
+		def := schema.Types[node]
+		fmt.Fprintf(out, "  %q [label=%q", node, node+"\n"+strings.ToLower(strings.ReplaceAll(string(def.Kind), "_", " ")))
+		switch def.Kind {
+		case ast.Interface, ast.Union:
+			out.WriteString(", style=rounded")
+		case ast.Enum, ast.InputObject:
+			out.WriteString(", style=dashed")
+		}
+		out.WriteString("];\n")
+	}
+	for _, edge := range edges {
+		fmt.Fprintf(out, "  %q -> %q", edge.From, edge.To)
+		switch edge.Kind {
+		case edgeField:
+			fmt.Fprintf(out, " [label=%q]", strings.Join(edge.Fields, ", "))
+		case edgeImplements:
+			out.WriteString(" [style=dashed, arrowhead=empty]")
+		case edgeMember:
+			out.WriteString(" [style=dotted]")
+		}
+		out.WriteString(";\n")
+	}
+	out.WriteString("}\n")
+	return []byte(out.String()), nil
+}
+
+// Mermaid returns the graph of DOT as a Mermaid flowchart.
+func Mermaid(schema *ast.Schema, options GraphOptions) ([]byte, error) {
+	nodes, edges, err := typeGraph(schema, options)
+	if err != nil {
+		return nil, err
+	}
+	out := &strings.Builder{}
+	out.WriteString("flowchart LR\n")
+	for _, node := range nodes {
+		switch schema.Types[node].Kind {
+		case ast.Interface, ast.Union:
+			fmt.Fprintf(out, "  %s([%s])\n", node, node)
+		default:
+			fmt.Fprintf(out, "  %s[%s]\n", node, node)
+		}
+	}
+	for _, edge := range edges {
+		switch edge.Kind {
+		case edgeField:
+			fmt.Fprintf(out, "  %s -->|%s| %s\n", edge.From, strings.Join(edge.Fields, ", "), edge.To)
+		case edgeImplements:
+			fmt.Fprintf(out, "  %s -.->|implements| %s\n", edge.From, edge.To)
+		case edgeMember:
+			fmt.Fprintf(out, "  %s -.-> %s\n", edge.From, edge.To)
+		}
+	}
+	return []byte(out.String()), nil
+}
+
+// typeGraph returns the sorted names of the types reachable from options.Root and the
+// relationships between them.
+func typeGraph(schema *ast.Schema, options GraphOptions) ([]string, []graphEdge, error) {
+	var roots []string
+	if options.Root != "" {
+		if schema.Types[options.Root] == nil {
+			return nil, nil, fmt.Errorf("type %s not found", options.Root)
+		}
+		roots = []string{options.Root}
+	} else {
+		for _, root := range []*ast.Definition{schema.Query, schema.Mutation, schema.Subscription} {
+			if root != nil {
+				roots = append(roots, root.Name)
+			}
+		}
+	}
+
+	graphed := func(name string) bool {
+		def := schema.Types[name]
+		return def != nil && def.Kind != ast.Scalar && !strings.HasPrefix(name, "__")
+	}
+	depth := map[string]int{}
+	queue := []string{}
+	for _, root := range roots {
+		depth[root] = 0
+		queue = append(queue, root)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if options.Depth > 0 && depth[name] >= options.Depth {
+			continue
+		}
+		def := schema.Types[name]
+		var neighbors []string
+		for _, field := range def.Fields {
+			if !strings.HasPrefix(field.Name, "__") {
+				neighbors = append(neighbors, field.Type.Name())
+			}
+		}
+		neighbors = append(neighbors, def.Interfaces...)
+		neighbors = append(neighbors, def.Types...)
+		for _, implementation := range schema.PossibleTypes[name] {
+			if def.Kind == ast.Interface {
+				neighbors = append(neighbors, implementation.Name)
+			}
+		}
+		for _, neighbor := range neighbors {
+			if _, seen := depth[neighbor]; !seen && graphed(neighbor) {
+				depth[neighbor] = depth[name] + 1
+				queue = append(queue, neighbor)
+			}
+		}
+	}
+
+	nodes := make([]string, 0, len(depth))
+	for name := range depth {
+		nodes = append(nodes, name)
+	}
+	sort.Strings(nodes)
+
+	var edges []graphEdge
+	for _, name := range nodes {
+		def := schema.Types[name]
+		fields := map[string][]string{}
+		var targets []string
+		for _, field := range def.Fields {
+			target := field.Type.Name()
+			if _, ok := depth[target]; !ok || strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			if fields[target] == nil {
+				targets = append(targets, target)
+			}
+			fields[target] = append(fields[target], field.Name)
+		}
+		for _, target := range targets {
+			edges = append(edges, graphEdge{From: name, To: target, Fields: fields[target], Kind: edgeField})
+		}
+		for _, iface := range def.Interfaces {
+			if _, ok := depth[iface]; ok {
+				edges = append(edges, graphEdge{From: name, To: iface, Kind: edgeImplements})
+			}
+		}
+		for _, member := range def.Types {
+			if _, ok := depth[member]; ok {
+				edges = append(edges, graphEdge{From: name, To: member, Kind: edgeMember})
+			}
+		}
+	}
+	return nodes, edges, nil
+}
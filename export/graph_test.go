@@ -0,0 +1,64 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypeGraph(t *testing.T) {
+	schema := loadTestSchema(t)
+
+	tests := map[string]struct {
+		options     GraphOptions
+		mermaid     bool
+		expect      []string
+		expectNotIn []string
+		expectErr   bool
+	}{
+		"DOT from the root types": {
+			expect: []string{
+				`"Query" [label="Query\nobject"];`,
+				`"Node" [label="Node\ninterface", style=rounded];`,
+				`"Query" -> "User" [label="user"];`,
+				`"User" -> "Node" [style=dashed, arrowhead=empty];`,
+			},
+		},
+		"Mermaid from a type": {
+			options:     GraphOptions{Root: "User"},
+			mermaid:     true,
+			expect:      []string{"flowchart LR\n", "  Node([Node])\n", "  User -->|roles| Role\n", "  User -.->|implements| Node\n"},
+			expectNotIn: []string{"Query"},
+		},
+		"limited depth": {
+			options:     GraphOptions{Root: "Query", Depth: 1},
+			expect:      []string{`"Query" -> "User"`},
+			expectNotIn: []string{`"Role"`, `"Node"`},
+		},
+		"unknown root": {
+			options:   GraphOptions{Root: "Missing"},
+			expectErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			render := DOT
+			if tt.mermaid {
+				render = Mermaid
+			}
+			out, err := render(schema, tt.options)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(string(out), expect) {
+					t.Errorf("expect graph to contain %q got:\n%s", expect, out)
+				}
+			}
+			for _, unexpected := range tt.expectNotIn {
+				if strings.Contains(string(out), unexpected) {
+					t.Errorf("expect graph not to contain %q got:\n%s", unexpected, out)
+				}
+			}
+		})
+	}
+}
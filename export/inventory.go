@@ -0,0 +1,106 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// InventoryField is a field, input field or enum value of a field inventory.
+type InventoryField struct {
+	Type string `json:"type"`
+	Kind string `json:"kind"`
+	// Field is the name of the field or enum value.
+	Field string `json:"field"`
+	// ReturnType is empty for enum values.
+	ReturnType        string              `json:"returnType,omitempty"`
+	Arguments         []InventoryArgument `json:"arguments,omitempty"`
+	Deprecated        bool                `json:"deprecated"`
+	DeprecationReason string              `json:"deprecationReason,omitempty"`
+	Description       string              `json:"description,omitempty"`
+}
+
+// InventoryArgument is an argument of an InventoryField.
+type InventoryArgument struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	DefaultValue string `json:"defaultValue,omitempty"`
+}
+
+// Inventory lists every field of the object, interface and input object types of
+// schema, and every enum value, ordered like the reference documentation.
+func Inventory(schema *ast.Schema) []InventoryField {
+	var fields []InventoryField
+	for _, section := range docSections(schema) {
+		for _, typ := range section.Types {
+			for _, field := range typ.Fields {
+				inventory := InventoryField{
+					Type:              typ.Name,
+					Kind:              typ.Kind,
+					Field:             field.Name,
+					ReturnType:        field.Type,
+					Deprecated:        field.Deprecated,
+					DeprecationReason: field.Reason,
+					Description:       field.Description,
+				}
+				for _, arg := range field.Args {
+					inventory.Arguments = append(inventory.Arguments, InventoryArgument{Name: arg.Name, Type: arg.Type, DefaultValue: arg.Default})
+				}
+				fields = append(fields, inventory)
+			}
+			for _, value := range typ.Values {
+				fields = append(fields, InventoryField{
+					Type:              typ.Name,
+					Kind:              typ.Kind,
+					Field:             value.Name,
+					Deprecated:        value.Deprecated,
+					DeprecationReason: value.Reason,
+					Description:       value.Description,
+				})
+			}
+		}
+	}
+	return fields
+}
+
+// CSV returns the Inventory of schema as CSV with a header row. Arguments are joined
+// in a single column as "name: Type = default; ...".
+func CSV(schema *ast.Schema) ([]byte, error) {
+	out := &bytes.Buffer{}
+	w := csv.NewWriter(out)
+	w.Write([]string{"type", "kind", "field", "return_type", "arguments", "deprecated", "deprecation_reason", "description"})
+	for _, field := range Inventory(schema) {
+		args := make([]string, 0, len(field.Arguments))
+		for _, arg := range field.Arguments {
+			argument := arg.Name + ": " + arg.Type
+			if arg.DefaultValue != "" {
+				argument += " = " + arg.DefaultValue
+			}
+			args = append(args, argument)
+		}
+		w.Write([]string{field.Type, field.Kind, field.Field, field.ReturnType, strings.Join(args, "; "),
+			strconv.FormatBool(field.Deprecated), field.DeprecationReason, field.Description})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// JSONL returns the Inventory of schema as JSON Lines, one field per line.
+func JSONL(schema *ast.Schema) ([]byte, error) {
+	out := &bytes.Buffer{}
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	for _, field := range Inventory(schema) {
+		if err := encoder.Encode(field); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
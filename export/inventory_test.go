@@ -0,0 +1,46 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInventory(t *testing.T) {
+	schema := loadTestSchema(t)
+
+	tests := map[string]struct {
+		render func() ([]byte, error)
+		expect []string
+	}{
+		"CSV": {
+			render: func() ([]byte, error) { return CSV(schema) },
+			expect: []string{
+				"type,kind,field,return_type,arguments,deprecated,deprecation_reason,description\n",
+				"Query,object,user,User,id: ID!,false,,\n",
+				"User,object,name,String,,true,use fullName,\n",
+				"Role,enum,ADMIN,,,false,,\n",
+				"UserInput,input object,name,String!,,false,,\n",
+			},
+		},
+		"JSON Lines": {
+			render: func() ([]byte, error) { return JSONL(schema) },
+			expect: []string{
+				`{"type":"Query","kind":"object","field":"user","returnType":"User","arguments":[{"name":"id","type":"ID!"}],"deprecated":false}` + "\n",
+				`{"type":"User","kind":"object","field":"name","returnType":"String","deprecated":true,"deprecationReason":"use fullName"}` + "\n",
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			out, err := tt.render()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(string(out), expect) {
+					t.Errorf("expect inventory to contain %q got:\n%s", expect, out)
+				}
+			}
+		})
+	}
+}
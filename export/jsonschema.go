@@ -0,0 +1,145 @@
+// Package export converts the types of GraphQL schemas to other type systems, such as
+// JSON Schema, so payloads shaped like GraphQL types can be validated or generated with
+// tools that don't speak GraphQL.
+package export
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// JSONSchemaDialect is the JSON Schema draft of the documents returned by JSONSchema.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns a JSON Schema document defining the object, interface, union,
+// input object, enum and custom scalar types of schema under $defs, referenced as
+// #/$defs/<type name>. Fields with arguments are exported like any other field.
+func JSONSchema(schema *ast.Schema) ([]byte, error) {
+	return marshal(map[string]interface{}{
+		"$schema": JSONSchemaDialect,
+		"$defs":   jsonSchemaDefinitions(schema, "#/$defs/"),
+	})
+}
+
+// OpenAPI returns an OpenAPI 3.1 document, titled title, defining the same schemas as
+// JSONSchema under components/schemas, whose dialect OpenAPI 3.1 shares.
+func OpenAPI(schema *ast.Schema, title, version string) ([]byte, error) {
+	return marshal(map[string]interface{}{
+		"openapi": "3.1.0",
+		"info":    map[string]interface{}{"title": title, "version": version},
+		"paths":   map[string]interface{}{},
+		"components": map[string]interface{}{
+			"schemas": jsonSchemaDefinitions(schema, "#/components/schemas/"),
+		},
+	})
+}
+
+func marshal(doc interface{}) ([]byte, error) {
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// exportedTypes returns the types of schema that aren't built in, sorted by name.
+func exportedTypes(schema *ast.Schema) []*ast.Definition {
+	var defs []*ast.Definition
+	for name, def := range schema.Types {
+		if !def.BuiltIn && !strings.HasPrefix(name, "__") {
+			defs = append(defs, def)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+func jsonSchemaDefinitions(schema *ast.Schema, refPrefix string) map[string]interface{} {
+	defs := map[string]interface{}{}
+	for _, def := range exportedTypes(schema) {
+		var out map[string]interface{}
+		switch def.Kind {
+		case ast.Object, ast.Interface, ast.InputObject:
+			properties := map[string]interface{}{}
+			required := []string{}
+			for _, field := range def.Fields {
+				if strings.HasPrefix(field.Name, "__") {
+					continue
+				}
+				property := jsonSchemaType(field.Type, refPrefix)
+				describe(property, field.Description, field.Directives)
+				properties[field.Name] = property
+				// Input fields with a default value may be left out.
+				if field.Type.NonNull && field.DefaultValue == nil {
+					required = append(required, field.Name)
+				}
+			}
+			out = map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+			if len(required) > 0 {
+				out["required"] = required
+			}
+		case ast.Union:
+			var members []interface{}
+			for _, member := range def.Types {
+				members = append(members, map[string]interface{}{"$ref": refPrefix + member})
+			}
+			out = map[string]interface{}{"oneOf": members}
+		case ast.Enum:
+			var values []interface{}
+			for _, value := range def.EnumValues {
+				values = append(values, value.Name)
+			}
+			out = map[string]interface{}{"type": "string", "enum": values}
+		case ast.Scalar:
+			// Custom scalars can be serialized as any JSON value.
+			out = map[string]interface{}{}
+		default:
+			continue
+		}
+		describe(out, def.Description, def.Directives)
+		defs[def.Name] = out
+	}
+	return defs
+}
+
+// jsonSchemaType returns the schema of values of typ, allowing null unless typ is
+// non-null.
+func jsonSchemaType(typ *ast.Type, refPrefix string) map[string]interface{} {
+	var out map[string]interface{}
+	if typ.Elem != nil {
+		out = map[string]interface{}{"type": "array", "items": jsonSchemaType(typ.Elem, refPrefix)}
+	} else if scalar, ok := builtinJSONTypes[typ.NamedType]; ok {
+		out = map[string]interface{}{"type": scalar}
+	} else {
+		out = map[string]interface{}{"$ref": refPrefix + typ.NamedType}
+	}
+	if typ.NonNull {
+		return out
+	}
+	if primitive, ok := out["type"].(string); ok {
+		out["type"] = []string{primitive, "null"}
+		return out
+	}
+	return map[string]interface{}{"anyOf": []interface{}{out, map[string]interface{}{"type": "null"}}}
+}
+
+var builtinJSONTypes = map[string]string{
+	"Int":     "integer",
+	"Float":   "number",
+	"String":  "string",
+	"ID":      "string",
+	"Boolean": "boolean",
+}
+
+// describe copies the description and deprecation of a GraphQL element to its schema.
+func describe(out map[string]interface{}, description string, directives ast.DirectiveList) {
+	if description != "" {
+		out["description"] = description
+	}
+	if directives.ForName("deprecated") != nil {
+		out["deprecated"] = true
+	}
+}
@@ -0,0 +1,124 @@
+package export
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/zucchinho/gqlfetch"
+)
+
+const testSchema = `scalar DateTime
+
+type Query {
+	user(id: ID!): User
+}
+
+"A registered user."
+type User implements Node {
+	id: ID!
+	name: String @deprecated(reason: "use fullName")
+	roles: [Role!]!
+	createdAt: DateTime
+	manager: User
+}
+
+interface Node {
+	id: ID!
+}
+
+union SearchResult = User
+
+enum Role {
+	ADMIN
+	MEMBER
+}
+
+input UserInput {
+	name: String!
+	age: Int
+	role: Role! = MEMBER
+}
+`
+
+func loadTestSchema(t *testing.T) *ast.Schema {
+	t.Helper()
+	schema, err := gqlfetch.LoadSchema(&ast.Source{Name: "schema.graphql", Input: testSchema})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return schema
+}
+
+func TestJSONSchema(t *testing.T) {
+	out, err := JSONSchema(loadTestSchema(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc struct {
+		Schema string                            `json:"$schema"`
+		Defs   map[string]map[string]interface{} `json:"$defs"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Schema != JSONSchemaDialect {
+		t.Errorf("expect dialect %s got: %s", JSONSchemaDialect, doc.Schema)
+	}
+
+	tests := map[string]struct {
+		path   []string
+		expect interface{}
+	}{
+		"description":           {path: []string{"User", "description"}, expect: "A registered user."},
+		"required fields":       {path: []string{"User", "required"}, expect: []interface{}{"id", "roles"}},
+		"nullable scalar":       {path: []string{"User", "properties", "name", "type"}, expect: []interface{}{"string", "null"}},
+		"deprecated field":      {path: []string{"User", "properties", "name", "deprecated"}, expect: true},
+		"list of enums":         {path: []string{"User", "properties", "roles", "items", "$ref"}, expect: "#/$defs/Role"},
+		"nullable reference":    {path: []string{"User", "properties", "manager", "anyOf"}, expect: []interface{}{map[string]interface{}{"$ref": "#/$defs/User"}, map[string]interface{}{"type": "null"}}},
+		"enum values":           {path: []string{"Role", "enum"}, expect: []interface{}{"ADMIN", "MEMBER"}},
+		"union members":         {path: []string{"SearchResult", "oneOf"}, expect: []interface{}{map[string]interface{}{"$ref": "#/$defs/User"}}},
+		"input object":          {path: []string{"UserInput", "properties", "age", "type"}, expect: []interface{}{"integer", "null"}},
+		"defaults not required": {path: []string{"UserInput", "required"}, expect: []interface{}{"name"}},
+		"custom scalar accepts": {path: []string{"DateTime"}, expect: map[string]interface{}{}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got interface{} = doc.Defs[tt.path[0]]
+			for _, key := range tt.path[1:] {
+				object, _ := got.(map[string]interface{})
+				got = object[key]
+			}
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("expect %v got: %v", tt.expect, got)
+			}
+		})
+	}
+	if _, ok := doc.Defs["String"]; ok {
+		t.Errorf("expect built-in scalars to be left out")
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	out, err := OpenAPI(loadTestSchema(t), "Users", "1.2.0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("expect OpenAPI 3.1.0 got: %v", doc.OpenAPI)
+	}
+	roles := doc.Components.Schemas["User"]["properties"].(map[string]interface{})["roles"].(map[string]interface{})
+	if ref := roles["items"].(map[string]interface{})["$ref"]; ref != "#/components/schemas/Role" {
+		t.Errorf("expect references to components got: %v", ref)
+	}
+}
@@ -0,0 +1,233 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var builtinProtoTypes = map[string]string{
+	"Int":     "int32",
+	"Float":   "double",
+	"String":  "string",
+	"ID":      "string",
+	"Boolean": "bool",
+}
+
+// Proto returns a proto3 file declaring package pkg, with a message for every object,
+// interface and input object type of schema, an enum for every enum type, and a
+// message holding a oneof for every union. The fields of the root operation types
+// become the RPCs of a QueryService, MutationService and SubscriptionService, with
+// request messages holding the arguments of the field.
+//
+// The conversion is experimental and lossy: nullable scalars become optional fields,
+// lists of lists are wrapped in List messages, custom scalars become strings, and
+// field numbers follow the order of the fields, so they change when fields are added
+// in the middle of a type.
+func Proto(schema *ast.Schema, pkg string) ([]byte, error) {
+	p := &protoPrinter{schema: schema, lists: map[string]string{}}
+	p.printf("syntax = \"proto3\";\n")
+	if pkg != "" {
+		p.printf("\npackage %s;\n", pkg)
+	}
+
+	roots := map[string]bool{}
+	var services []*ast.Definition
+	for _, root := range []*ast.Definition{schema.Query, schema.Mutation, schema.Subscription} {
+		if root != nil {
+			roots[root.Name] = true
+			services = append(services, root)
+		}
+	}
+
+	for _, def := range exportedTypes(schema) {
+		if roots[def.Name] {
+			continue
+		}
+		switch def.Kind {
+		case ast.Object, ast.Interface, ast.InputObject:
+			p.message(def.Name, def.Description, def.Fields)
+		case ast.Union:
+			p.printf("\n")
+			p.comment("", def.Description)
+			p.printf("message %s {\n  oneof value {\n", def.Name)
+			for i, member := range def.Types {
+				p.printf("    %s %s = %d;\n", member, protoFieldName(member), i+1)
+			}
+			p.printf("  }\n}\n")
+		case ast.Enum:
+			p.enum(def)
+		}
+	}
+
+	for _, root := range services {
+		var rpcs []string
+		for _, field := range root.Fields {
+			if strings.HasPrefix(field.Name, "__") {
+				continue
+			}
+			name := exportedName(root.Name) + exportedName(field.Name)
+			var args ast.FieldList
+			for _, arg := range field.Arguments {
+				args = append(args, &ast.FieldDefinition{Name: arg.Name, Type: arg.Type, Description: arg.Description, Directives: arg.Directives})
+			}
+			p.message(name+"Request", "", args)
+			p.message(name+"Response", "", ast.FieldList{field})
+			rpc := &strings.Builder{}
+			for _, line := range descriptionLines(field.Description) {
+				fmt.Fprintf(rpc, "  //%s\n", line)
+			}
+			fmt.Fprintf(rpc, "  rpc %s(%sRequest) returns (", exportedName(field.Name), name)
+			if root == schema.Subscription {
+				rpc.WriteString("stream ")
+			}
+			fmt.Fprintf(rpc, "%sResponse);\n", name)
+			rpcs = append(rpcs, rpc.String())
+		}
+		p.printf("\nservice %sService {\n%s}\n", exportedName(root.Name), strings.Join(rpcs, ""))
+	}
+
+	names := make([]string, 0, len(p.lists))
+	for name := range p.lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p.printf("\nmessage %s {\n  repeated %s values = 1;\n}\n", name, p.lists[name])
+	}
+	return []byte(p.String()), nil
+}
+
+type protoPrinter struct {
+	strings.Builder
+	schema *ast.Schema
+	// lists are the element types of the wrapper messages needed by lists of lists.
+	lists map[string]string
+}
+
+func (p *protoPrinter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(p, format, args...)
+}
+
+func (p *protoPrinter) comment(indent, description string) {
+	for _, line := range descriptionLines(description) {
+		p.printf("%s//%s\n", indent, line)
+	}
+}
+
+func (p *protoPrinter) message(name, description string, fields ast.FieldList) {
+	p.printf("\n")
+	p.comment("", description)
+	p.printf("message %s {\n", name)
+	number := 0
+	for _, field := range fields {
+		if strings.HasPrefix(field.Name, "__") {
+			continue
+		}
+		number++
+		p.comment("  ", field.Description)
+		p.printf("  %s %s = %d", p.fieldType(field.Type), protoFieldName(field.Name), number)
+		if field.Directives.ForName("deprecated") != nil {
+			p.printf(" [deprecated = true]")
+		}
+		p.printf(";\n")
+	}
+	p.printf("}\n")
+}
+
+func (p *protoPrinter) enum(def *ast.Definition) {
+	prefix := upperSnakeCase(def.Name) + "_"
+	p.printf("\n")
+	p.comment("", def.Description)
+	p.printf("enum %s {\n  %sUNSPECIFIED = 0;\n", def.Name, prefix)
+	for i, value := range def.EnumValues {
+		p.comment("  ", value.Description)
+		p.printf("  %s%s = %d", prefix, value.Name, i+1)
+		if value.Directives.ForName("deprecated") != nil {
+			p.printf(" [deprecated = true]")
+		}
+		p.printf(";\n")
+	}
+	p.printf("}\n")
+}
+
+// fieldType returns the type of a field holding typ, with its label.
+func (p *protoPrinter) fieldType(typ *ast.Type) string {
+	if typ.Elem != nil {
+		return "repeated " + p.elemType(typ.Elem)
+	}
+	name := p.namedType(typ.NamedType)
+	if def := p.schema.Types[typ.NamedType]; !typ.NonNull && def != nil && (def.Kind == ast.Scalar || def.Kind == ast.Enum) {
+		return "optional " + name
+	}
+	return name
+}
+
+// elemType returns the type of the elements of a repeated field, wrapping nested lists,
+// which protobuf doesn't support, in a List message.
+func (p *protoPrinter) elemType(typ *ast.Type) string {
+	if typ.Elem == nil {
+		return p.namedType(typ.NamedType)
+	}
+	elem := p.elemType(typ.Elem)
+	name := exportedName(elem) + "List"
+	p.lists[name] = elem
+	return name
+}
+
+// namedType maps scalars to protobuf scalar types, custom ones to strings.
+func (p *protoPrinter) namedType(name string) string {
+	if scalar, ok := builtinProtoTypes[name]; ok {
+		return scalar
+	}
+	if def := p.schema.Types[name]; def != nil && def.Kind == ast.Scalar {
+		return "string"
+	}
+	return name
+}
+
+// descriptionLines prefixes the lines of description with a space, for comments.
+func descriptionLines(description string) []string {
+	if description == "" {
+		return nil
+	}
+	lines := strings.Split(description, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = " " + line
+		}
+	}
+	return lines
+}
+
+// protoFieldName converts a camelCase GraphQL name to snake_case.
+func protoFieldName(name string) string {
+	return strings.ToLower(upperSnakeCase(name))
+}
+
+// upperSnakeCase converts a camelCase or PascalCase name to UPPER_SNAKE_CASE.
+func upperSnakeCase(name string) string {
+	runes := []rune(name)
+	var out strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && runes[i-1] != '_' &&
+			(unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			out.WriteRune('_')
+		}
+		out.WriteRune(unicode.ToUpper(r))
+	}
+	return out.String()
+}
+
+// exportedName capitalizes the first letter of name.
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
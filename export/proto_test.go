@@ -0,0 +1,113 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/zucchinho/gqlfetch"
+)
+
+func TestProto(t *testing.T) {
+	schema, err := gqlfetch.LoadSchema(&ast.Source{Input: `
+type Query {
+	"Finds a user."
+	user(id: ID!): User
+}
+
+type Subscription {
+	userUpdated(id: ID!): User!
+}
+
+type User {
+	id: ID!
+	displayName: String
+	matrix: [[Float!]]
+	status: Status
+}
+
+union Result = User
+
+enum Status {
+	ACTIVE
+	SUSPENDED @deprecated
+}
+`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := Proto(schema, "users.v1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := `syntax = "proto3";
+
+package users.v1;
+
+message Result {
+  oneof value {
+    User user = 1;
+  }
+}
+
+enum Status {
+  STATUS_UNSPECIFIED = 0;
+  STATUS_ACTIVE = 1;
+  STATUS_SUSPENDED = 2 [deprecated = true];
+}
+
+message User {
+  string id = 1;
+  optional string display_name = 2;
+  repeated DoubleList matrix = 3;
+  optional Status status = 4;
+}
+
+message QueryUserRequest {
+  string id = 1;
+}
+
+message QueryUserResponse {
+  // Finds a user.
+  User user = 1;
+}
+
+service QueryService {
+  // Finds a user.
+  rpc User(QueryUserRequest) returns (QueryUserResponse);
+}
+
+message SubscriptionUserUpdatedRequest {
+  string id = 1;
+}
+
+message SubscriptionUserUpdatedResponse {
+  User user_updated = 1;
+}
+
+service SubscriptionService {
+  rpc UserUpdated(SubscriptionUserUpdatedRequest) returns (stream SubscriptionUserUpdatedResponse);
+}
+
+message DoubleList {
+  repeated double values = 1;
+}
+`
+	if string(got) != expect {
+		t.Errorf("expect:\n%v\ngot:\n%v", expect, string(got))
+	}
+}
+
+func Test_upperSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"displayName": "DISPLAY_NAME",
+		"HTTPStatus":  "HTTP_STATUS",
+		"userID":      "USER_ID",
+		"already_ok":  "ALREADY_OK",
+	}
+	for name, expect := range tests {
+		if got := upperSnakeCase(name); got != expect {
+			t.Errorf("expect %s got: %s", expect, got)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var builtinTypeScriptTypes = map[string]string{
+	"Int":     "number",
+	"Float":   "number",
+	"String":  "string",
+	"ID":      "string",
+	"Boolean": "boolean",
+}
+
+// TypeScript returns TypeScript type definitions, for a .d.ts file, of the types of
+// schema: an interface for every object, interface and input object type, with an
+// optional __typename on objects, a union of string literals for every enum, a union
+// type for every union, and an alias of unknown for every custom scalar. The arguments
+// of fields are declared as <Type><Field>Args interfaces. Nullable fields allow null,
+// and nullable input fields and the ones with a default value can be left out.
+func TypeScript(schema *ast.Schema) ([]byte, error) {
+	out := &strings.Builder{}
+	for i, def := range exportedTypes(schema) {
+		if i > 0 {
+			out.WriteString("\n")
+		}
+		writeJSDoc(out, "", def.Description, def.Directives)
+		switch def.Kind {
+		case ast.Object, ast.Interface, ast.InputObject:
+			fmt.Fprintf(out, "export interface %s {\n", def.Name)
+			if def.Kind == ast.Object {
+				fmt.Fprintf(out, "  __typename?: %q;\n", def.Name)
+			}
+			for _, field := range def.Fields {
+				if strings.HasPrefix(field.Name, "__") {
+					continue
+				}
+				writeJSDoc(out, "  ", field.Description, field.Directives)
+				optional := ""
+				if def.Kind == ast.InputObject && (!field.Type.NonNull || field.DefaultValue != nil) {
+					optional = "?"
+				}
+				fmt.Fprintf(out, "  %s%s: %s;\n", field.Name, optional, typeScriptType(field.Type))
+			}
+			out.WriteString("}\n")
+			for _, field := range def.Fields {
+				if len(field.Arguments) == 0 || strings.HasPrefix(field.Name, "__") {
+					continue
+				}
+				fmt.Fprintf(out, "\nexport interface %s%sArgs {\n", def.Name, exportedName(field.Name))
+				for _, arg := range field.Arguments {
+					writeJSDoc(out, "  ", arg.Description, arg.Directives)
+					optional := ""
+					if !arg.Type.NonNull || arg.DefaultValue != nil {
+						optional = "?"
+					}
+					fmt.Fprintf(out, "  %s%s: %s;\n", arg.Name, optional, typeScriptType(arg.Type))
+				}
+				out.WriteString("}\n")
+			}
+		case ast.Union:
+			fmt.Fprintf(out, "export type %s = %s;\n", def.Name, strings.Join(def.Types, " | "))
+		case ast.Enum:
+			var values []string
+			for _, value := range def.EnumValues {
+				values = append(values, fmt.Sprintf("%q", value.Name))
+			}
+			fmt.Fprintf(out, "export type %s = %s;\n", def.Name, strings.Join(values, " | "))
+		case ast.Scalar:
+			fmt.Fprintf(out, "export type %s = unknown;\n", def.Name)
+		}
+	}
+	return []byte(out.String()), nil
+}
+
+func typeScriptType(typ *ast.Type) string {
+	var out string
+	if typ.Elem != nil {
+		out = "Array<" + typeScriptType(typ.Elem) + ">"
+	} else if builtin, ok := builtinTypeScriptTypes[typ.NamedType]; ok {
+		out = builtin
+	} else {
+		out = typ.NamedType
+	}
+	if !typ.NonNull {
+		out += " | null"
+	}
+	return out
+}
+
+// writeJSDoc writes the description and deprecation reason of a GraphQL element as a
+// JSDoc comment.
+func writeJSDoc(out *strings.Builder, indent, description string, directives ast.DirectiveList) {
+	var lines []string
+	if description != "" {
+		lines = strings.Split(description, "\n")
+	}
+	if deprecated := directives.ForName("deprecated"); deprecated != nil {
+		tag := "@deprecated"
+		if reason := deprecated.Arguments.ForName("reason"); reason != nil && reason.Value != nil {
+			tag += " " + reason.Value.Raw
+		}
+		lines = append(lines, tag)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(out, "%s * %s\n", indent, strings.ReplaceAll(line, "*/", "*\\/"))
+	}
+	fmt.Fprintf(out, "%s */\n", indent)
+}
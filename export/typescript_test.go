@@ -0,0 +1,53 @@
+package export
+
+import "testing"
+
+func TestTypeScript(t *testing.T) {
+	got, err := TypeScript(loadTestSchema(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := `export type DateTime = unknown;
+
+export interface Node {
+  id: string;
+}
+
+export interface Query {
+  __typename?: "Query";
+  user: User | null;
+}
+
+export interface QueryUserArgs {
+  id: string;
+}
+
+export type Role = "ADMIN" | "MEMBER";
+
+export type SearchResult = User;
+
+/**
+ * A registered user.
+ */
+export interface User {
+  __typename?: "User";
+  id: string;
+  /**
+   * @deprecated use fullName
+   */
+  name: string | null;
+  roles: Array<Role>;
+  createdAt: DateTime | null;
+  manager: User | null;
+}
+
+export interface UserInput {
+  name: string;
+  age?: number | null;
+  role?: Role;
+}
+`
+	if string(got) != expect {
+		t.Errorf("expect:\n%v\ngot:\n%v", expect, string(got))
+	}
+}
@@ -0,0 +1,40 @@
+package gqlfetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// fetchFallbackSchema downloads the SDL served at options.FallbackSchemaURL, used when
+// the endpoint doesn't allow introspection, within Timeout and MaxResponseBytes.
+func fetchFallbackSchema(ctx context.Context, options BuildClientSchemaOptions) (string, error) {
+	ctx, cancel := withTimeout(ctx, options)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, options.FallbackSchemaURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create fallback schema request: %w", err)
+	}
+
+	// The fallback URL is never reached through the socket of a unix:// endpoint.
+	options.Endpoint = options.FallbackSchemaURL
+	client, err := options.httpClient()
+	if err != nil {
+		return "", err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch fallback schema: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch fallback schema: %s", res.Status)
+	}
+
+	sdl, err := io.ReadAll(limitResponse(options, res.Body))
+	if err != nil {
+		return "", fmt.Errorf("failed to read fallback schema: %w", err)
+	}
+	return string(sdl), nil
+}
@@ -0,0 +1,112 @@
+package gqlfetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildClientSchemaWithOptions_IntrospectionDisabled(t *testing.T) {
+	const sdl = "type Query {\n\tversion: String\n}\n"
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sdl))
+	}))
+	defer fallback.Close()
+
+	tests := map[string]struct {
+		message           string
+		fallbackURL       string
+		expect            string
+		expectDisabledErr bool
+	}{
+		"apollo server": {
+			message:           "GraphQL introspection is not allowed by Apollo Server, but the query contained __schema or __type.",
+			expectDisabledErr: true,
+		},
+		"falls back to the schema URL": {
+			message:     "Introspection is disabled",
+			fallbackURL: fallback.URL,
+			expect:      sdl,
+		},
+		"other errors don't fall back": {
+			message:     "Unauthorized",
+			fallbackURL: fallback.URL,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"errors": [{"message": "` + tt.message + `"}]}`))
+			}))
+			defer server.Close()
+
+			schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:          server.URL,
+				FallbackSchemaURL: tt.fallbackURL,
+			})
+			if tt.expect != "" {
+				if err != nil || schema != tt.expect {
+					t.Fatalf("expect fallback schema got: %q, %v", schema, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expect error")
+			}
+			if got := errors.Is(err, ErrIntrospectionDisabled); got != tt.expectDisabledErr {
+				t.Errorf("expect ErrIntrospectionDisabled: %v got: %v", tt.expectDisabledErr, err)
+			}
+			var gqlErrs GraphQLErrors
+			if !errors.As(err, &gqlErrs) {
+				t.Errorf("expect GraphQLErrors details got: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemaWithOptions_FallbackLimits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errors": [{"message": "Introspection is disabled"}]}`))
+	}))
+	defer server.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		w.Write([]byte("type Query {\n\t\"The version of the API, larger than the introspection error.\"\n\tversion: String\n}\n"))
+	}))
+	defer fallback.Close()
+
+	tests := map[string]struct {
+		path             string
+		timeout          time.Duration
+		maxResponseBytes int64
+		expectErr        error
+	}{
+		"timeout":   {path: "/slow", timeout: 50 * time.Millisecond, expectErr: context.DeadlineExceeded},
+		"too large": {maxResponseBytes: 80, expectErr: ErrResponseTooLarge},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:          server.URL,
+				FallbackSchemaURL: fallback.URL + tt.path,
+				Timeout:           tt.timeout,
+				MaxResponseBytes:  tt.maxResponseBytes,
+			})
+			if !errors.Is(err, tt.expectErr) || !strings.Contains(err.Error(), "fallback schema") {
+				t.Errorf("expect fallback schema error: %v got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
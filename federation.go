@@ -0,0 +1,41 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+const federationSDLQuery = "query FederationSDL { _service { sdl } }"
+
+// errServiceUnavailable is returned when an endpoint doesn't expose the Apollo
+// Federation _service field.
+var errServiceUnavailable = errors.New("endpoint does not expose _service { sdl }")
+
+// fetchFederationSDL fetches the subgraph's own SDL, which keeps federation directives
+// such as @key and @external that standard introspection strips.
+func fetchFederationSDL(ctx context.Context, options BuildClientSchemaOptions) (string, error) {
+	var response struct {
+		Errors GraphQLErrors `json:"errors"`
+		Data   struct {
+			Service *struct {
+				SDL string `json:"sdl"`
+			} `json:"_service"`
+		} `json:"data"`
+	}
+	err := execute(ctx, options, requestParams{Query: federationSDLQuery, OperationName: "FederationSDL"}, func(body io.Reader) error {
+		if err := json.NewDecoder(body).Decode(&response); err != nil {
+			return errServiceUnavailable
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(response.Errors) != 0 || response.Data.Service == nil || response.Data.Service.SDL == "" {
+		return "", errServiceUnavailable
+	}
+	return response.Data.Service.SDL, nil
+}
@@ -0,0 +1,59 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBuildClientSchemaWithOptions_FederationSDL(t *testing.T) {
+	introspection, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	const subgraphSDL = "type User @key(fields: \"id\") {\n\tid: ID!\n}\n"
+
+	tests := map[string]struct {
+		service bool
+		expect  string
+	}{
+		"subgraph exposes _service":   {service: true, expect: subgraphSDL},
+		"falls back to introspection": {service: false, expect: "type User implements Node"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var params requestParams
+				json.NewDecoder(r.Body).Decode(&params)
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case params.Query == federationSDLQuery && tt.service:
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"data": map[string]interface{}{"_service": map[string]string{"sdl": subgraphSDL}},
+					})
+				case params.Query == federationSDLQuery:
+					w.Write([]byte(`{"errors":[{"message":"Cannot query field \"_service\" on type \"Query\"."}]}`))
+				default:
+					w.Write(introspection)
+				}
+			}))
+			defer server.Close()
+
+			schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:      server.URL,
+				Method:        http.MethodPost,
+				FederationSDL: true,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(schema, tt.expect) {
+				t.Errorf("expect schema to contain %q got:\n%v", tt.expect, schema)
+			}
+		})
+	}
+}
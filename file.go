@@ -0,0 +1,68 @@
+package gqlfetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BuildClientSchemaToFile fetches the schema and writes it to path, creating missing
+// parent directories. The file is replaced atomically and only when its content
+// changed, so build tools watching its modification time aren't triggered needlessly.
+// It reports whether the file was written.
+func BuildClientSchemaToFile(ctx context.Context, options BuildClientSchemaOptions, path string) (bool, error) {
+	schema, err := BuildClientSchemaWithOptions(ctx, options)
+	if err != nil {
+		return false, err
+	}
+	return WriteSchemaFile(path, schema)
+}
+
+// WriteSchemaFile writes schema to path like BuildClientSchemaToFile, reporting whether
+// the file was written.
+func WriteSchemaFile(path, schema string) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(existing, []byte(schema)) {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := writeFileAtomic(path, []byte(schema)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it over
+// path, so readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
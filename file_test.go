@@ -0,0 +1,52 @@
+package gqlfetch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuildClientSchemaToFile(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+	options := BuildClientSchemaOptions{Endpoint: server.URL}
+	path := filepath.Join(t.TempDir(), "nested", "schema.graphql")
+
+	changed, err := BuildClientSchemaToFile(context.Background(), options, path)
+	if err != nil || !changed {
+		t.Fatalf("expect the schema to be written got: %v, %v", changed, err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	expected, err := BuildClientSchemaWithOptions(context.Background(), options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(written) != expected {
+		t.Errorf("expect the written schema to match the fetched one")
+	}
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatalf("set modification time: %v", err)
+	}
+	changed, err = BuildClientSchemaToFile(context.Background(), options, path)
+	if err != nil || changed {
+		t.Fatalf("expect an unchanged schema not to be written got: %v, %v", changed, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat schema: %v", err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Errorf("expect modification time to be preserved got: %v", info.ModTime())
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expect no temporary file left behind got: %v, %v", entries, err)
+	}
+}
@@ -0,0 +1,73 @@
+package gqlfetch
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SDLFormat controls the layout of printed SDL, for instance to match schema files
+// formatted by prettier. The zero value indents with tabs and prints every argument on
+// its own line.
+type SDLFormat struct {
+	// Indent is one level of indentation, a tab when empty.
+	Indent string
+	// TrailingCommas ends every argument printed on its own line with a comma.
+	TrailingCommas bool
+	// WrapWidth prints the arguments of a field or directive on its line when the line
+	// fits in this many characters, and one per line otherwise. Arguments with a
+	// description are always printed one per line. When zero, they always are.
+	WrapWidth int
+}
+
+// indent returns depth levels of indentation.
+func (f SDLFormat) indent(depth int) string {
+	indent := f.Indent
+	if indent == "" {
+		indent = "\t"
+	}
+	return strings.Repeat(indent, depth)
+}
+
+// printArguments prints the arguments of a field or directive definition at depth, on
+// the line made of head, the arguments and tail when it fits in the wrap width, and one
+// per line otherwise. Errors mention the arguments as path followed by their name.
+func printArguments(sb *strings.Builder, args []introspectionInputField, head, tail string, depth int, format SDLFormat, path string) error {
+	printed := make([]string, len(args))
+	inline := format.WrapWidth > 0
+	for i, arg := range args {
+		astType, err := introspectionTypeToAstType(arg.Type)
+		if err != nil {
+			return fmt.Errorf("convert type of argument %s%s: %w", path, arg.Name, err)
+		}
+		argument := &strings.Builder{}
+		argument.WriteString(arg.Name)
+		argument.WriteString(": ")
+		argument.WriteString(astType.String())
+		printDefaultValue(argument, arg.DefaultValue)
+		printDeprecation(argument, arg.IsDeprecated, arg.DeprecationReason)
+		printAppliedDirectives(argument, arg.AppliedDirectives)
+		printed[i] = argument.String()
+		if arg.Description != "" {
+			inline = false
+		}
+	}
+
+	line := head + "(" + strings.Join(printed, ", ") + ")" + tail
+	if inline && utf8.RuneCountInString(line) <= format.WrapWidth {
+		sb.WriteString(line)
+		return nil
+	}
+
+	sb.WriteString(head + "(\n")
+	for i, arg := range args {
+		printDescription(sb, arg.Description)
+		sb.WriteString(format.indent(depth+1) + printed[i])
+		if format.TrailingCommas {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(format.indent(depth) + ")" + tail)
+	return nil
+}
@@ -0,0 +1,69 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func Test_printSchema_SDLFormat(t *testing.T) {
+	var response introspectionResults
+	err := json.Unmarshal([]byte(`{"data": {"__schema": {
+		"queryType": {"name": "Query"},
+		"types": [
+			{"kind": "OBJECT", "name": "Query", "fields": [
+				{"name": "users", "args": [
+					{"name": "first", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "10"},
+					{"name": "after", "type": {"kind": "SCALAR", "name": "String"}}
+				], "type": {"kind": "SCALAR", "name": "String"}}
+			], "interfaces": []},
+			{"kind": "SCALAR", "name": "Int"},
+			{"kind": "SCALAR", "name": "String"}
+		],
+		"directives": [{"name": "cache", "locations": ["FIELD_DEFINITION"], "args": [{"name": "maxAge", "type": {"kind": "SCALAR", "name": "Int"}}]}]
+	}}}`), &response)
+	if err != nil {
+		t.Fatalf("decode introspection: %v", err)
+	}
+
+	tests := map[string]struct {
+		format   SDLFormat
+		expected string
+	}{
+		"default": {
+			expected: "directive @cache(\n\tmaxAge: Int\n) on FIELD_DEFINITION\n\n" +
+				"type Query {\n\tusers(\n\t\tfirst: Int = 10\n\t\tafter: String\n\t): String\n}\n\n",
+		},
+		"spaces and trailing commas": {
+			format: SDLFormat{Indent: "  ", TrailingCommas: true},
+			expected: "directive @cache(\n  maxAge: Int,\n) on FIELD_DEFINITION\n\n" +
+				"type Query {\n  users(\n    first: Int = 10,\n    after: String,\n  ): String\n}\n\n",
+		},
+		"wrapped": {
+			format: SDLFormat{Indent: "  ", WrapWidth: 80},
+			expected: "directive @cache(maxAge: Int) on FIELD_DEFINITION\n\n" +
+				"type Query {\n  users(first: Int = 10, after: String): String\n}\n\n",
+		},
+		"wider than the wrap width": {
+			format: SDLFormat{Indent: "  ", WrapWidth: 48},
+			expected: "directive @cache(\n  maxAge: Int\n) on FIELD_DEFINITION\n\n" +
+				"type Query {\n  users(first: Int = 10, after: String): String\n}\n\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sdl, err := printSchema(context.Background(), response.Data.Schema, builtinFilter{without: true}, tt.format)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sdl != tt.expected {
+				t.Errorf("expect:\n%v\ngot:\n%v", tt.expected, sdl)
+			}
+			if _, err := LoadSchema(&ast.Source{Input: sdl}); err != nil {
+				t.Errorf("expect printed schema to load got: %v", err)
+			}
+		})
+	}
+}
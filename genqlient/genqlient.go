@@ -0,0 +1,80 @@
+// Package genqlient fetches the schema configured in genqlient.yaml and optionally runs
+// genqlient afterwards, for a single "fetch and generate" step.
+package genqlient
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/zucchinho/gqlfetch"
+	"github.com/zucchinho/gqlfetch/internal/yamlkey"
+)
+
+// DefaultConfigPath is the configuration file genqlient reads by default.
+const DefaultConfigPath = "genqlient.yaml"
+
+// Config describes the fetch and the generation.
+type Config struct {
+	Options gqlfetch.BuildClientSchemaOptions
+	// ConfigPath defaults to DefaultConfigPath.
+	ConfigPath string
+	// Generate runs Command once the schema is written.
+	Generate bool
+	// Command defaults to go run github.com/Khan/genqlient with ConfigPath.
+	Command []string
+}
+
+// SchemaPath returns the schema file configured in a genqlient configuration file,
+// resolved against the directory of the file as genqlient does.
+func SchemaPath(configPath string) (string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read genqlient config: %w", err)
+	}
+	schemas := yamlkey.Values(data, "schema")
+	if len(schemas) == 0 {
+		return "", fmt.Errorf("%s has no schema", configPath)
+	}
+	if len(schemas) > 1 {
+		return "", fmt.Errorf("%s lists several schema files, fetch into one of them with gqlfetch.BuildClientSchemaToFile", configPath)
+	}
+	schema := schemas[0]
+	if !filepath.IsAbs(schema) {
+		schema = filepath.Join(filepath.Dir(configPath), schema)
+	}
+	return schema, nil
+}
+
+// FetchAndGenerate writes the fetched schema where genqlient.yaml expects it, then
+// runs genqlient when Generate is set, even if the schema didn't change.
+func FetchAndGenerate(ctx context.Context, config Config) error {
+	configPath := config.ConfigPath
+	if configPath == "" {
+		configPath = DefaultConfigPath
+	}
+	path, err := SchemaPath(configPath)
+	if err != nil {
+		return err
+	}
+	if _, err := gqlfetch.BuildClientSchemaToFile(ctx, config.Options, path); err != nil {
+		return fmt.Errorf("failed to fetch schema for genqlient: %w", err)
+	}
+	if !config.Generate {
+		return nil
+	}
+
+	command := config.Command
+	if len(command) == 0 {
+		command = []string{"go", "run", "github.com/Khan/genqlient", configPath}
+	}
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run genqlient: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package genqlient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zucchinho/gqlfetch"
+)
+
+func TestFetchAndGenerate(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to stand in for genqlient")
+	}
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "genqlient.yaml")
+	if err := os.WriteFile(configPath, []byte("schema: schema.graphql\noperations:\n- genqlient.graphql\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	marker := filepath.Join(dir, "generated")
+
+	err = FetchAndGenerate(context.Background(), Config{
+		Options:    gqlfetch.BuildClientSchemaOptions{Endpoint: server.URL},
+		ConfigPath: configPath,
+		Generate:   true,
+		Command:    []string{sh, "-c", "touch " + marker},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	schema, err := os.ReadFile(filepath.Join(dir, "schema.graphql"))
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	if !strings.Contains(string(schema), "type User implements Node") {
+		t.Errorf("expect the fetched schema to be written got:\n%s", schema)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("expect genqlient to run got: %v", err)
+	}
+}
@@ -2,4 +2,9 @@ module github.com/zucchinho/gqlfetch
 
 go 1.17
 
-require github.com/vektah/gqlparser v1.3.1
+require (
+	github.com/andybalholm/brotli v1.1.0
+	github.com/vektah/gqlparser/v2 v2.5.1
+)
+
+require github.com/agnivade/levenshtein v1.0.1 // indirect
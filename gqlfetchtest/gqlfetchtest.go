@@ -0,0 +1,133 @@
+// Package gqlfetchtest helps testing GraphQL servers against a golden schema file.
+package gqlfetchtest
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zucchinho/gqlfetch"
+)
+
+// update rewrites the golden files instead of comparing them, with go test -update.
+// Test packages using gqlfetchtest must not define their own -update flag.
+var update = flag.Bool("update", false, "update the golden schema files")
+
+// RequireSchemaMatches introspects endpointOrHandler, an endpoint URL or an
+// http.Handler served in-process, and fails the test unless the schema, sorted so the
+// field order of the server doesn't matter, equals the SDL in goldenFile. Running the
+// tests with -update writes the schema to goldenFile instead.
+func RequireSchemaMatches(t testing.TB, endpointOrHandler interface{}, goldenFile string) {
+	t.Helper()
+
+	options := gqlfetch.BuildClientSchemaOptions{SortSchema: true}
+	switch target := endpointOrHandler.(type) {
+	case string:
+		options.Endpoint = target
+	case http.Handler:
+		options.Endpoint = "http://localhost/graphql"
+		options.Transport = gqlfetch.HandlerTransport(target)
+	default:
+		t.Fatalf("gqlfetchtest: expected an endpoint URL or an http.Handler, got %T", endpointOrHandler)
+	}
+
+	schema, err := gqlfetch.BuildClientSchemaWithOptions(context.Background(), options)
+	if err != nil {
+		t.Fatalf("gqlfetchtest: failed to fetch schema: %v", err)
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(goldenFile), 0o755); err != nil {
+			t.Fatalf("gqlfetchtest: %v", err)
+		}
+		if err := os.WriteFile(goldenFile, []byte(schema), 0o644); err != nil {
+			t.Fatalf("gqlfetchtest: failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(goldenFile)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("gqlfetchtest: golden file %s does not exist, run the tests with -update to create it", goldenFile)
+	}
+	if err != nil {
+		t.Fatalf("gqlfetchtest: failed to read golden file: %v", err)
+	}
+	if string(golden) != schema {
+		t.Fatalf("gqlfetchtest: schema does not match %s (-golden +fetched), run the tests with -update to accept it:\n%s", goldenFile, diff(string(golden), schema))
+	}
+}
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 2
+
+// diff returns the lines removed from a and added in b, with some context, computed
+// from their longest common subsequence.
+func diff(a, b string) string {
+	x, y := strings.Split(a, "\n"), strings.Split(b, "\n")
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type line struct {
+		op   byte
+		text string
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, line{' ', x[i]})
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', x[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', y[j]})
+			j++
+		}
+	}
+
+	show := make([]bool, len(lines))
+	for k, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for c := k - diffContext; c <= k+diffContext; c++ {
+			if c >= 0 && c < len(lines) {
+				show[c] = true
+			}
+		}
+	}
+	sb := &strings.Builder{}
+	for k, l := range lines {
+		if !show[k] {
+			if k > 0 && show[k-1] {
+				sb.WriteString("...\n")
+			}
+			continue
+		}
+		fmt.Fprintf(sb, "%c %s\n", l.op, l.text)
+	}
+	return sb.String()
+}
@@ -0,0 +1,100 @@
+package gqlfetchtest
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fatalRecorder records the failure of a test instead of failing it.
+type fatalRecorder struct {
+	testing.TB
+	failure string
+}
+
+func (r *fatalRecorder) Helper() {}
+
+func (r *fatalRecorder) Fatalf(format string, args ...interface{}) {
+	r.failure = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func requireSchemaMatches(t *testing.T, endpointOrHandler interface{}, goldenFile string) string {
+	recorder := &fatalRecorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		RequireSchemaMatches(recorder, endpointOrHandler, goldenFile)
+	}()
+	<-done
+	return recorder.failure
+}
+
+func TestRequireSchemaMatches(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	})
+	golden := filepath.Join(t.TempDir(), "schema.graphql")
+
+	if failure := requireSchemaMatches(t, handler, golden); !strings.Contains(failure, "run the tests with -update") {
+		t.Errorf("expect a missing golden file to fail got: %v", failure)
+	}
+
+	*update = true
+	failure := requireSchemaMatches(t, handler, golden)
+	*update = false
+	if failure != "" {
+		t.Fatalf("unexpected failure: %v", failure)
+	}
+	if failure := requireSchemaMatches(t, handler, golden); failure != "" {
+		t.Errorf("expect the schema to match the golden file got: %v", failure)
+	}
+
+	schema, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	changed := strings.Replace(string(schema), "\tADMIN\n", "\tOWNER\n", 1)
+	if err := os.WriteFile(golden, []byte(changed), 0o644); err != nil {
+		t.Fatalf("write golden file: %v", err)
+	}
+	failure = requireSchemaMatches(t, handler, golden)
+	if !strings.Contains(failure, "- \tOWNER\n+ \tADMIN\n") {
+		t.Errorf("expect a diff of the changed enum value got: %v", failure)
+	}
+
+	if failure := requireSchemaMatches(t, 42, golden); !strings.Contains(failure, "got int") {
+		t.Errorf("expect an invalid target to fail got: %v", failure)
+	}
+}
+
+func Test_diff(t *testing.T) {
+	tests := map[string]struct {
+		a, b   string
+		expect string
+	}{
+		"equal": {a: "a\nb", b: "a\nb", expect: ""},
+		"changed line": {
+			a:      "1\n2\n3\n4\n5\n6\n7",
+			b:      "1\n2\n3\nfour\n5\n6\n7",
+			expect: "  2\n  3\n- 4\n+ four\n  5\n  6\n...\n",
+		},
+		"added line": {a: "a\nc", b: "a\nb\nc", expect: "  a\n+ b\n  c\n"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := diff(tt.a, tt.b); got != tt.expect {
+				t.Errorf("expect:\n%q\ngot:\n%q", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Package gqlgenplugin fetches a remote schema at generate time, before gqlgen loads
+// the schema files listed in gqlgen.yml. The fetch is configured by the gqlfetch section
+// of gqlgen.yml, the output being a schema file of its own next to the hand-written ones:
+//
+//	schema:
+//	  - graph/*.graphqls
+//	gqlfetch:
+//	  endpoint: https://api.example.com/graphql
+//	  output: graph/remote.graphqls
+//	  headers:
+//	    - "Authorization: Bearer ${API_TOKEN}"
+//
+// gqlgen reads schema files before it runs any plugin hook, so the fetch has to happen
+// ahead of api.Generate, and its config loader rejects keys it doesn't know, so it reads
+// the configuration without the gqlfetch section:
+//
+//	p, err := gqlgenplugin.Load("gqlgen.yml")
+//	...
+//	if err := p.Fetch(ctx); err != nil {
+//		return err
+//	}
+//	gqlgenConfig, err := gqlgenplugin.GQLGenConfig("gqlgen.yml")
+//	...
+//	cfg, err := config.ReadConfig(gqlgenConfig)
+//	...
+//	err = api.Generate(cfg, api.AddPlugin(p))
+//
+// Plugin implements gqlgen's plugin.Plugin interface without depending on gqlgen.
+package gqlgenplugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zucchinho/gqlfetch"
+	"github.com/zucchinho/gqlfetch/internal/envexpand"
+	"github.com/zucchinho/gqlfetch/internal/yamlkey"
+)
+
+// ConfigKey is the section of gqlgen.yml configuring the fetch.
+const ConfigKey = "gqlfetch"
+
+// Plugin writes the schema fetched with Options to Path.
+type Plugin struct {
+	Options gqlfetch.BuildClientSchemaOptions
+	// Path is the schema file to write. It's required, and mustn't be one of the
+	// hand-written schema files, which the fetch would overwrite.
+	Path string
+}
+
+func New(options gqlfetch.BuildClientSchemaOptions, path string) *Plugin {
+	return &Plugin{Options: options, Path: path}
+}
+
+// Load returns the plugin configured by the gqlfetch section of a gqlgen configuration
+// file: its endpoint, headers as "Name: value" and output file, resolved against the
+// directory of the file. Environment variables are expanded in the endpoint and header
+// values.
+func Load(configPath string) (*Plugin, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gqlgen config: %w", err)
+	}
+	section := yamlkey.Section(data, ConfigKey)
+	if section == nil {
+		return nil, fmt.Errorf("%s has no %s section", configPath, ConfigKey)
+	}
+
+	var options gqlfetch.BuildClientSchemaOptions
+	endpoints := yamlkey.Values(section, "endpoint")
+	if len(endpoints) != 1 {
+		return nil, fmt.Errorf("%s: %s.endpoint is required", configPath, ConfigKey)
+	}
+	if options.Endpoint, err = envexpand.Expand(endpoints[0]); err != nil {
+		return nil, err
+	}
+	for _, header := range yamlkey.Values(section, "headers") {
+		i := strings.Index(header, ":")
+		if i < 1 {
+			return nil, fmt.Errorf("%s: invalid header %q, expected \"Name: value\"", configPath, header)
+		}
+		value, err := envexpand.Expand(strings.TrimSpace(header[i+1:]))
+		if err != nil {
+			return nil, err
+		}
+		if options.Headers == nil {
+			options.Headers = make(http.Header)
+		}
+		options.Headers.Add(strings.TrimSpace(header[:i]), value)
+	}
+
+	outputs := yamlkey.Values(section, "output")
+	if len(outputs) != 1 {
+		return nil, fmt.Errorf("%s: %s.output is required, set it to a schema file of its own", configPath, ConfigKey)
+	}
+	path := outputs[0]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(configPath), path)
+	}
+	return New(options, path), nil
+}
+
+// GQLGenConfig returns a gqlgen configuration file without its gqlfetch section, for
+// gqlgen's config.ReadConfig.
+func GQLGenConfig(configPath string) (io.Reader, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gqlgen config: %w", err)
+	}
+	return bytes.NewReader(yamlkey.Without(data, ConfigKey)), nil
+}
+
+func (p *Plugin) Name() string {
+	return "gqlfetch"
+}
+
+// Fetch writes the schema, only touching the file when the schema changed, so gqlgen
+// and build tools relying on modification times aren't triggered needlessly. Set
+// Options.Cache to avoid re-fetching the schema on every generate run.
+func (p *Plugin) Fetch(ctx context.Context) error {
+	if p.Path == "" {
+		return errors.New("no schema file to write the fetched schema to, set Path")
+	}
+	if _, err := gqlfetch.BuildClientSchemaToFile(ctx, p.Options, p.Path); err != nil {
+		return fmt.Errorf("failed to fetch schema for gqlgen: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package gqlgenplugin
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zucchinho/gqlfetch"
+)
+
+func TestPlugin_Fetch(t *testing.T) {
+	fixture, err := os.ReadFile("../testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+	os.Setenv("GQLGENPLUGIN_TEST_TOKEN", "secret")
+	defer os.Unsetenv("GQLGENPLUGIN_TEST_TOKEN")
+
+	schema := "schema:\n  - graph/*.graphqls\n"
+	tests := map[string]struct {
+		config    string
+		expectErr string
+	}{
+		"configured": {
+			config: schema + "gqlfetch:\n  endpoint: " + server.URL + "\n  output: graph/remote.graphqls\n  headers:\n    - \"Authorization: Bearer ${GQLGENPLUGIN_TEST_TOKEN}\"\nmodels:\n  ID:\n    model: github.com/99designs/gqlgen/graphql.ID\n",
+		},
+		"no section": {
+			config:    schema,
+			expectErr: "has no gqlfetch section",
+		},
+		"no output": {
+			config:    schema + "gqlfetch:\n  endpoint: " + server.URL + "\n",
+			expectErr: "gqlfetch.output is required",
+		},
+		"unset variable": {
+			config:    schema + "gqlfetch:\n  endpoint: ${GQLGENPLUGIN_TEST_UNSET}\n  output: graph/remote.graphqls\n",
+			expectErr: "GQLGENPLUGIN_TEST_UNSET is not set",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			config := filepath.Join(dir, "gqlgen.yml")
+			if err := os.WriteFile(config, []byte(tt.config), 0o644); err != nil {
+				t.Fatalf("write config: %v", err)
+			}
+
+			plugin, err := Load(config)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := plugin.Fetch(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			fetched, err := os.ReadFile(filepath.Join(dir, "graph", "remote.graphqls"))
+			if err != nil {
+				t.Fatalf("read schema: %v", err)
+			}
+			if !strings.Contains(string(fetched), "type User implements Node") {
+				t.Errorf("expect the fetched schema to be written got:\n%s", fetched)
+			}
+
+			gqlgenConfig, err := GQLGenConfig(config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			stripped, _ := io.ReadAll(gqlgenConfig)
+			if expect := schema + "models:\n  ID:\n    model: github.com/99designs/gqlgen/graphql.ID\n"; string(stripped) != expect {
+				t.Errorf("expect the config without the gqlfetch section:\n%s\ngot:\n%s", expect, stripped)
+			}
+		})
+	}
+
+	if err := New(gqlfetch.BuildClientSchemaOptions{Endpoint: server.URL}, "").Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "set Path") {
+		t.Errorf("expect an error without Path got: %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package gqlfetch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// SchemaHash returns the hex-encoded SHA-256 of the normalized form of sdl, in which
+// types, fields, arguments, enum values, interfaces, union members and directives are
+// sorted by name, and comments and formatting are dropped. Schemas differing only in
+// those respects have the same hash, so it can be compared to cheaply detect changes.
+// SDL that doesn't load, such as federation SDL without its directive definitions, is
+// hashed with only the surrounding whitespace trimmed.
+func SchemaHash(sdl string) string {
+	normalized := strings.TrimSpace(sdl)
+	if schema, err := LoadSchema(&ast.Source{Input: sdl}); err == nil {
+		doc := userSchemaDocument(schema)
+		for _, def := range doc.Definitions {
+			sortDefinition(def)
+		}
+		for _, dir := range doc.Directives {
+			sortArguments(dir.Arguments)
+		}
+		normalized = formatSchemaDocument(doc)
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func sortDefinition(def *ast.Definition) {
+	sort.SliceStable(def.Fields, func(i, j int) bool { return def.Fields[i].Name < def.Fields[j].Name })
+	for _, field := range def.Fields {
+		sortArguments(field.Arguments)
+	}
+	sort.SliceStable(def.EnumValues, func(i, j int) bool { return def.EnumValues[i].Name < def.EnumValues[j].Name })
+	sort.Strings(def.Interfaces)
+	sort.Strings(def.Types)
+}
+
+func sortArguments(args ast.ArgumentDefinitionList) {
+	sort.SliceStable(args, func(i, j int) bool { return args[i].Name < args[j].Name })
+}
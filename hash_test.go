@@ -0,0 +1,43 @@
+package gqlfetch
+
+import "testing"
+
+func TestSchemaHash(t *testing.T) {
+	const schema = `"A user"
+type User {
+	id: ID!
+	name(format: String, locale: String): String
+}
+
+type Query {
+	user: User
+	users: [User!]!
+}
+`
+	tests := map[string]struct {
+		sdl         string
+		expectEqual bool
+	}{
+		"identical": {sdl: schema, expectEqual: true},
+		"reordered": {
+			sdl:         "type Query { users: [User!]! user: User }\n\"A user\" type User { name(locale: String, format: String): String id: ID! }",
+			expectEqual: true,
+		},
+		"comments and whitespace": {sdl: "# the schema\n\n" + schema + "\n# sha256: ignored\n", expectEqual: true},
+		"field added":             {sdl: schema + "extend type User { email: String }"},
+		"description changed":     {sdl: `"A person" type User { id: ID! name(format: String, locale: String): String } type Query { user: User users: [User!]! }`},
+		"invalid SDL":             {sdl: "type User @key(fields: \"id\") { id: ID! }"},
+	}
+	expect := SchemaHash(schema)
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := SchemaHash(tt.sdl)
+			if len(got) != 64 {
+				t.Errorf("expect a hex SHA-256 got: %v", got)
+			}
+			if (got == expect) != tt.expectEqual {
+				t.Errorf("expect equal hashes: %v got: %v and %v", tt.expectEqual, expect, got)
+			}
+		})
+	}
+}
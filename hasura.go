@@ -0,0 +1,45 @@
+package gqlfetch
+
+import (
+	"context"
+	"net/http"
+)
+
+// HasuraAdminSecret authenticates requests to a Hasura endpoint with its admin secret.
+type HasuraAdminSecret struct {
+	Secret string
+}
+
+func (a HasuraAdminSecret) Apply(req *http.Request) error {
+	req.Header.Set("X-Hasura-Admin-Secret", a.Secret)
+	return nil
+}
+
+// RoleSchema is the schema a Hasura endpoint exposes to Role.
+type RoleSchema struct {
+	Role   string
+	Schema string
+	Err    error
+}
+
+// BuildHasuraRoleSchemas introspects the schema Hasura exposes to each of roles, by
+// sending the admin secret along with an X-Hasura-Role header, in parallel like
+// BuildClientSchemas. Results are returned in the order of roles.
+func BuildHasuraRoleSchemas(ctx context.Context, options BuildClientSchemaOptions, adminSecret string, roles []string) []RoleSchema {
+	roleOptions := make([]BuildClientSchemaOptions, len(roles))
+	for i, role := range roles {
+		roleOptions[i] = options
+		roleOptions[i].Auth = HasuraAdminSecret{Secret: adminSecret}
+		roleOptions[i].Headers = options.Headers.Clone()
+		if roleOptions[i].Headers == nil {
+			roleOptions[i].Headers = make(http.Header)
+		}
+		roleOptions[i].Headers.Set("X-Hasura-Role", role)
+	}
+
+	results := make([]RoleSchema, len(roles))
+	for i, result := range BuildClientSchemas(ctx, roleOptions) {
+		results[i] = RoleSchema{Role: roles[i], Schema: result.Schema, Err: result.Err}
+	}
+	return results
+}
@@ -0,0 +1,65 @@
+package gqlfetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBuildHasuraRoleSchemas(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	anonymous := `{"data": {"__schema": {"queryType": {"name": "Query"}, "directives": [], "types": [
+		{"kind": "OBJECT", "name": "Query", "interfaces": [], "fields": [
+			{"name": "publicPosts", "args": [], "type": {"kind": "SCALAR", "name": "String"}, "isDeprecated": false}]},
+		{"kind": "SCALAR", "name": "String"}]}}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Hasura-Admin-Secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Header.Get("X-Hasura-Role") {
+		case "user":
+			w.Write(fixture)
+		case "anonymous":
+			w.Write([]byte(anonymous))
+		default:
+			w.Write([]byte(`{"errors": [{"message": "invalid role", "extensions": {"code": "access-denied"}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	results := BuildHasuraRoleSchemas(context.Background(), BuildClientSchemaOptions{Endpoint: server.URL}, "secret", []string{"user", "anonymous", "unknown"})
+	tests := map[string]struct {
+		index     int
+		expect    string
+		expectErr bool
+	}{
+		"user":      {index: 0, expect: "type User implements Node"},
+		"anonymous": {index: 1, expect: "publicPosts: String"},
+		"unknown":   {index: 2, expectErr: true},
+	}
+	for role, tt := range tests {
+		t.Run(role, func(t *testing.T) {
+			result := results[tt.index]
+			if result.Role != role {
+				t.Errorf("expect role %s got: %s", role, result.Role)
+			}
+			if (result.Err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, result.Err)
+			}
+			if !strings.Contains(result.Schema, tt.expect) {
+				t.Errorf("expect schema to contain %q got:\n%v", tt.expect, result.Schema)
+			}
+		})
+	}
+	if strings.Contains(results[1].Schema, "User") {
+		t.Errorf("expect the anonymous schema to leave out User got:\n%v", results[1].Schema)
+	}
+}
@@ -0,0 +1,49 @@
+// Package blockstring prints GraphQL block strings, shared by the SDL printers.
+package blockstring
+
+import "strings"
+
+// Print quotes s as a block string the way graphql-js does: short single lines stay on
+// one line, anything else is printed on its own lines, so the quotes never touch a
+// trailing quote or backslash and the parser's indentation removal doesn't alter the
+// value.
+func Print(s string) string {
+	if len(s) <= 70 && !strings.ContainsAny(s, "\r\n") && !strings.Contains(s, `"""`) &&
+		!strings.HasSuffix(s, `"`) && !strings.HasSuffix(s, `\`) {
+		return `"""` + s + `"""`
+	}
+	escaped := strings.ReplaceAll(s, `"""`, `\"""`)
+	lines := strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(escaped), "\n")
+	escaped = strings.Join(lines, "\n")
+
+	isSingleLine := len(lines) == 1
+	forceLeadingNewLine := len(lines) > 1
+	for _, line := range lines[1:] {
+		if line != "" && !isWhitespace(line[0]) {
+			forceLeadingNewLine = false
+			break
+		}
+	}
+	hasTrailingTripleQuotes := strings.HasSuffix(escaped, `\"""`)
+	hasTrailingQuote := strings.HasSuffix(s, `"`) && !hasTrailingTripleQuotes
+	hasTrailingSlash := strings.HasSuffix(s, `\`)
+	forceTrailingNewLine := hasTrailingQuote || hasTrailingSlash
+	printAsMultipleLines := !isSingleLine || len(s) > 70 || forceTrailingNewLine || forceLeadingNewLine || hasTrailingTripleQuotes
+
+	sb := &strings.Builder{}
+	sb.WriteString(`"""`)
+	skipLeadingNewLine := isSingleLine && isWhitespace(s[0])
+	if (printAsMultipleLines && !skipLeadingNewLine) || forceLeadingNewLine {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(escaped)
+	if printAsMultipleLines || forceTrailingNewLine {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(`"""`)
+	return sb.String()
+}
+
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
@@ -0,0 +1,40 @@
+package blockstring
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+func TestPrint(t *testing.T) {
+	tests := map[string]struct {
+		description string
+		expect      string
+	}{
+		"single line":        {description: "The user.", expect: `"""The user."""`},
+		"multiple lines":     {description: "The user.\nSee also Node.", expect: "\"\"\"\nThe user.\nSee also Node.\n\"\"\""},
+		"triple quotes":      {description: `Use """ for blocks.`, expect: `"""Use \""" for blocks."""`},
+		"trailing quote":     {description: `Named "user"`, expect: "\"\"\"\nNamed \"user\"\n\"\"\""},
+		"trailing backslash": {description: `C:\`, expect: "\"\"\"\nC:\\\n\"\"\""},
+		"indented lines":     {description: "Example:\n  query { me }", expect: "\"\"\"\nExample:\n  query { me }\n\"\"\""},
+		"long line":          {description: strings.Repeat("a", 71), expect: "\"\"\"\n" + strings.Repeat("a", 71) + "\n\"\"\""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := Print(tt.description)
+			if got != tt.expect {
+				t.Errorf("printing block string expect: %q got: %q", tt.expect, got)
+			}
+
+			doc, err := parser.ParseSchema(&ast.Source{Input: got + "\nscalar Described"})
+			if err != nil {
+				t.Fatalf("expect printed block string to parse got: %v\n%v", err, got)
+			}
+			if parsed := doc.Definitions[0].Description; parsed != tt.description {
+				t.Errorf("expect description to round trip: %q got: %q", tt.description, parsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Package envexpand expands environment variable references in endpoints and header
+// values, for the cli and configuration files.
+package envexpand
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Expand expands environment variable references, failing on unset variables rather
+// than silently sending an empty value.
+func Expand(s string) (string, error) {
+	var missing []string
+	expanded := os.Expand(s, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
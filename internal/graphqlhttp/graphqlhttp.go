@@ -0,0 +1,58 @@
+// Package graphqlhttp sends GraphQL operations to the APIs of schema registries.
+package graphqlhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/zucchinho/gqlfetch"
+)
+
+// Do posts query and variables to endpoint with header and decodes the data of the
+// response into data. GraphQL errors are returned as gqlfetch.GraphQLErrors.
+func Do(ctx context.Context, client *http.Client, endpoint string, header http.Header, query string, variables map[string]interface{}, data interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("request failed: %s: %s", res.Status, bytes.TrimSpace(snippet))
+	}
+
+	var response struct {
+		Data   json.RawMessage        `json:"data"`
+		Errors gqlfetch.GraphQLErrors `json:"errors"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(response.Errors) > 0 {
+		return response.Errors
+	}
+	if err := json.Unmarshal(response.Data, data); err != nil {
+		return fmt.Errorf("failed to decode response data: %w", err)
+	}
+	return nil
+}
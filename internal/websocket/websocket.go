@@ -0,0 +1,418 @@
+// Package websocket implements the subset of RFC 6455 needed to exchange text messages
+// with GraphQL servers: the opening handshake, (fragmented) text frames, pings and
+// closing, on the client and on the server side.
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// acceptGUID is appended to the client key to compute Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxMessageSize bounds the size of a single message unless SetReadLimit changes it.
+const maxMessageSize = 256 << 20
+
+// ErrClosed is returned when the peer closed the connection.
+var ErrClosed = errors.New("websocket connection closed")
+
+// ErrMessageTooLarge is returned when a message exceeds the read limit.
+var ErrMessageTooLarge = errors.New("websocket message too large")
+
+// Conn is a WebSocket connection exchanging text messages.
+type Conn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	client    bool
+	readLimit int64
+
+	// ctx is the context of a dialed connection, which is closed once it's done.
+	ctx       context.Context
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// Dial performs the opening handshake described by req, whose URL uses the ws or wss
+// scheme, offering the given subprotocols. wss connections use tlsConfig, which may be
+// nil. The connection is tunneled through proxy with an HTTP CONNECT request when it's
+// not nil, and closed once ctx is done. It returns the subprotocol the server chose.
+func Dial(ctx context.Context, req *http.Request, protocols []string, tlsConfig *tls.Config, proxy *url.URL) (*Conn, string, error) {
+	host := req.URL.Host
+	secure := req.URL.Scheme == "wss"
+	if req.URL.Port() == "" {
+		if secure {
+			host = net.JoinHostPort(req.URL.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(req.URL.Hostname(), "80")
+		}
+	}
+
+	var conn net.Conn
+	var err error
+	if proxy != nil {
+		if conn, err = dialProxy(ctx, proxy, host); err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	c := &Conn{conn: conn, client: true, ctx: ctx, stop: make(chan struct{})}
+	go c.closeOnDone(conn)
+	protocol, err := c.handshake(req, protocols, tlsConfig, secure)
+	if err != nil {
+		c.conn.Close()
+		c.closeOnce.Do(func() { close(c.stop) })
+		return nil, "", c.contextErr(err)
+	}
+	return c, protocol, nil
+}
+
+// closeOnDone closes conn, the network connection under TLS, once the context of the
+// dial is done, so reads and writes blocked on a silent server return.
+func (c *Conn) closeOnDone(conn net.Conn) {
+	select {
+	case <-c.ctx.Done():
+		conn.Close()
+	case <-c.stop:
+	}
+}
+
+// contextErr returns the error of the context of the dial once it's done, the one
+// explaining why the connection failed, and err otherwise.
+func (c *Conn) contextErr(err error) error {
+	if c.ctx != nil && c.ctx.Err() != nil {
+		return c.ctx.Err()
+	}
+	return err
+}
+
+// dialProxy connects to host through the http or https proxy with a CONNECT request.
+func dialProxy(ctx context.Context, proxy *url.URL, host string) (net.Conn, error) {
+	proxyHost := proxy.Host
+	switch proxy.Scheme {
+	case "http":
+		if proxy.Port() == "" {
+			proxyHost = net.JoinHostPort(proxy.Hostname(), "80")
+		}
+	case "https":
+		if proxy.Port() == "" {
+			proxyHost = net.JoinHostPort(proxy.Hostname(), "443")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported websocket proxy scheme %q, expected http or https", proxy.Scheme)
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", proxyHost)
+	if err != nil {
+		return nil, err
+	}
+	if proxy.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxy.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	connect := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: host}, Host: host, Header: make(http.Header)}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		connect.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+	if err := connect.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// The proxy answers before the tunnel carries any byte, so reading its response
+	// byte by byte leaves the tunnel untouched.
+	res, err := http.ReadResponse(bufio.NewReaderSize(oneByteReader{conn}, 16), connect)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("websocket proxy CONNECT failed: %s", res.Status)
+	}
+	return conn, nil
+}
+
+// oneByteReader reads a byte at a time, so a bufio.Reader wrapping it never reads past
+// what it returned.
+type oneByteReader struct {
+	io.Reader
+}
+
+func (r oneByteReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	return r.Reader.Read(p)
+}
+
+// handshake secures the connection of a wss endpoint and performs the opening
+// handshake, returning the subprotocol the server chose.
+func (c *Conn) handshake(req *http.Request, protocols []string, tlsConfig *tls.Config, secure bool) (string, error) {
+	ctx, conn := c.ctx, c.conn
+	if secure {
+		config := tlsConfig.Clone()
+		if config == nil {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config.ServerName = req.URL.Hostname()
+		}
+		tlsConn := tls.Client(conn, config)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return "", err
+		}
+		conn = tlsConn
+		c.conn = conn
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return "", err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	handshake := req.Clone(ctx)
+	handshake.Method = http.MethodGet
+	handshake.URL.Scheme = "http"
+	handshake.Header.Set("Upgrade", "websocket")
+	handshake.Header.Set("Connection", "Upgrade")
+	handshake.Header.Set("Sec-WebSocket-Key", key)
+	handshake.Header.Set("Sec-WebSocket-Version", "13")
+	if len(protocols) > 0 {
+		handshake.Header.Set("Sec-WebSocket-Protocol", strings.Join(protocols, ", "))
+	}
+	if err := handshake.Write(conn); err != nil {
+		return "", err
+	}
+
+	c.reader = bufio.NewReader(conn)
+	res, err := http.ReadResponse(c.reader, handshake)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		return "", fmt.Errorf("websocket handshake failed: %s", res.Status)
+	}
+	if res.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return "", errors.New("websocket handshake failed: invalid Sec-WebSocket-Accept")
+	}
+	return res.Header.Get("Sec-WebSocket-Protocol"), nil
+}
+
+// Upgrade performs the server side of the opening handshake, selecting protocol, which
+// may be empty.
+func Upgrade(w http.ResponseWriter, r *http.Request, protocol string) (*Conn, error) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || r.Header.Get("Sec-WebSocket-Key") == "" {
+		http.Error(w, "expected a websocket handshake", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("response writer can't be hijacked")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n"
+	if protocol != "" {
+		response += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+	if _, err := rw.WriteString(response + "\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Conn{conn: conn, reader: rw.Reader}, nil
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// SetReadLimit bounds the size of the messages read, ReadMessage failing with
+// ErrMessageTooLarge before buffering a larger one.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// ReadMessage returns the next text or binary message, answering pings on the way.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	limit := c.readLimit
+	if limit <= 0 {
+		limit = maxMessageSize
+	}
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame(limit - int64(len(message)))
+		if err != nil {
+			return nil, c.contextErr(err)
+		}
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, c.contextErr(err)
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			c.writeFrame(opClose, payload)
+			return nil, ErrClosed
+		case opText, opBinary, opContinuation:
+			message = append(message, payload...)
+		default:
+			return nil, fmt.Errorf("unknown websocket opcode %d", opcode)
+		}
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// WriteMessage sends a text message.
+func (c *Conn) WriteMessage(message []byte) error {
+	return c.contextErr(c.writeFrame(opText, message))
+}
+
+// Close sends a normal closure and closes the connection.
+func (c *Conn) Close() error {
+	if c.stop != nil {
+		c.closeOnce.Do(func() { close(c.stop) })
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	c.writeFrame(opClose, []byte{0x03, 0xe8})
+	return c.conn.Close()
+}
+
+// readFrame reads the next frame, failing with ErrMessageTooLarge when its payload
+// exceeds limit bytes.
+func (c *Conn) readFrame(limit int64) (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > uint64(limit) {
+		return false, 0, nil, ErrMessageTooLarge
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// writeFrame writes a single final frame, masked when sent by a client.
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	maskBit := byte(0)
+	if c.client {
+		maskBit = 0x80
+	}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, maskBit|126, byte(length>>8), byte(length))
+	default:
+		var extended [8]byte
+		binary.BigEndian.PutUint64(extended[:], uint64(length))
+		frame = append(append(frame, maskBit|127), extended[:]...)
+	}
+
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		masked := make([]byte, len(payload))
+		for i, b := range payload {
+			masked[i] = b ^ mask[i%4]
+		}
+		payload = masked
+	}
+	_, err := c.conn.Write(append(frame, payload...))
+	return err
+}
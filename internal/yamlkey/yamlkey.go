@@ -0,0 +1,133 @@
+// Package yamlkey reads top-level keys of simple YAML configuration files, such as the
+// schema location of gqlgen.yml and genqlient.yaml, without a YAML dependency.
+package yamlkey
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// Values returns the value of a top-level key, either a scalar (key: value) or a block
+// sequence of scalars (key: followed by "- value" lines). Flow collections, anchors and
+// multi-line scalars aren't supported.
+func Values(data []byte, key string) []string {
+	var values []string
+	inKey := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := stripComment(scanner.Text())
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "-") {
+			if inKey {
+				break
+			}
+			name, value, ok := splitKey(line)
+			if !ok || name != key {
+				continue
+			}
+			if value != "" {
+				return []string{unquote(value)}
+			}
+			inKey = true
+			continue
+		}
+
+		if inKey {
+			item := strings.TrimSpace(line)
+			if !strings.HasPrefix(item, "-") {
+				break
+			}
+			values = append(values, unquote(strings.TrimSpace(strings.TrimPrefix(item, "-"))))
+		}
+	}
+	return values
+}
+
+// Section returns the block mapping of a top-level key, dedented so Values reads its
+// keys, or nil when the key is missing or holds a scalar.
+func Section(data []byte, key string) []byte {
+	var section []string
+	indent := ""
+	forEachLine(data, key, func(line string, inSection bool) {
+		lineIndent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !inSection || lineIndent == "" || strings.TrimSpace(stripComment(line)) == "" {
+			return
+		}
+		if indent == "" {
+			indent = lineIndent
+		}
+		section = append(section, strings.TrimPrefix(line, indent))
+	})
+	if len(section) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(section, "\n") + "\n")
+}
+
+// Without returns data without a top-level key and its block.
+func Without(data []byte, key string) []byte {
+	var out bytes.Buffer
+	forEachLine(data, key, func(line string, inSection bool) {
+		if !inSection {
+			out.WriteString(line + "\n")
+		}
+	})
+	return out.Bytes()
+}
+
+// forEachLine calls fn with every line of data, and whether it's the top-level key or
+// part of its block.
+func forEachLine(data []byte, key string, fn func(line string, inSection bool)) {
+	inKey := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(stripComment(line))
+		if trimmed != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "-") {
+			name, _, ok := splitKey(stripComment(line))
+			if inKey = ok && name == key; inKey {
+				fn(line, true)
+				continue
+			}
+		}
+		fn(line, inKey && (trimmed != "" || strings.TrimSpace(line) == "" || strings.HasPrefix(line, " ")))
+	}
+}
+
+func splitKey(line string) (string, string, bool) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	return unquote(strings.TrimSpace(line[:i])), strings.TrimSpace(line[i+1:]), true
+}
+
+// stripComment removes a trailing comment, which starts with a # preceded by a space
+// outside of quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
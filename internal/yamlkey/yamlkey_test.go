@@ -0,0 +1,56 @@
+package yamlkey
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	tests := map[string]struct {
+		yaml   string
+		expect []string
+	}{
+		"scalar": {
+			yaml:   "schema: schema.graphql\noperations:\n- genqlient.graphql\n",
+			expect: []string{"schema.graphql"},
+		},
+		"quoted scalar with comment": {
+			yaml:   `schema: "graph/schema.graphqls" # fetched`,
+			expect: []string{"graph/schema.graphqls"},
+		},
+		"sequence": {
+			yaml:   "# gqlgen\nschema:\n  - graph/*.graphqls\n  - 'remote.graphqls'\nexec:\n  filename: graph/generated.go\n",
+			expect: []string{"graph/*.graphqls", "remote.graphqls"},
+		},
+		"missing": {
+			yaml: "exec:\n  schema: nested.graphql\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := Values([]byte(tt.yaml), "schema"); !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("expect: %v got: %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestSection(t *testing.T) {
+	yaml := "schema:\n  - graph/*.graphqls\n\ngqlfetch:\n  endpoint: https://api.example.com/graphql # remote\n\n  headers:\n    - \"Authorization: Bearer ${TOKEN}\"\n# models\nmodels:\n  ID:\n    model: github.com/99designs/gqlgen/graphql.ID\n"
+
+	section := Section([]byte(yaml), "gqlfetch")
+	if got := Values(section, "endpoint"); !reflect.DeepEqual(got, []string{"https://api.example.com/graphql"}) {
+		t.Errorf("expect the endpoint of the section got: %v", got)
+	}
+	if got := Values(section, "headers"); !reflect.DeepEqual(got, []string{"Authorization: Bearer ${TOKEN}"}) {
+		t.Errorf("expect the headers of the section got: %v", got)
+	}
+	if Section([]byte(yaml), "missing") != nil {
+		t.Error("expect no section for a missing key")
+	}
+
+	expect := "schema:\n  - graph/*.graphqls\n\n# models\nmodels:\n  ID:\n    model: github.com/99designs/gqlgen/graphql.ID\n"
+	if got := string(Without([]byte(yaml), "gqlfetch")); got != expect {
+		t.Errorf("expect:\n%s\ngot:\n%s", expect, got)
+	}
+}
@@ -2,25 +2,44 @@ package gqlfetch
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 
-	"github.com/vektah/gqlparser/ast"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 type introspectionResults struct {
-	Errors []struct {
-		Message string `json:"message"`
-	} `json:"errors"`
-	Data struct {
+	Errors GraphQLErrors `json:"errors"`
+	Data   struct {
 		Schema introspectionSchema `json:"__schema"`
 	} `json:"data"`
 }
 
 type introspectionSchema struct {
-	QueryType    ast.Definition                     `json:"queryType"`
-	MutationType ast.Definition                     `json:"mutationType"`
-	Types        []introspectionTypeDefinition      `json:"types"`
-	Directives   []introspectionDirectiveDefinition `json:"directives"`
+	QueryType        introspectionRootType              `json:"queryType"`
+	MutationType     introspectionRootType              `json:"mutationType"`
+	SubscriptionType introspectionRootType              `json:"subscriptionType"`
+	Types            []introspectionTypeDefinition      `json:"types"`
+	Directives       []introspectionDirectiveDefinition `json:"directives"`
+
+	// warnings report the types a Lenient fetch left out, printed as comments.
+	warnings []Warning
+}
+
+// introspectionRootType references a root operation type, an absent root has no name
+// and is encoded as null.
+type introspectionRootType struct {
+	Name string `json:"name"`
+}
+
+func (t introspectionRootType) MarshalJSON() ([]byte, error) {
+	if t.Name == "" {
+		return []byte("null"), nil
+	}
+	return json.Marshal(struct {
+		Name string `json:"name"`
+	}{Name: t.Name})
 }
 
 type introspectionTypeDefinition struct {
@@ -29,37 +48,52 @@ type introspectionTypeDefinition struct {
 	Description   string                    `json:"description"`
 	Fields        []introspectedTypeField   `json:"fields"`
 	InputFields   []introspectionInputField `json:"inputFields"`
-	Interfaces    []ast.Definition          `json:"interfaces"`
+	Interfaces    []introspectedType        `json:"interfaces"`
 	EnumValues    json.RawMessage           `json:"enumValues"`
 	PossibleTypes json.RawMessage           `json:"possibleTypes"`
+	// SpecifiedByURL is only fetched with SpecifiedByURL.
+	SpecifiedByURL *string `json:"specifiedByURL,omitempty"`
+	// IsOneOf is only fetched with OneOf.
+	IsOneOf bool `json:"isOneOf,omitempty"`
+	// AppliedDirectives is only fetched with AppliedDirectives.
+	AppliedDirectives []introspectionAppliedDirective `json:"appliedDirectives,omitempty"`
 }
 
 type introspectedTypeField struct {
-	Name              string                    `json:"name"`
-	Description       string                    `json:"description"`
-	Args              []introspectionInputField `json:"args"`
-	Type              *introspectedType         `json:"type"`
-	IsDeprecated      bool                      `json:"isDeprecated"`
-	DeprecationReason interface{}               `json:"deprecationReason"`
+	Name              string                          `json:"name"`
+	Description       string                          `json:"description"`
+	Args              []introspectionInputField       `json:"args"`
+	Type              *introspectedType               `json:"type"`
+	IsDeprecated      bool                            `json:"isDeprecated"`
+	DeprecationReason *string                         `json:"deprecationReason"`
+	AppliedDirectives []introspectionAppliedDirective `json:"appliedDirectives,omitempty"`
 }
 
 type introspectionDirectiveDefinition struct {
-	Name        string                  `json:"name"`
-	Description string                  `json:"description"`
-	Locations   []ast.DirectiveLocation `json:"locations"`
-	Args        []struct {
-		Name         string            `json:"name"`
-		Description  string            `json:"description"`
-		Type         *introspectedType `json:"type"`
-		DefaultValue interface{}       `json:"defaultValue"`
-	} `json:"args"`
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Locations   []ast.DirectiveLocation   `json:"locations"`
+	Args        []introspectionInputField `json:"args"`
+	// IsRepeatable is only fetched with DirectiveIsRepeatable.
+	IsRepeatable bool `json:"isRepeatable,omitempty"`
 }
 
 type introspectionInputField struct {
-	Name         string            `json:"name"`
-	Description  string            `json:"description"`
-	Type         *introspectedType `json:"type"`
-	DefaultValue interface{}       `json:"defaultValue"`
+	Name              string                          `json:"name"`
+	Description       string                          `json:"description"`
+	Type              *introspectedType               `json:"type"`
+	DefaultValue      *string                         `json:"defaultValue"`
+	IsDeprecated      bool                            `json:"isDeprecated"`
+	DeprecationReason *string                         `json:"deprecationReason"`
+	AppliedDirectives []introspectionAppliedDirective `json:"appliedDirectives,omitempty"`
+}
+
+type introspectionEnumValue struct {
+	Name              string                          `json:"name"`
+	Description       string                          `json:"description"`
+	IsDeprecated      bool                            `json:"isDeprecated"`
+	DeprecationReason *string                         `json:"deprecationReason"`
+	AppliedDirectives []introspectionAppliedDirective `json:"appliedDirectives,omitempty"`
 }
 
 type introspectedType struct {
@@ -71,20 +105,33 @@ type introspectedType struct {
 type introspectionTypeKind string
 
 const (
-	NON_NULL introspectionTypeKind = "NON_NULL"
-	LIST     introspectionTypeKind = "LIST"
-	OBJECT   introspectionTypeKind = "OBJECT"
+	NON_NULL     introspectionTypeKind = "NON_NULL"
+	LIST         introspectionTypeKind = "LIST"
+	SCALAR       introspectionTypeKind = "SCALAR"
+	OBJECT       introspectionTypeKind = "OBJECT"
+	INTERFACE    introspectionTypeKind = "INTERFACE"
+	UNION        introspectionTypeKind = "UNION"
+	ENUM         introspectionTypeKind = "ENUM"
+	INPUT_OBJECT introspectionTypeKind = "INPUT_OBJECT"
 )
 
+// introspectionTypeToAstType converts a type reference, wrapping the named type in the
+// lists and non-nulls its NON_NULL and LIST kinds describe. The ofType of named kinds
+// is ignored, as some servers return an empty one.
 func introspectionTypeToAstType(typ *introspectedType) (*ast.Type, error) {
 	var res ast.Type
-	if typ.OfType == nil {
-		res.NamedType = *typ.Name
-		return &res, nil
+	if typ == nil {
+		return nil, errors.New("missing type reference")
 	}
 
 	switch typ.Kind {
 	case NON_NULL:
+		if typ.OfType == nil {
+			return nil, fmt.Errorf("%s type reference without an inner type", typ.Kind)
+		}
+		if typ.OfType.Kind == NON_NULL {
+			return nil, fmt.Errorf("%s type reference wrapping a %s type", typ.Kind, typ.OfType.Kind)
+		}
 		astType, err := introspectionTypeToAstType(typ.OfType)
 		if err != nil {
 			return nil, fmt.Errorf("convert introspection type to AST type: %w\n%v", err, typ.OfType)
@@ -93,22 +140,216 @@ func introspectionTypeToAstType(typ *introspectedType) (*ast.Type, error) {
 		res.NonNull = true
 		return &res, nil
 	case LIST:
+		if typ.OfType == nil {
+			return nil, fmt.Errorf("%s type reference without an inner type", typ.Kind)
+		}
 		astType, err := introspectionTypeToAstType(typ.OfType)
 		if err != nil {
 			return nil, fmt.Errorf("convert introspection type to AST type: %w\n%v", err, typ.OfType)
 		}
 		res.Elem = astType
 		return &res, nil
+	case SCALAR, OBJECT, INTERFACE, UNION, ENUM, INPUT_OBJECT:
+		if typ.Name == nil || *typ.Name == "" {
+			return nil, fmt.Errorf("%s type reference without a name", typ.Kind)
+		}
+		res.NamedType = *typ.Name
+		return &res, nil
 	default:
 		return nil, fmt.Errorf("type kind unknown: %s", typ.Kind)
 	}
 }
 
+// withoutDescriptions clears every description of the schema, for servers that return
+// them even though they weren't requested.
+func withoutDescriptions(schema introspectionSchema) (introspectionSchema, error) {
+	directives := make([]introspectionDirectiveDefinition, len(schema.Directives))
+	for i, directive := range schema.Directives {
+		directive.Description = ""
+		directive.Args = inputFieldsWithoutDescriptions(directive.Args)
+		directives[i] = directive
+	}
+	schema.Directives = directives
+
+	types := make([]introspectionTypeDefinition, len(schema.Types))
+	for i, typ := range schema.Types {
+		typ.Description = ""
+		fields := make([]introspectedTypeField, len(typ.Fields))
+		for j, field := range typ.Fields {
+			field.Description = ""
+			field.Args = inputFieldsWithoutDescriptions(field.Args)
+			fields[j] = field
+		}
+		if typ.Fields != nil {
+			typ.Fields = fields
+		}
+		typ.InputFields = inputFieldsWithoutDescriptions(typ.InputFields)
+
+		if len(typ.EnumValues) > 0 && string(typ.EnumValues) != "null" {
+			var values []introspectionEnumValue
+			if err := json.Unmarshal(typ.EnumValues, &values); err != nil {
+				return introspectionSchema{}, fmt.Errorf("cannot unmarshal enum values: %w\n%v", err, typ.EnumValues)
+			}
+			for j := range values {
+				values[j].Description = ""
+			}
+			encoded, err := json.Marshal(values)
+			if err != nil {
+				return introspectionSchema{}, fmt.Errorf("cannot marshal enum values: %w", err)
+			}
+			typ.EnumValues = encoded
+		}
+		types[i] = typ
+	}
+	schema.Types = types
+	return schema, nil
+}
+
+func inputFieldsWithoutDescriptions(fields []introspectionInputField) []introspectionInputField {
+	if fields == nil {
+		return nil
+	}
+	stripped := make([]introspectionInputField, len(fields))
+	for i, field := range fields {
+		field.Description = ""
+		stripped[i] = field
+	}
+	return stripped
+}
+
+// sortSchema sorts the members of the schema by name, copying every slice it sorts.
+func sortSchema(schema introspectionSchema) (introspectionSchema, error) {
+	schema.Directives = append([]introspectionDirectiveDefinition(nil), schema.Directives...)
+	sort.SliceStable(schema.Directives, func(i, j int) bool { return schema.Directives[i].Name < schema.Directives[j].Name })
+
+	types := make([]introspectionTypeDefinition, len(schema.Types))
+	for i, typ := range schema.Types {
+		if typ.Fields != nil {
+			typ.Fields = append([]introspectedTypeField(nil), typ.Fields...)
+			sort.SliceStable(typ.Fields, func(i, j int) bool { return typ.Fields[i].Name < typ.Fields[j].Name })
+		}
+		if typ.InputFields != nil {
+			typ.InputFields = append([]introspectionInputField(nil), typ.InputFields...)
+			sort.SliceStable(typ.InputFields, func(i, j int) bool { return typ.InputFields[i].Name < typ.InputFields[j].Name })
+		}
+		if typ.Interfaces != nil {
+			typ.Interfaces = sortTypeRefs(typ.Interfaces)
+		}
+
+		if len(typ.EnumValues) > 0 && string(typ.EnumValues) != "null" {
+			var values []introspectionEnumValue
+			if err := json.Unmarshal(typ.EnumValues, &values); err != nil {
+				return introspectionSchema{}, fmt.Errorf("cannot unmarshal enum values: %w\n%v", err, typ.EnumValues)
+			}
+			sort.SliceStable(values, func(i, j int) bool { return values[i].Name < values[j].Name })
+			encoded, err := json.Marshal(values)
+			if err != nil {
+				return introspectionSchema{}, fmt.Errorf("cannot marshal enum values: %w", err)
+			}
+			typ.EnumValues = encoded
+		}
+		if len(typ.PossibleTypes) > 0 && string(typ.PossibleTypes) != "null" {
+			var possible []introspectedType
+			if err := json.Unmarshal(typ.PossibleTypes, &possible); err != nil {
+				return introspectionSchema{}, fmt.Errorf("cannot unmarshal possible types: %w\n%v", err, typ.PossibleTypes)
+			}
+			encoded, err := json.Marshal(sortTypeRefs(possible))
+			if err != nil {
+				return introspectionSchema{}, fmt.Errorf("cannot marshal possible types: %w", err)
+			}
+			typ.PossibleTypes = encoded
+		}
+		types[i] = typ
+	}
+	sort.SliceStable(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	schema.Types = types
+	return schema, nil
+}
+
+func sortTypeRefs(refs []introspectedType) []introspectedType {
+	sorted := append([]introspectedType(nil), refs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return typeRefName(sorted[i]) < typeRefName(sorted[j]) })
+	return sorted
+}
+
+// interfaceNames returns the names of the interfaces typ implements, failing on
+// references without a name rather than printing an invalid schema.
+func interfaceNames(typ introspectionTypeDefinition) ([]string, error) {
+	var names []string
+	for _, intface := range typ.Interfaces {
+		if intface.Name == nil || *intface.Name == "" {
+			return nil, fmt.Errorf("%s implements an interface reference without a name", typ.Name)
+		}
+		names = append(names, *intface.Name)
+	}
+	return names, nil
+}
+
+func typeRefName(ref introspectedType) string {
+	if ref.Name == nil {
+		return ""
+	}
+	return *ref.Name
+}
+
+// validateRootOperationTypes ensures every root operation type named by the schema is
+// present in the introspected types as an object type.
+func validateRootOperationTypes(schema introspectionSchema) error {
+	roots := map[ast.Operation]string{
+		ast.Query:        schema.QueryType.Name,
+		ast.Mutation:     schema.MutationType.Name,
+		ast.Subscription: schema.SubscriptionType.Name,
+	}
+	for _, operation := range []ast.Operation{ast.Query, ast.Mutation, ast.Subscription} {
+		name := roots[operation]
+		if name == "" {
+			continue
+		}
+		var found *introspectionTypeDefinition
+		for i := range schema.Types {
+			if schema.Types[i].Name == name {
+				found = &schema.Types[i]
+				break
+			}
+		}
+		if found == nil {
+			return fmt.Errorf("schema %s root refers to type %s that does not exist", operation, name)
+		}
+		if found.Kind != ast.Object {
+			return fmt.Errorf("schema %s root type %s must be %v, got %v", operation, name, ast.Object, found.Kind)
+		}
+	}
+	return nil
+}
+
+// defaultDeprecationReason is the reason assumed by the @deprecated directive when none is given.
+const defaultDeprecationReason = "No longer supported"
+
 var (
 	excludeScalarTypes = []string{"ID", "Int", "String", "Float", "Boolean"}
-	excludeDirectives  = []string{"deprecated", "include", "skip"}
+	excludeDirectives  = []string{"deprecated", "include", "skip", "specifiedBy"}
 )
 
+// builtinFilter selects the built-in directives and scalars left out of printed SDL.
+type builtinFilter struct {
+	without        bool
+	keepDirectives []string
+	keepScalars    []string
+}
+
+// builtinFilterOf returns the filter of WithoutBuiltins, KeepDirectives and KeepScalars.
+func builtinFilterOf(options BuildClientSchemaOptions) builtinFilter {
+	return builtinFilter{without: options.WithoutBuiltins, keepDirectives: options.KeepDirectives, keepScalars: options.KeepScalars}
+}
+
+func (f builtinFilter) skipDirective(name string) bool {
+	return f.without && containsStr(name, excludeDirectives) && !containsStr(name, f.keepDirectives)
+}
+
+func (f builtinFilter) skipType(typ introspectionTypeDefinition) bool {
+	return f.without && typ.Kind == ast.Scalar && containsStr(typ.Name, excludeScalarTypes) && !containsStr(typ.Name, f.keepScalars)
+}
+
 func containsStr(needle string, hay []string) bool {
 	for _, s := range hay {
 		if needle == s {
@@ -0,0 +1,133 @@
+package gqlfetch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_introspectionTypeToAstType(t *testing.T) {
+	tests := map[string]struct {
+		typ       string
+		expect    string
+		expectErr string
+	}{
+		"scalar":       {typ: `{"kind": "SCALAR", "name": "Int"}`, expect: "Int"},
+		"object":       {typ: `{"kind": "OBJECT", "name": "User"}`, expect: "User"},
+		"interface":    {typ: `{"kind": "INTERFACE", "name": "Node"}`, expect: "Node"},
+		"union":        {typ: `{"kind": "UNION", "name": "SearchResult"}`, expect: "SearchResult"},
+		"enum":         {typ: `{"kind": "ENUM", "name": "Role"}`, expect: "Role"},
+		"input object": {typ: `{"kind": "INPUT_OBJECT", "name": "Filter"}`, expect: "Filter"},
+		"named kind with an empty ofType": {
+			typ:    `{"kind": "ENUM", "name": "Role", "ofType": {"kind": "", "name": null}}`,
+			expect: "Role",
+		},
+		"non-null interface": {
+			typ:    `{"kind": "NON_NULL", "ofType": {"kind": "INTERFACE", "name": "Node"}}`,
+			expect: "Node!",
+		},
+		"list of non-null unions": {
+			typ:    `{"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "UNION", "name": "SearchResult"}}}`,
+			expect: "[SearchResult!]",
+		},
+		"non-null list of non-null lists of non-null scalars": {
+			typ:    `{"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Int"}}}}}}`,
+			expect: "[[Int!]!]!",
+		},
+		"lists of lists of enums": {
+			typ:    `{"kind": "LIST", "ofType": {"kind": "LIST", "ofType": {"kind": "LIST", "ofType": {"kind": "ENUM", "name": "Role"}}}}`,
+			expect: "[[[Role]]]",
+		},
+		"non-null list of nullable lists of input objects": {
+			typ:    `{"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "INPUT_OBJECT", "name": "Filter"}}}}}`,
+			expect: "[[Filter!]]!",
+		},
+		"non-null without an inner type": {
+			typ:       `{"kind": "NON_NULL", "name": "Int"}`,
+			expectErr: "NON_NULL type reference without an inner type",
+		},
+		"list without an inner type": {
+			typ:       `{"kind": "LIST", "ofType": {"kind": "LIST"}}`,
+			expectErr: "LIST type reference without an inner type",
+		},
+		"non-null of non-null": {
+			typ:       `{"kind": "NON_NULL", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Int"}}}`,
+			expectErr: "NON_NULL type reference wrapping a NON_NULL type",
+		},
+		"named kind without a name": {
+			typ:       `{"kind": "LIST", "ofType": {"kind": "SCALAR", "name": null}}`,
+			expectErr: "SCALAR type reference without a name",
+		},
+		"unknown kind": {
+			typ:       `{"kind": "NON_NULL", "ofType": {"kind": "TUPLE", "name": "Pair"}}`,
+			expectErr: "type kind unknown: TUPLE",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var typ introspectedType
+			if err := json.Unmarshal([]byte(tt.typ), &typ); err != nil {
+				t.Fatalf("decode type: %v", err)
+			}
+			astType, err := introspectionTypeToAstType(&typ)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Fatalf("expect error containing %q got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if astType.String() != tt.expect {
+				t.Errorf("expect %s got: %s", tt.expect, astType.String())
+			}
+		})
+	}
+}
+
+func Test_interfaceNames(t *testing.T) {
+	tests := map[string]struct {
+		typ       string
+		expect    string
+		expectErr string
+	}{
+		"object": {
+			typ:    `{"kind": "OBJECT", "name": "User", "fields": [], "interfaces": [{"kind": "INTERFACE", "name": "Node"}, {"kind": "INTERFACE", "name": "Entity"}]}`,
+			expect: "type User implements Node & Entity {",
+		},
+		"object with a nameless interface": {
+			typ:       `{"kind": "OBJECT", "name": "User", "fields": [], "interfaces": [{"kind": "INTERFACE", "name": null}]}`,
+			expectErr: "User implements an interface reference without a name",
+		},
+		"interface with a nameless interface": {
+			typ:       `{"kind": "INTERFACE", "name": "Node", "fields": [], "interfaces": [{"kind": "INTERFACE"}]}`,
+			expectErr: "Node implements an interface reference without a name",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var typ introspectionTypeDefinition
+			if err := json.Unmarshal([]byte(tt.typ), &typ); err != nil {
+				t.Fatalf("decode type: %v", err)
+			}
+			sb := &strings.Builder{}
+			printErr := printType(sb, typ, SDLFormat{})
+			_, convertErr := introspectionTypeToAstDefinition(typ)
+			for _, err := range []error{printErr, convertErr} {
+				if tt.expectErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+						t.Errorf("expect error containing %q got: %v", tt.expectErr, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if tt.expectErr == "" && !strings.Contains(sb.String(), tt.expect) {
+				t.Errorf("expect %q got:\n%s", tt.expect, sb)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package gqlfetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ErrNotLoggedIn is returned by TokenStore.Load when no login is saved under the key.
+var ErrNotLoggedIn = errors.New("not logged in")
+
+// ErrNoKeychain is returned by Keychain when the operating system has no keychain it
+// supports.
+var ErrNoKeychain = errors.New("no supported keychain")
+
+// SavedLogin is a token obtained with OAuth2Login, along with what refreshing it
+// requires.
+type SavedLogin struct {
+	Token
+	TokenURL string
+	ClientID string
+	Scopes   []string
+}
+
+// TokenStore saves logins between runs, under a key such as the endpoint.
+type TokenStore interface {
+	// Load returns ErrNotLoggedIn when no login is saved under key.
+	Load(key string) (SavedLogin, error)
+	Save(key string, login SavedLogin) error
+	Delete(key string) error
+}
+
+// Keychain is a TokenStore saving logins in the keychain of the operating system,
+// through the security command on macOS and secret-tool of libsecret on Linux.
+type Keychain struct {
+	// Service names the keychain items, gqlfetch when empty.
+	Service string
+}
+
+// keychainExitError is returned by keychainCommand when the command fails.
+type keychainExitError struct {
+	Command string
+	Code    int
+	Stderr  string
+}
+
+func (e *keychainExitError) Error() string {
+	return fmt.Sprintf("%s failed with exit status %d: %s", e.Command, e.Code, e.Stderr)
+}
+
+// keychainCommand runs a keychain command, writing stdin to it and returning its
+// output. It is replaced by tests.
+var keychainCommand = func(stdin string, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrNoKeychain, err)
+	}
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return "", &keychainExitError{Command: name, Code: exitErr.ExitCode(), Stderr: strings.TrimSpace(stderr.String())}
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+	return stdout.String(), nil
+}
+
+// securityItemNotFound is the exit status of the security command when the item
+// doesn't exist, errSecItemNotFound.
+const securityItemNotFound = 44
+
+// keychainGOOS is replaced by tests.
+var keychainGOOS = runtime.GOOS
+
+func (k Keychain) service() string {
+	if k.Service == "" {
+		return "gqlfetch"
+	}
+	return k.Service
+}
+
+func (k Keychain) Load(key string) (SavedLogin, error) {
+	var output string
+	var err error
+	switch keychainGOOS {
+	case "darwin":
+		output, err = keychainCommand("", "security", "find-generic-password", "-s", k.service(), "-a", key, "-w")
+	case "linux":
+		output, err = keychainCommand("", "secret-tool", "lookup", "service", k.service(), "account", key)
+	default:
+		return SavedLogin{}, fmt.Errorf("%w on %s", ErrNoKeychain, keychainGOOS)
+	}
+	// secret-tool exits with status 1 without a message when the item doesn't exist,
+	// and reports other failures, such as a locked keychain, on standard error.
+	var exitErr *keychainExitError
+	switch {
+	case errors.As(err, &exitErr) && keychainGOOS == "darwin" && exitErr.Code == securityItemNotFound,
+		errors.As(err, &exitErr) && keychainGOOS == "linux" && exitErr.Code == 1 && exitErr.Stderr == "",
+		err == nil && strings.TrimSpace(output) == "":
+		return SavedLogin{}, ErrNotLoggedIn
+	case err != nil:
+		return SavedLogin{}, fmt.Errorf("failed to load login: %w", err)
+	}
+	var login SavedLogin
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &login); err != nil {
+		return SavedLogin{}, fmt.Errorf("failed to decode saved login: %w", err)
+	}
+	return login, nil
+}
+
+func (k Keychain) Save(key string, login SavedLogin) error {
+	encoded, err := json.Marshal(login)
+	if err != nil {
+		return fmt.Errorf("failed to encode login: %w", err)
+	}
+	switch keychainGOOS {
+	case "darwin":
+		// The interactive mode of security reads the command from standard input, so
+		// the tokens don't show in the process list.
+		if strings.ContainsAny(k.service()+key, "\"\\\n") {
+			return fmt.Errorf("cannot save login for %q in the keychain", key)
+		}
+		command := fmt.Sprintf("add-generic-password -U -s \"%s\" -a \"%s\" -X %s\n", k.service(), key, hex.EncodeToString(encoded))
+		if _, err = keychainCommand(command, "security", "-i"); err == nil {
+			// security -i reports the errors of its commands without failing, so the
+			// login is read back.
+			var saved SavedLogin
+			if saved, err = k.Load(key); err == nil && saved.AccessToken != login.AccessToken {
+				err = errors.New("keychain item not updated")
+			}
+		}
+	case "linux":
+		_, err = keychainCommand(string(encoded), "secret-tool", "store", "--label", k.service()+" login for "+key, "service", k.service(), "account", key)
+	default:
+		return fmt.Errorf("%w on %s", ErrNoKeychain, keychainGOOS)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save login: %w", err)
+	}
+	return nil
+}
+
+func (k Keychain) Delete(key string) error {
+	var err error
+	switch keychainGOOS {
+	case "darwin":
+		_, err = keychainCommand("", "security", "delete-generic-password", "-s", k.service(), "-a", key)
+	case "linux":
+		_, err = keychainCommand("", "secret-tool", "clear", "service", k.service(), "account", key)
+	default:
+		return fmt.Errorf("%w on %s", ErrNoKeychain, keychainGOOS)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete login: %w", err)
+	}
+	return nil
+}
+
+// StoredLogin authenticates with the bearer token saved under Key, refreshing it once
+// expired and saving the refreshed token.
+type StoredLogin struct {
+	Store TokenStore
+	Key   string
+	// HTTPClient sends the refresh requests, http.DefaultClient when nil.
+	HTTPClient *http.Client
+
+	once   sync.Once
+	bearer *BearerToken
+}
+
+func (a *StoredLogin) Apply(req *http.Request) error {
+	a.once.Do(func() {
+		a.bearer = &BearerToken{Source: a.fetchToken}
+	})
+	return a.bearer.Apply(req)
+}
+
+func (a *StoredLogin) fetchToken(ctx context.Context) (Token, error) {
+	login, err := a.Store.Load(a.Key)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to load login for %s: %w", a.Key, err)
+	}
+	if login.valid() {
+		return login.Token, nil
+	}
+	if login.RefreshToken == "" {
+		return Token{}, fmt.Errorf("login for %s expired, log in again", a.Key)
+	}
+	provider := OAuth2Login{ClientID: login.ClientID, Scopes: login.Scopes, TokenURL: login.TokenURL, HTTPClient: a.HTTPClient}
+	if login.Token, err = provider.Refresh(ctx, login.RefreshToken); err != nil {
+		return Token{}, err
+	}
+	if err := a.Store.Save(a.Key, login); err != nil {
+		return Token{}, err
+	}
+	return login.Token, nil
+}
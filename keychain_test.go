@@ -0,0 +1,190 @@
+package gqlfetch
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKeychain(t *testing.T) {
+	command, goos := keychainCommand, keychainGOOS
+	defer func() { keychainCommand, keychainGOOS = command, goos }()
+
+	key := "https://api.example.com/graphql"
+	tests := map[string]struct {
+		goos         string
+		expectSave   string
+		expectLoad   string
+		expectDelete string
+		notFound     *keychainExitError
+		expectErr    error
+	}{
+		"macOS": {
+			goos:         "darwin",
+			expectSave:   "security -i",
+			expectLoad:   "security find-generic-password -s gqlfetch -a " + key + " -w",
+			expectDelete: "security delete-generic-password -s gqlfetch -a " + key,
+			notFound:     &keychainExitError{Command: "security", Code: 44, Stderr: "The specified item could not be found in the keychain."},
+		},
+		"linux": {
+			goos:         "linux",
+			expectSave:   "secret-tool store --label gqlfetch login for " + key + " service gqlfetch account " + key,
+			expectLoad:   "secret-tool lookup service gqlfetch account " + key,
+			expectDelete: "secret-tool clear service gqlfetch account " + key,
+			notFound:     &keychainExitError{Command: "secret-tool", Code: 1},
+		},
+		"unsupported": {goos: "plan9", expectErr: ErrNoKeychain},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			keychainGOOS = tt.goos
+			item, locked := "", false
+			var commands []string
+			keychainCommand = func(stdin string, name string, args ...string) (string, error) {
+				command := strings.Join(append([]string{name}, args...), " ")
+				commands = append(commands, command)
+				switch {
+				case locked:
+					return "", &keychainExitError{Command: name, Code: 1, Stderr: "keychain is locked"}
+				case command == "security -i":
+					if strings.Contains(stdin, "{") {
+						t.Errorf("expect the login to be hex encoded got: %s", stdin)
+					}
+					decoded, err := hex.DecodeString(strings.TrimSpace(stdin[strings.Index(stdin, "-X ")+3:]))
+					if err != nil {
+						t.Fatalf("decode stdin: %v", err)
+					}
+					item = string(decoded)
+				case stdin != "":
+					item = stdin
+				case strings.Contains(command, "find-generic-password") || strings.Contains(command, "lookup"):
+					if item == "" {
+						return "", tt.notFound
+					}
+					return item + "\n", nil
+				default:
+					item = ""
+				}
+				return "", nil
+			}
+
+			keychain := Keychain{}
+			saved := SavedLogin{Token: Token{AccessToken: "token", RefreshToken: "refresh"}, TokenURL: "https://sso.example.com/token", ClientID: "gqlfetch"}
+			err := keychain.Save(key, saved)
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expect error: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(commands) == 0 || commands[0] != tt.expectSave {
+				t.Errorf("expect command %q got: %q", tt.expectSave, commands)
+			}
+			for _, command := range commands {
+				if strings.Contains(command, "refresh") {
+					t.Errorf("expect the tokens to stay out of the arguments got: %s", command)
+				}
+			}
+			commands = nil
+			login, err := keychain.Load(key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if login.AccessToken != "token" || login.RefreshToken != "refresh" || login.ClientID != "gqlfetch" {
+				t.Errorf("expect the saved login got: %+v", login)
+			}
+			if err := keychain.Delete(key); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := keychain.Load(key); !errors.Is(err, ErrNotLoggedIn) {
+				t.Errorf("expect error: %v got: %v", ErrNotLoggedIn, err)
+			}
+			locked = true
+			if _, err := keychain.Load(key); err == nil || errors.Is(err, ErrNotLoggedIn) {
+				t.Errorf("expect a locked keychain to fail got: %v", err)
+			}
+
+			if len(commands) != 4 || commands[0] != tt.expectLoad || commands[1] != tt.expectDelete {
+				t.Errorf("expect commands %q and %q got: %q", tt.expectLoad, tt.expectDelete, commands)
+			}
+		})
+	}
+}
+
+// memoryTokenStore is a TokenStore keeping logins in memory.
+type memoryTokenStore map[string]SavedLogin
+
+func (s memoryTokenStore) Load(key string) (SavedLogin, error) {
+	login, ok := s[key]
+	if !ok {
+		return SavedLogin{}, ErrNotLoggedIn
+	}
+	return login, nil
+}
+
+func (s memoryTokenStore) Save(key string, login SavedLogin) error {
+	s[key] = login
+	return nil
+}
+
+func (s memoryTokenStore) Delete(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func TestStoredLogin(t *testing.T) {
+	provider := newOAuth2Provider(t, nil, nil)
+	defer provider.Close()
+
+	tests := map[string]struct {
+		login       *SavedLogin
+		expectToken string
+		expectErr   string
+	}{
+		"valid": {
+			login:       &SavedLogin{Token: Token{AccessToken: "token", Expiry: time.Now().Add(time.Hour)}},
+			expectToken: "token",
+		},
+		"refreshed": {
+			login:       &SavedLogin{Token: Token{AccessToken: "token", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)}, TokenURL: provider.URL + "/token", ClientID: "gqlfetch"},
+			expectToken: "refreshed-refresh",
+		},
+		"expired": {
+			login:     &SavedLogin{Token: Token{AccessToken: "token", Expiry: time.Now().Add(-time.Hour)}},
+			expectErr: "expired, log in again",
+		},
+		"not logged in": {expectErr: "not logged in"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			store := memoryTokenStore{}
+			if tt.login != nil {
+				store["endpoint"] = *tt.login
+			}
+			req := httptest.NewRequest(http.MethodPost, "https://api.example.com/graphql", nil)
+			err := (&StoredLogin{Store: store, Key: "endpoint"}).Apply(req)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := req.Header.Get("Authorization"); got != "Bearer "+tt.expectToken {
+				t.Errorf("expect Authorization: Bearer %s got: %s", tt.expectToken, got)
+			}
+			if store["endpoint"].AccessToken != tt.expectToken || store["endpoint"].RefreshToken != tt.login.RefreshToken {
+				t.Errorf("expect the refreshed login to be saved got: %+v", store["endpoint"])
+			}
+		})
+	}
+}
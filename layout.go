@@ -0,0 +1,149 @@
+package gqlfetch
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// OutputLayout selects how BuildClientSchemaToDir splits the schema into files, the way
+// gqlgen projects usually organize their .graphqls files.
+type OutputLayout int
+
+const (
+	// LayoutSingleFile writes the whole schema to schema.graphqls.
+	LayoutSingleFile OutputLayout = iota
+	// LayoutByKind writes the root operation types to queries.graphqls,
+	// mutations.graphqls and subscriptions.graphqls, and the other types to a file per
+	// kind, such as objects.graphqls, enums.graphqls or inputs.graphqls. Directives
+	// and the schema definition go to schema.graphqls.
+	LayoutByKind
+	// LayoutByPrefix writes the root operation types like LayoutByKind, and the other
+	// types to a file per leading word of their name, such as types/user.graphqls for
+	// User, UserConnection and UserInput.
+	LayoutByPrefix
+)
+
+// kindFiles are the files of LayoutByKind.
+var kindFiles = map[ast.DefinitionKind]string{
+	ast.Object:      "objects.graphqls",
+	ast.Interface:   "interfaces.graphqls",
+	ast.Union:       "unions.graphqls",
+	ast.Enum:        "enums.graphqls",
+	ast.InputObject: "inputs.graphqls",
+	ast.Scalar:      "scalars.graphqls",
+}
+
+// SplitSchema splits sdl into files according to layout, returning the content of every
+// file by slash-separated path.
+func SplitSchema(sdl string, layout OutputLayout) (map[string]string, error) {
+	schema, err := LoadSchema(&ast.Source{Input: sdl})
+	if err != nil {
+		return nil, err
+	}
+	if layout == LayoutSingleFile {
+		return map[string]string{"schema.graphqls": formatSchemaDocument(userSchemaDocument(schema))}, nil
+	}
+
+	roots := map[string]string{}
+	if schema.Query != nil {
+		roots[schema.Query.Name] = "queries.graphqls"
+	}
+	if schema.Mutation != nil {
+		roots[schema.Mutation.Name] = "mutations.graphqls"
+	}
+	if schema.Subscription != nil {
+		roots[schema.Subscription.Name] = "subscriptions.graphqls"
+	}
+
+	docs := map[string]*ast.SchemaDocument{}
+	doc := func(path string) *ast.SchemaDocument {
+		if docs[path] == nil {
+			docs[path] = &ast.SchemaDocument{}
+		}
+		return docs[path]
+	}
+
+	full := userSchemaDocument(schema)
+	if len(full.Schema) > 0 || len(full.Directives) > 0 {
+		doc("schema.graphqls").Schema = full.Schema
+		doc("schema.graphqls").Directives = full.Directives
+	}
+	for _, def := range full.Definitions {
+		path := roots[def.Name]
+		switch {
+		case path != "":
+		case layout == LayoutByKind:
+			path = kindFiles[def.Kind]
+		case layout == LayoutByPrefix:
+			path = "types/" + namePrefix(def.Name) + ".graphqls"
+		default:
+			return nil, fmt.Errorf("unknown output layout %d", layout)
+		}
+		doc(path).Definitions = append(doc(path).Definitions, def)
+	}
+
+	files := make(map[string]string, len(docs))
+	for path, doc := range docs {
+		files[path] = formatSchemaDocument(doc)
+	}
+	return files, nil
+}
+
+// namePrefix returns the first word of a PascalCase, camelCase or snake_case name,
+// lowercased: User for UserConnection, url for URLInput.
+func namePrefix(name string) string {
+	runes := []rune(strings.TrimLeft(name, "_"))
+	end := len(runes)
+	for i := 1; i < len(runes); i++ {
+		if runes[i] == '_' {
+			end = i
+			break
+		}
+		if unicode.IsUpper(runes[i]) {
+			// The last capital of an acronym starts the next word: URL|Input.
+			if unicode.IsUpper(runes[i-1]) && (i+1 >= len(runes) || !unicode.IsLower(runes[i+1])) {
+				continue
+			}
+			end = i
+			break
+		}
+	}
+	if end == 0 {
+		return strings.ToLower(name)
+	}
+	return strings.ToLower(string(runes[:end]))
+}
+
+// BuildClientSchemaToDir fetches the schema and writes it to dir split according to
+// options.OutputLayout, each file being replaced only when its content changed like
+// BuildClientSchemaToFile. It returns the index of the files making up the schema,
+// slash-separated and sorted. Files left from a previous layout aren't removed.
+func BuildClientSchemaToDir(ctx context.Context, options BuildClientSchemaOptions, dir string) ([]string, error) {
+	options.OutputFormat = OutputSDL
+	sdl, err := BuildClientSchemaWithOptions(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	files, err := SplitSchema(sdl, options.OutputLayout)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make([]string, 0, len(files))
+	for path := range files {
+		index = append(index, path)
+	}
+	sort.Strings(index)
+	for _, path := range index {
+		if _, err := WriteSchemaFile(filepath.Join(dir, filepath.FromSlash(path)), files[path]); err != nil {
+			return nil, err
+		}
+	}
+	return index, nil
+}
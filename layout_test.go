@@ -0,0 +1,125 @@
+package gqlfetch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestSplitSchema(t *testing.T) {
+	const sdl = `directive @auth on FIELD_DEFINITION
+type Query { user(id: ID!): User @auth users: UserConnection }
+type Mutation { createUser(input: CreateUserInput!): User }
+type User { id: ID! role: Role }
+type UserConnection { nodes: [User!]! pageInfo: PageInfo! }
+type PageInfo { hasNextPage: Boolean! }
+input CreateUserInput { name: String! }
+enum Role { ADMIN USER }
+`
+	tests := map[string]struct {
+		layout OutputLayout
+		expect map[string][]string
+	}{
+		"single file": {
+			layout: LayoutSingleFile,
+			expect: map[string][]string{"schema.graphqls": {"directive @auth", "type Query", "type User ", "enum Role"}},
+		},
+		"by kind": {
+			layout: LayoutByKind,
+			expect: map[string][]string{
+				"schema.graphqls":    {"directive @auth"},
+				"queries.graphqls":   {"type Query"},
+				"mutations.graphqls": {"type Mutation"},
+				"objects.graphqls":   {"type PageInfo", "type User ", "type UserConnection"},
+				"inputs.graphqls":    {"input CreateUserInput"},
+				"enums.graphqls":     {"enum Role"},
+			},
+		},
+		"by prefix": {
+			layout: LayoutByPrefix,
+			expect: map[string][]string{
+				"schema.graphqls":       {"directive @auth"},
+				"queries.graphqls":      {"type Query"},
+				"mutations.graphqls":    {"type Mutation"},
+				"types/user.graphqls":   {"type User ", "type UserConnection"},
+				"types/page.graphqls":   {"type PageInfo"},
+				"types/create.graphqls": {"input CreateUserInput"},
+				"types/role.graphqls":   {"enum Role"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			files, err := SplitSchema(sdl, tt.layout)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var paths, expectPaths []string
+			for path := range files {
+				paths = append(paths, path)
+			}
+			for path, fragments := range tt.expect {
+				expectPaths = append(expectPaths, path)
+				for _, fragment := range fragments {
+					if !strings.Contains(files[path], fragment) {
+						t.Errorf("expect %s to contain %q got:\n%v", path, fragment, files[path])
+					}
+				}
+			}
+			sort.Strings(paths)
+			sort.Strings(expectPaths)
+			if !reflect.DeepEqual(paths, expectPaths) {
+				t.Errorf("expect files %v got: %v", expectPaths, paths)
+			}
+
+			var sources []*ast.Source
+			for path, content := range files {
+				sources = append(sources, &ast.Source{Name: path, Input: content})
+			}
+			if _, err := LoadSchema(sources...); err != nil {
+				t.Errorf("expect the files to load together got: %v", err)
+			}
+		})
+	}
+}
+
+func Test_namePrefix(t *testing.T) {
+	tests := map[string]string{
+		"User":           "user",
+		"UserConnection": "user",
+		"URLInput":       "url",
+		"ID":             "id",
+		"user_role":      "user",
+		"_Service":       "service",
+	}
+	for name, expect := range tests {
+		if got := namePrefix(name); got != expect {
+			t.Errorf("expect %s prefix %q got: %q", name, expect, got)
+		}
+	}
+}
+
+func TestBuildClientSchemaToDir(t *testing.T) {
+	dir := t.TempDir()
+	index, err := BuildClientSchemaToDir(context.Background(), BuildClientSchemaOptions{
+		Endpoint:     "file://testdata/introspection.json",
+		OutputLayout: LayoutByKind,
+	}, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !sort.StringsAreSorted(index) || len(index) < 2 {
+		t.Errorf("expect a sorted index of several files got: %v", index)
+	}
+	for _, path := range index {
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			t.Errorf("expect %s to be written got: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+// Package lint checks GraphQL schemas against naming, documentation, deprecation and
+// pagination rules, so schema conventions can be enforced in CI.
+package lint
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/zucchinho/gqlfetch"
+)
+
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Info    Severity = "info"
+	// Off disables a rule.
+	Off Severity = "off"
+)
+
+// Finding is a violation of a rule.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	// Path locates the element, e.g. "User", "User.name", "User.friends(first)" or
+	// "Role.ADMIN".
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %s: %s (%s)", f.Severity, f.Path, f.Message, f.Rule)
+}
+
+// HasErrors reports whether any of the findings has the Error severity.
+func HasErrors(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Rule checks a schema, calling report for every violation.
+type Rule struct {
+	Name        string
+	Description string
+	Severity    Severity
+	Check       func(schema *ast.Schema, report func(path, message string))
+}
+
+// Config selects the rules to run.
+type Config struct {
+	// Rules defaults to DefaultRules.
+	Rules []Rule
+	// Severities overrides the severity of rules by name, Off disabling them.
+	Severities map[string]Severity
+}
+
+// DefaultRules returns the built-in rules.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "type-names", Description: "type names are PascalCase", Severity: Error, Check: checkTypeNames},
+		{Name: "field-names", Description: "field and argument names are camelCase", Severity: Error, Check: checkFieldNames},
+		{Name: "enum-values", Description: "enum values are UPPER_CASE", Severity: Error, Check: checkEnumValues},
+		{Name: "descriptions", Description: "types and fields have descriptions", Severity: Warning, Check: checkDescriptions},
+		{Name: "deprecation-reasons", Description: "deprecations explain what to use instead", Severity: Warning, Check: checkDeprecationReasons},
+		{Name: "pagination", Description: "connections follow the Relay cursor connections specification", Severity: Warning, Check: checkPagination},
+	}
+}
+
+// Schema runs the rules of config on schema and returns the findings ordered by path.
+func Schema(schema *ast.Schema, config Config) ([]Finding, error) {
+	rules := config.Rules
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	known := map[string]bool{}
+	for _, rule := range rules {
+		known[rule.Name] = true
+	}
+	for name, severity := range config.Severities {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown lint rule %q", name)
+		}
+		switch severity {
+		case Error, Warning, Info, Off:
+		default:
+			return nil, fmt.Errorf("invalid severity %q for lint rule %s, expected error, warning, info or off", severity, name)
+		}
+	}
+
+	var findings []Finding
+	for _, rule := range rules {
+		severity := rule.Severity
+		if override, ok := config.Severities[rule.Name]; ok {
+			severity = override
+		}
+		if severity == Off {
+			continue
+		}
+		name := rule.Name
+		rule.Check(schema, func(path, message string) {
+			findings = append(findings, Finding{Rule: name, Severity: severity, Path: path, Message: message})
+		})
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Path < findings[j].Path })
+	return findings, nil
+}
+
+// Endpoint fetches the schema described by options and lints it.
+func Endpoint(ctx context.Context, options gqlfetch.BuildClientSchemaOptions, config Config) ([]Finding, error) {
+	schema, err := gqlfetch.BuildClientSchemaAST(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("fetch schema: %w", err)
+	}
+	return Schema(schema, config)
+}
+
+var (
+	pascalCase = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	camelCase  = regexp.MustCompile(`^[a-z][A-Za-z0-9]*$`)
+	upperCase  = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// userTypes returns the types of schema that aren't built in, sorted by name.
+func userTypes(schema *ast.Schema) []*ast.Definition {
+	var defs []*ast.Definition
+	for name, def := range schema.Types {
+		if !def.BuiltIn && !strings.HasPrefix(name, "__") {
+			defs = append(defs, def)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// userFields returns the fields of def, leaving out introspection fields.
+func userFields(def *ast.Definition) []*ast.FieldDefinition {
+	var fields []*ast.FieldDefinition
+	for _, field := range def.Fields {
+		if !strings.HasPrefix(field.Name, "__") {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func checkTypeNames(schema *ast.Schema, report func(path, message string)) {
+	for _, def := range userTypes(schema) {
+		if !pascalCase.MatchString(def.Name) {
+			report(def.Name, fmt.Sprintf("type name %s should be PascalCase", def.Name))
+		}
+	}
+}
+
+func checkFieldNames(schema *ast.Schema, report func(path, message string)) {
+	for _, def := range userTypes(schema) {
+		for _, field := range userFields(def) {
+			path := def.Name + "." + field.Name
+			if !camelCase.MatchString(field.Name) {
+				report(path, fmt.Sprintf("field name %s should be camelCase", field.Name))
+			}
+			for _, arg := range field.Arguments {
+				if !camelCase.MatchString(arg.Name) {
+					report(path+"("+arg.Name+")", fmt.Sprintf("argument name %s should be camelCase", arg.Name))
+				}
+			}
+		}
+	}
+}
+
+func checkEnumValues(schema *ast.Schema, report func(path, message string)) {
+	for _, def := range userTypes(schema) {
+		for _, value := range def.EnumValues {
+			if !upperCase.MatchString(value.Name) {
+				report(def.Name+"."+value.Name, fmt.Sprintf("enum value %s should be UPPER_CASE", value.Name))
+			}
+		}
+	}
+}
+
+func checkDescriptions(schema *ast.Schema, report func(path, message string)) {
+	roots := map[*ast.Definition]bool{schema.Query: true, schema.Mutation: true, schema.Subscription: true}
+	for _, def := range userTypes(schema) {
+		// Root operation types are described by their fields.
+		if def.Description == "" && !roots[def] {
+			report(def.Name, fmt.Sprintf("%s %s has no description", kindName(def.Kind), def.Name))
+		}
+		for _, field := range userFields(def) {
+			if field.Description == "" {
+				report(def.Name+"."+field.Name, fmt.Sprintf("field %s.%s has no description", def.Name, field.Name))
+			}
+		}
+	}
+}
+
+// defaultDeprecationReason is the reason servers report for a bare @deprecated.
+const defaultDeprecationReason = "No longer supported"
+
+func checkDeprecationReasons(schema *ast.Schema, report func(path, message string)) {
+	check := func(path, noun string, directives ast.DirectiveList) {
+		deprecated := directives.ForName("deprecated")
+		if deprecated == nil {
+			return
+		}
+		reason := deprecated.Arguments.ForName("reason")
+		if reason == nil || reason.Value == nil || strings.TrimSpace(reason.Value.Raw) == "" || reason.Value.Raw == defaultDeprecationReason {
+			report(path, fmt.Sprintf("%s %s is deprecated without a reason", noun, path))
+		}
+	}
+	for _, def := range userTypes(schema) {
+		for _, field := range userFields(def) {
+			path := def.Name + "." + field.Name
+			check(path, "field", field.Directives)
+			for _, arg := range field.Arguments {
+				check(path+"("+arg.Name+")", "argument", arg.Directives)
+			}
+		}
+		for _, value := range def.EnumValues {
+			check(def.Name+"."+value.Name, "enum value", value.Directives)
+		}
+	}
+}
+
+// checkPagination checks that types named like connections have edges and pageInfo
+// fields, and that fields returning connections take first and after, or last and
+// before, arguments.
+func checkPagination(schema *ast.Schema, report func(path, message string)) {
+	isConnection := func(name string) bool {
+		def := schema.Types[name]
+		return def != nil && def.Kind == ast.Object && strings.HasSuffix(name, "Connection")
+	}
+	for _, def := range userTypes(schema) {
+		if isConnection(def.Name) {
+			if def.Fields.ForName("edges") == nil {
+				report(def.Name, fmt.Sprintf("connection %s has no edges field", def.Name))
+			}
+			if pageInfo := def.Fields.ForName("pageInfo"); pageInfo == nil {
+				report(def.Name, fmt.Sprintf("connection %s has no pageInfo field", def.Name))
+			} else if !pageInfo.Type.NonNull || pageInfo.Type.Elem != nil {
+				report(def.Name+".pageInfo", fmt.Sprintf("%s.pageInfo should be non-null", def.Name))
+			}
+		}
+		for _, field := range userFields(def) {
+			if field.Type.Elem != nil || !isConnection(field.Type.Name()) {
+				continue
+			}
+			forward := field.Arguments.ForName("first") != nil && field.Arguments.ForName("after") != nil
+			backward := field.Arguments.ForName("last") != nil && field.Arguments.ForName("before") != nil
+			if !forward && !backward {
+				report(def.Name+"."+field.Name, fmt.Sprintf("field %s.%s returns a connection without first and after, or last and before, arguments", def.Name, field.Name))
+			}
+		}
+	}
+}
+
+func kindName(kind ast.DefinitionKind) string {
+	return strings.ToLower(strings.ReplaceAll(string(kind), "_", " "))
+}
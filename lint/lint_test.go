@@ -0,0 +1,118 @@
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/zucchinho/gqlfetch"
+)
+
+const testSchema = `
+type Query {
+	"Finds a user."
+	user(user_id: ID!): user_account
+	"Lists users."
+	users(first: Int, after: String): UserConnection!
+	"Lists groups."
+	groups: GroupConnection
+}
+
+"A registered user."
+type user_account {
+	"Identifies the user."
+	id: ID!
+	"Legacy name."
+	Name: String @deprecated
+	"Current role."
+	role: Role
+}
+
+"The role of a user."
+enum Role {
+	ADMIN
+	regularMember
+}
+
+"A page of users."
+type UserConnection {
+	"The users of the page."
+	edges: [UserEdge!]!
+	"Where the page ends."
+	pageInfo: PageInfo!
+}
+
+"A user of a page."
+type UserEdge {
+	"The user."
+	node: user_account
+	"Cursor of the user."
+	cursor: String!
+}
+
+"A page of groups."
+type GroupConnection {
+	"The groups of the page."
+	nodes: [String!]!
+}
+
+"Pagination state."
+type PageInfo {
+	"Whether more users follow."
+	hasNextPage: Boolean!
+	"Cursor of the last user."
+	endCursor: String
+}
+`
+
+func TestSchema(t *testing.T) {
+	schema, err := gqlfetch.LoadSchema(&ast.Source{Input: testSchema})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		config    Config
+		expect    []Finding
+		expectErr bool
+	}{
+		"default rules": {
+			expect: []Finding{
+				{Rule: "pagination", Severity: Warning, Path: "GroupConnection", Message: "connection GroupConnection has no edges field"},
+				{Rule: "pagination", Severity: Warning, Path: "GroupConnection", Message: "connection GroupConnection has no pageInfo field"},
+				{Rule: "pagination", Severity: Warning, Path: "Query.groups", Message: "field Query.groups returns a connection without first and after, or last and before, arguments"},
+				{Rule: "field-names", Severity: Error, Path: "Query.user(user_id)", Message: "argument name user_id should be camelCase"},
+				{Rule: "enum-values", Severity: Error, Path: "Role.regularMember", Message: "enum value regularMember should be UPPER_CASE"},
+				{Rule: "type-names", Severity: Error, Path: "user_account", Message: "type name user_account should be PascalCase"},
+				{Rule: "field-names", Severity: Error, Path: "user_account.Name", Message: "field name Name should be camelCase"},
+				{Rule: "deprecation-reasons", Severity: Warning, Path: "user_account.Name", Message: "field user_account.Name is deprecated without a reason"},
+			},
+		},
+		"overridden severities": {
+			config: Config{Severities: map[string]Severity{"type-names": Off, "field-names": Off, "pagination": Off, "enum-values": Info}},
+			expect: []Finding{
+				{Rule: "enum-values", Severity: Info, Path: "Role.regularMember", Message: "enum value regularMember should be UPPER_CASE"},
+				{Rule: "deprecation-reasons", Severity: Warning, Path: "user_account.Name", Message: "field user_account.Name is deprecated without a reason"},
+			},
+		},
+		"unknown rule": {
+			config:    Config{Severities: map[string]Severity{"naming": Off}},
+			expectErr: true,
+		},
+		"invalid severity": {
+			config:    Config{Severities: map[string]Severity{"descriptions": "fatal"}},
+			expectErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			findings, err := Schema(schema, tt.config)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if !reflect.DeepEqual(findings, tt.expect) {
+				t.Errorf("expect findings:\n%v\ngot:\n%v", tt.expect, findings)
+			}
+		})
+	}
+}
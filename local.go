@@ -0,0 +1,39 @@
+package gqlfetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// StdinEndpoint reads a captured introspection response from standard input.
+const StdinEndpoint = "-"
+
+// fileEndpointPrefix marks endpoints that read a captured introspection response from
+// a file, such as file:///abs/introspection.json or file://relative/introspection.json.
+const fileEndpointPrefix = "file://"
+
+// stdin is replaced by tests.
+var stdin io.Reader = os.Stdin
+
+func isLocalEndpoint(endpoint string) bool {
+	return endpoint == StdinEndpoint || strings.HasPrefix(endpoint, fileEndpointPrefix)
+}
+
+// readLocalIntrospection decodes the introspection response captured at a local
+// endpoint, as accepted by BuildSchemaFromIntrospectionJSON.
+func readLocalIntrospection(ctx context.Context, endpoint string) (introspectionSchema, error) {
+	if endpoint == StdinEndpoint {
+		return decodeIntrospection(ctx, stdin)
+	}
+
+	path := strings.TrimPrefix(endpoint, fileEndpointPrefix)
+	file, err := os.Open(path)
+	if err != nil {
+		return introspectionSchema{}, fmt.Errorf("failed to open introspection file: %w", err)
+	}
+	defer file.Close()
+	return decodeIntrospection(ctx, file)
+}
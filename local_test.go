@@ -0,0 +1,48 @@
+package gqlfetch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildClientSchemaWithOptions_LocalEndpoint(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	absolute, err := filepath.Abs("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("resolve fixture: %v", err)
+	}
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+	expected, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer func(original io.Reader) { stdin = original }(stdin)
+	stdin = bytes.NewReader(fixture)
+
+	tests := map[string]struct {
+		endpoint string
+	}{
+		"relative file": {endpoint: "file://testdata/introspection.json"},
+		"absolute file": {endpoint: "file://" + absolute},
+		"stdin":         {endpoint: StdinEndpoint},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{Endpoint: tt.endpoint})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if schema != expected {
+				t.Errorf("expect the local schema to match the fetched one got:\n%v", schema)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package gqlfetch
+
+// Logger receives debug logs about requests, retries, cache hits and the fetched schema
+// as a message followed by alternating keys and values. A *slog.Logger satisfies it.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+}
+
+func (options BuildClientSchemaOptions) debug(msg string, args ...interface{}) {
+	if options.Logger != nil {
+		options.Logger.Debug(msg, args...)
+	}
+}
@@ -0,0 +1,63 @@
+package gqlfetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) {
+	l.messages = append(l.messages, msg)
+}
+
+func TestBuildClientSchemaWithOptions_Logger(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	options := BuildClientSchemaOptions{
+		Endpoint:    server.URL,
+		RetryPolicy: &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+		Cache:       FileCache{Dir: t.TempDir()},
+		CacheTTL:    time.Hour,
+		Logger:      logger,
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := BuildClientSchemaWithOptions(context.Background(), options); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	expect := []string{
+		"sending introspection request",
+		"retrying introspection request",
+		"sending introspection request",
+		"received introspection response",
+		"fetched schema",
+		"serving cached schema",
+	}
+	if got := strings.Join(logger.messages, "\n"); got != strings.Join(expect, "\n") {
+		t.Errorf("expect logs:\n%v\ngot:\n%v", strings.Join(expect, "\n"), got)
+	}
+}
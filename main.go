@@ -1,29 +1,273 @@
 package gqlfetch
 
 import (
-	"bytes"
 	"context"
-	_ "embed"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/vektah/gqlparser/ast"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/zucchinho/gqlfetch/internal/blockstring"
 )
 
-//go:embed introspect.graphql
-var introspectSchema string
-
 type BuildClientSchemaOptions struct {
-	Endpoint        string
+	// Preset fills in the endpoint, headers and quirks of a well-known API, such as
+	// "github", "gitlab" or "shopify", or one added with RegisterPreset. Explicitly set
+	// options take precedence.
+	Preset string
+
+	// Endpoint is the URL of the GraphQL server, ws:// and wss:// URLs being queried
+	// over GraphQL over WebSocket, and unix:// URLs such as
+	// unix:///var/run/app.sock:/graphql over the unix domain socket before the HTTP
+	// path, without HTTPClient. A file:// URL or "-" read a previously captured introspection response from
+	// a file or standard input instead.
+	Endpoint string
+	// Method is the HTTP method of the introspection request, POST when empty. GET
+	// requests carry the query as URL parameters instead of a JSON body.
 	Method          string
 	Headers         http.Header
 	WithoutBuiltins bool
+	// KeepDirectives and KeepScalars name the built-in directives, such as "specifiedBy",
+	// and scalars, such as "ID", printed even though WithoutBuiltins is set. Keeping
+	// every built-in directive leaves out only the scalars, and vice versa.
+	KeepDirectives []string
+	KeepScalars    []string
+	// Auth authenticates every request, after Headers are applied.
+	Auth Auth
+
+	// InputValueDeprecation requests deprecation details for arguments and input fields,
+	// which is only understood by servers implementing the October 2021 specification.
+	InputValueDeprecation bool
+	// DirectiveIsRepeatable requests whether directives are repeatable, which is only
+	// understood by servers implementing the October 2021 specification.
+	DirectiveIsRepeatable bool
+	// SpecifiedByURL requests the specification URL of custom scalars, printed as
+	// @specifiedBy, which is only understood by servers implementing the October 2021
+	// specification.
+	SpecifiedByURL bool
+	// OneOf requests whether input objects are oneOf input objects, printed as
+	// `input Filter @oneOf`. It is part of a specification draft.
+	OneOf bool
+	// AppliedDirectives requests the directives applied to types, fields, arguments,
+	// input fields and enum values through the appliedDirectives introspection extension
+	// of graphql-java and Hot Chocolate, and prints them. Applied directives the schema
+	// doesn't define are left out.
+	AppliedDirectives bool
+
+	// WithoutDescriptions neither requests nor prints descriptions, which keeps
+	// generated schemas small and their diffs focused.
+	WithoutDescriptions bool
+
+	// Lenient leaves out the types of kinds gqlfetch doesn't know, such as the ones of
+	// server extensions, instead of failing the fetch. Fields, arguments and input fields
+	// referring to them are left out too. Every type left out is reported to OnWarning,
+	// and printed as a comment by the built-in printer.
+	Lenient bool
+	// OnWarning is called for every part of the introspection result changed or left
+	// out to print it, such as control characters removed from descriptions, unknown
+	// directive locations or the types a Lenient fetch leaves out.
+	OnWarning func(Warning)
+
+	// SkipValidation returns the printed SDL without first checking that it loads with
+	// LoadSchema. Schemas returned verbatim, such as federation SDL, are never validated.
+	SkipValidation bool
+
+	// SDLFormat controls the indentation and line wrapping of the printed SDL.
+	SDLFormat SDLFormat
+	// GQLParserFormatter prints the SDL with gqlparser's formatter instead of the
+	// built-in printer, after building and validating the schema as BuildClientSchemaAST
+	// does, so the output is guaranteed to load. Built-ins are always left out and
+	// SDLFormat doesn't apply. Only BuildClientSchemaWithOptions honours this option.
+	GQLParserFormatter bool
+
+	// SortSchema sorts types, fields, enum values, directives, interfaces and union
+	// members by name, so the output doesn't depend on the server's ordering.
+	SortSchema bool
+
+	// NegotiateQuery retries introspection queries rejected with GraphQL errors using
+	// progressively smaller queries: without the October 2021 additions, then without
+	// descriptions, then without directives.
+	NegotiateQuery bool
+
+	// IncludeTypes and ExcludeTypes are path.Match patterns, such as "*Connection",
+	// selecting the types kept in the schema. When IncludeTypes is set only the matching
+	// types, root operation types and built-ins are kept. ExcludeTypes then removes the
+	// matching types. Fields, arguments, interfaces and union members referring to a
+	// removed type are removed too, along with the types they leave empty.
+	IncludeTypes []string
+	ExcludeTypes []string
+	// PruneUnreachable removes the types that can't be reached from the root operation
+	// types, such as orphans left by server frameworks, after applying IncludeTypes and
+	// ExcludeTypes. Implementations of reachable interfaces are kept.
+	PruneUnreachable bool
+
+	// TypePrefix prefixes the name of every type, such as Billing_ turning User into
+	// Billing_User, and the references to them, so the schemas of several services can
+	// be stitched by hand without conflicts. Root operation types and built-ins keep
+	// their names, letting MergeSchemas with MergeMembers combine the root fields.
+	// IncludeTypes and ExcludeTypes match the names without the prefix.
+	TypePrefix string
+
+	// WithoutDeprecated leaves deprecated fields and enum values out of the schema by
+	// sending includeDeprecated: false, and deprecated arguments and input fields too
+	// with InputValueDeprecation.
+	WithoutDeprecated bool
+
+	// Variables and Extensions are sent with every GraphQL request, for gateways
+	// expecting values such as tenant IDs or tracing context alongside the query.
+	Variables  map[string]interface{}
+	Extensions map[string]interface{}
+
+	// PersistedQueries sends the introspection request as an automatic persisted
+	// query, for gateways accepting nothing else: the SHA-256 hash of the query is sent
+	// first, and the full query only when the server reports PersistedQueryNotFound.
+	PersistedQueries bool
+
+	// IntrospectionQuery replaces the embedded introspection query, for servers with
+	// vendor-specific extensions. It must select __schema with at least the fields of
+	// the embedded query and is sent without an operation name, so it should contain a
+	// single operation. NegotiateQuery and the options shaping the embedded query, such
+	// as InputValueDeprecation, don't apply to it.
+	IntrospectionQuery string
+
+	// OutputFormat selects what BuildClientSchemaWithOptions returns, SDL by default.
+	OutputFormat OutputFormat
+
+	// OutputLayout selects how BuildClientSchemaToDir splits the schema into files.
+	OutputLayout OutputLayout
+
+	// Source selects where the schema is read from, introspection of Endpoint by
+	// default. Only BuildClientSchemaWithOptions honours this option.
+	Source SchemaSource
+
+	// FederationSDL fetches the SDL of Apollo Federation subgraphs through
+	// `_service { sdl }`, which unlike introspection keeps directives such as @key and
+	// @external. Endpoints without _service fall back to introspection. Only
+	// BuildClientSchemaWithOptions honours this option.
+	FederationSDL bool
+
+	// RetryPolicy retries the introspection request on transient failures. When nil the
+	// request is attempted once.
+	RetryPolicy *RetryPolicy
+
+	// IgnoreRetryAfter fails 429 and 503 responses asking to wait with Retry-After or
+	// X-RateLimit-Reset, instead of waiting and retrying them when the context allows.
+	IgnoreRetryAfter bool
+
+	// RateLimit bounds the rate of requests, and can be shared by the options of
+	// several fetches. When nil requests are sent as fast as needed.
+	RateLimit *RateLimit
+
+	// Timeout bounds the whole fetch, including retries. When zero, the deadline of the
+	// context is used, falling back to DefaultTimeout if the context has none. A negative
+	// Timeout disables the fallback, leaving the context as the only bound.
+	Timeout time.Duration
+
+	// HTTPClient is used to perform the introspection request, allowing callers to
+	// configure proxies, TLS, tracing middleware or connection pooling. When nil
+	// http.DefaultTransport is used.
+	HTTPClient *http.Client
+
+	// CookieJar stores the cookies of the HTTP responses and sends them with later
+	// requests, for endpoints protected by session cookies. It can't be combined with
+	// HTTPClient, whose own Jar is used instead.
+	CookieJar http.CookieJar
+	// Login is called with the HTTP client of the introspection request before it is
+	// sent, to log in and obtain session cookies. A cookie jar is created when neither
+	// CookieJar nor HTTPClient is set.
+	Login func(ctx context.Context, client *http.Client) error
+
+	// TLS configures client certificates and trusted CAs without building an
+	// HTTPClient. It can't be combined with HTTPClient and is ignored when Transport is
+	// set. The files are read once, by the first request with these options.
+	TLS *TLSOptions
+
+	// Connection configures the HTTP version, timeouts and keep-alives of the
+	// connections to the endpoint. It can't be combined with HTTPClient and is ignored
+	// when Transport is set, and for WebSocket endpoints.
+	Connection *ConnectionOptions
+
+	// ProxyURL is the http, https or socks5 proxy used for the endpoint, overriding the
+	// HTTP_PROXY and HTTPS_PROXY environment variables. WebSocket endpoints are tunneled
+	// through http and https proxies only. It can't be combined with HTTPClient and is
+	// ignored when Transport is set, and for unix:// endpoints.
+	ProxyURL string
+
+	// Transport sends the introspection request instead of HTTPClient, for endpoints
+	// reached through Lambda invocations or in-process handlers. It isn't used for
+	// WebSocket endpoints or to fetch FallbackSchemaURL.
+	Transport Transport
+	// Middleware wraps the transport of every request, the first middleware being the
+	// outermost one, so it sees requests first and responses last.
+	Middleware []Middleware
+
+	// FallbackSchemaURL is fetched with a GET request when the endpoint reports that
+	// introspection is disabled, and must serve the schema as SDL, which is returned
+	// verbatim. Headers and Auth aren't sent to it. Only used with OutputSDL.
+	FallbackSchemaURL string
+
+	// Cache stores fetched schemas. A cached schema younger than CacheTTL is returned
+	// without fetching, an older one is revalidated with If-None-Match when the endpoint
+	// sent an ETag, and re-fetched otherwise.
+	Cache    SchemaCache
+	CacheTTL time.Duration
+
+	// OnResponse is called for every HTTP response received, including the ones that
+	// are retried, which helps diagnosing endpoints that don't answer with GraphQL.
+	OnResponse func(ResponseInfo)
+
+	// MaxResponseBytes fails the fetch with ErrResponseTooLarge once the response body
+	// exceeds this many bytes. Zero means no limit.
+	MaxResponseBytes int64
+
+	// Logger receives debug logs, nothing is logged when nil.
+	Logger Logger
+
+	// Tracer traces the fetch, nothing is traced when nil.
+	Tracer Tracer
+
+	// Metrics records fetches, errors, retries, durations and schema sizes.
+	Metrics Metrics
 }
 
+// OutputFormat is the format of the schema returned by BuildClientSchemaWithOptions.
+type OutputFormat int
+
+const (
+	// OutputSDL prints the schema as GraphQL SDL.
+	OutputSDL OutputFormat = iota
+	// OutputIntrospectionJSON encodes the normalized introspection result as
+	// {"__schema": ...}, the shape consumed by graphql-js' buildClientSchema and GraphQL
+	// Code Generator. Built-ins are always kept, as consumers expect a complete result.
+	OutputIntrospectionJSON
+	// OutputVoyagerJSON encodes the normalized introspection result as a GraphQL
+	// response, {"data": {"__schema": ...}}, the shape GraphQL Voyager consumes.
+	OutputVoyagerJSON
+)
+
+// SchemaSource is where BuildClientSchemaWithOptions reads the schema from.
+type SchemaSource int
+
+const (
+	// SourceIntrospection introspects Endpoint.
+	SourceIntrospection SchemaSource = iota
+	// SourceCosmoSupergraph reads the composed supergraph SDL from the WunderGraph Cosmo
+	// router execution config served at Endpoint, such as the latest.json of a
+	// federated graph on the Cosmo CDN, or stored in a file:// endpoint. The SDL is
+	// returned verbatim, keeping the federation v2 @link directives, and only with
+	// OutputSDL.
+	SourceCosmoSupergraph
+)
+
+// DefaultTimeout bounds fetches whose context has no deadline and no Timeout is set.
+const DefaultTimeout = 2 * time.Minute
+
 func BuildClientSchema(ctx context.Context, endpoint string, withoutBuiltins bool) (string, error) {
 	return BuildClientSchemaWithOptions(ctx, BuildClientSchemaOptions{
 		Endpoint:        endpoint,
@@ -43,84 +287,352 @@ func BuildClientSchemaWithHeaders(ctx context.Context, endpoint string, headers
 }
 
 func BuildClientSchemaWithOptions(ctx context.Context, options BuildClientSchemaOptions) (string, error) {
-	buffer := new(bytes.Buffer)
-	if err := json.NewEncoder(buffer).Encode(struct {
-		Query string `json:"query"`
-	}{Query: introspectSchema}); err != nil {
-		return "", fmt.Errorf("failed to prepare introspection query request: %w", err)
+	options, err := options.withPreset()
+	if err != nil {
+		return "", err
 	}
+	metrics, start := options.metrics(), time.Now()
+	metrics.IncFetches(options.Endpoint)
+	ctx, span := options.startSpan(ctx, "gqlfetch.fetch")
+	schema, err := buildClientSchema(ctx, options)
+	span.End(err)
 
-	req, err := http.NewRequestWithContext(ctx, options.Method, options.Endpoint, buffer)
+	metrics.ObserveDuration(options.Endpoint, time.Since(start))
 	if err != nil {
-		return "", fmt.Errorf("failed to create query request: %w", err)
+		metrics.IncErrors(options.Endpoint)
+		return "", err
+	}
+	metrics.ObserveSchemaSize(options.Endpoint, len(schema))
+	return schema, nil
+}
+
+func buildClientSchema(ctx context.Context, options BuildClientSchemaOptions) (string, error) {
+	if options.Cache != nil {
+		return buildCachedSchema(ctx, options)
 	}
 
-	// If no headers are provided, create an empty header map, so we can add the content type header
-	if options.Headers == nil {
-		options.Headers = make(http.Header)
+	if options.Source == SourceCosmoSupergraph {
+		return fetchCosmoSupergraph(ctx, options)
 	}
-	req.Header = http.Header(options.Headers)
-	req.Header.Add("Content-Type", "application/json")
 
-	client := http.Client{Timeout: 2 * time.Minute}
-	res, err := client.Do(req)
+	if options.FederationSDL && options.OutputFormat == OutputSDL && !isLocalEndpoint(options.Endpoint) {
+		sdl, err := fetchFederationSDL(ctx, options)
+		if !errors.Is(err, errServiceUnavailable) {
+			return sdl, err
+		}
+	}
+
+	schema, err := fetchIntrospection(ctx, options)
+	if errors.Is(err, ErrIntrospectionDisabled) && options.FallbackSchemaURL != "" && options.OutputFormat == OutputSDL {
+		return fetchFallbackSchema(ctx, options)
+	}
 	if err != nil {
 		return "", err
 	}
-	defer res.Body.Close()
 
-	var schemaResponse introspectionResults
-	err = json.NewDecoder(res.Body).Decode(&schemaResponse)
+	return printClientSchema(ctx, schema, options)
+}
+
+// printClientSchema prints schema in options.OutputFormat.
+func printClientSchema(ctx context.Context, schema introspectionSchema, options BuildClientSchemaOptions) (string, error) {
+	switch options.OutputFormat {
+	case OutputIntrospectionJSON:
+		return marshalIntrospection(introspectionData{Schema: schema})
+	case OutputVoyagerJSON:
+		return marshalIntrospection(struct {
+			Data introspectionData `json:"data"`
+		}{Data: introspectionData{Schema: schema}})
+	}
+
+	_, span := options.startSpan(ctx, "gqlfetch.print")
+	span.SetAttribute("gqlfetch.schema.types", len(schema.Types))
+	var sdl string
+	var err error
+	if options.GQLParserFormatter {
+		sdl, err = formatSchema(schema)
+	} else {
+		sdl, err = printSchema(ctx, schema, builtinFilterOf(options), options.SDLFormat)
+		if err == nil && !options.SkipValidation {
+			err = validateSDL(sdl)
+		}
+	}
+	span.End(err)
 	if err != nil {
 		return "", err
 	}
+	return sdl, nil
+}
+
+// introspectionData is the data of an introspection response.
+type introspectionData struct {
+	Schema introspectionSchema `json:"__schema"`
+}
+
+func marshalIntrospection(result interface{}) (string, error) {
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode introspection result: %w", err)
+	}
+	return string(out) + "\n", nil
+}
 
-	if len(schemaResponse.Errors) != 0 {
-		var errs []string
-		for _, err := range schemaResponse.Errors {
-			errs = append(errs, err.Message)
+// BuildClientSchemaAST introspects the endpoint described by options and returns the
+// resulting schema as a gqlparser *ast.Schema, without printing and re-parsing SDL.
+func BuildClientSchemaAST(ctx context.Context, options BuildClientSchemaOptions) (*ast.Schema, error) {
+	options, err := options.withPreset()
+	if err != nil {
+		return nil, err
+	}
+	schema, err := fetchIntrospection(ctx, options)
+	if errors.Is(err, ErrIntrospectionDisabled) && options.FallbackSchemaURL != "" {
+		sdl, err := fetchFallbackSchema(ctx, options)
+		if err != nil {
+			return nil, err
 		}
-		return "", errors.New("encountered the following GraphQL errors: " + strings.Join(errs, ","))
+		return LoadSchema(&ast.Source{Name: options.FallbackSchemaURL, Input: sdl})
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return printSchema(schemaResponse.Data.Schema, options.WithoutBuiltins), nil
+	return buildASTSchema(schema)
+}
+
+func fetchIntrospection(ctx context.Context, options BuildClientSchemaOptions) (introspectionSchema, error) {
+	queryOptions := introspectionQueryOptions{
+		InputValueDeprecation: options.InputValueDeprecation,
+		DirectiveIsRepeatable: options.DirectiveIsRepeatable,
+		SpecifiedByURL:        options.SpecifiedByURL,
+		OneOf:                 options.OneOf,
+		AppliedDirectives:     options.AppliedDirectives,
+		WithoutDescriptions:   options.WithoutDescriptions,
+		WithoutDeprecated:     options.WithoutDeprecated,
+	}
+	var schema introspectionSchema
+	var err error
+	if isLocalEndpoint(options.Endpoint) {
+		schema, err = readLocalIntrospection(ctx, options.Endpoint)
+	} else {
+		schema, err = fetchIntrospectionQuery(ctx, options, queryOptions)
+		if options.NegotiateQuery && options.IntrospectionQuery == "" {
+			schema, err = negotiateIntrospectionQuery(ctx, options, queryOptions, schema, err)
+		}
+	}
+	if err == nil {
+		schema, err = transformIntrospection(schema, options)
+	}
+	if err == nil {
+		options.debug("fetched schema", "endpoint", options.Endpoint, "types", len(schema.Types), "directives", len(schema.Directives))
+	}
+	return schema, err
 }
 
-func printSchema(schema introspectionSchema, withoutBuiltins bool) string {
+// transformIntrospection applies the options changing which types and descriptions of
+// a fetched schema are printed, and how they are named and ordered.
+func transformIntrospection(schema introspectionSchema, options BuildClientSchemaOptions) (introspectionSchema, error) {
+	var err error
+	if options.Lenient {
+		schema, err = withoutUnknownKinds(schema, options.warn)
+	}
+	if err == nil && options.WithoutDescriptions {
+		schema, err = withoutDescriptions(schema)
+	}
+	if err == nil {
+		schema, err = withPrintableSchema(schema, options.warn)
+	}
+	if err == nil && (len(options.IncludeTypes) > 0 || len(options.ExcludeTypes) > 0) {
+		schema, err = filterTypes(schema, options.IncludeTypes, options.ExcludeTypes)
+	}
+	if err == nil && options.PruneUnreachable {
+		schema, err = withoutUnreachableTypes(schema)
+	}
+	if err == nil && options.TypePrefix != "" {
+		schema, err = withTypePrefix(schema, options.TypePrefix)
+	}
+	if err == nil && options.SortSchema {
+		schema, err = sortSchema(schema)
+	}
+	return schema, err
+}
+
+func negotiateIntrospectionQuery(ctx context.Context, options BuildClientSchemaOptions, queryOptions introspectionQueryOptions, schema introspectionSchema, err error) (introspectionSchema, error) {
+
+	for _, reduce := range introspectionQueryReductions {
+		var gqlErrs GraphQLErrors
+		if err == nil || !errors.As(err, &gqlErrs) || errors.Is(err, ErrIntrospectionDisabled) {
+			break
+		}
+		reduced := reduce(queryOptions)
+		if reduced == queryOptions {
+			continue
+		}
+		queryOptions = reduced
+		schema, err = fetchIntrospectionQuery(ctx, options, queryOptions)
+	}
+	return schema, err
+}
+
+// introspectionQueryReductions are applied in order by NegotiateQuery, each one leaving
+// out more of the introspection query.
+var introspectionQueryReductions = []func(introspectionQueryOptions) introspectionQueryOptions{
+	func(o introspectionQueryOptions) introspectionQueryOptions {
+		o.InputValueDeprecation, o.DirectiveIsRepeatable, o.SpecifiedByURL, o.OneOf, o.AppliedDirectives = false, false, false, false, false
+		return o
+	},
+	func(o introspectionQueryOptions) introspectionQueryOptions {
+		o.WithoutDescriptions = true
+		return o
+	},
+	func(o introspectionQueryOptions) introspectionQueryOptions {
+		o.WithoutDirectives = true
+		return o
+	},
+}
+
+func fetchIntrospectionQuery(ctx context.Context, options BuildClientSchemaOptions, queryOptions introspectionQueryOptions) (introspectionSchema, error) {
+	params := requestParams{Query: options.IntrospectionQuery}
+	if params.Query == "" {
+		query, err := introspectionQuery(queryOptions)
+		if err != nil {
+			return introspectionSchema{}, err
+		}
+		params = requestParams{Query: query, OperationName: introspectionOperationName}
+	}
+
+	var schema introspectionSchema
+	err := execute(ctx, options, params, func(body io.Reader) (err error) {
+		schema, err = decodeIntrospection(ctx, body)
+		return err
+	})
+	return schema, err
+}
+
+// BuildSchemaFromIntrospectionJSON prints the schema described by a previously captured
+// introspection response without making any network call, the way
+// BuildClientSchemaWithOptions prints a fetched one, the options describing the
+// request being ignored. Both full responses ({"data": {"__schema": ...}}) and bare
+// exports ({"__schema": ...}) are accepted.
+func BuildSchemaFromIntrospectionJSON(reader io.Reader, options BuildClientSchemaOptions) (string, error) {
+	ctx := context.Background()
+	schema, err := decodeIntrospection(ctx, reader)
+	if err != nil {
+		return "", err
+	}
+	if schema, err = transformIntrospection(schema, options); err != nil {
+		return "", err
+	}
+	return printClientSchema(ctx, schema, options)
+}
+
+// PrintError is returned when part of the introspection result can't be printed as SDL.
+type PrintError struct {
+	// Path is the type or "@directive" that failed to print.
+	Path string
+	Err  error
+}
+
+func (e *PrintError) Error() string {
+	return fmt.Sprintf("failed to print %s: %v", e.Path, e.Err)
+}
+
+func (e *PrintError) Unwrap() error { return e.Err }
+
+// printSchema prints schema as SDL, stopping between types once ctx is done.
+func printSchema(ctx context.Context, schema introspectionSchema, builtins builtinFilter, format SDLFormat) (string, error) {
+	schema, err := withDefinedAppliedDirectives(schema)
+	if err != nil {
+		return "", err
+	}
 	sb := &strings.Builder{}
+	sb.Grow(estimateSize(schema.Types))
 
-	printDirectives(sb, schema.Directives, withoutBuiltins)
-	printTypes(sb, schema.Types, withoutBuiltins)
+	printSchemaDefinition(sb, schema, format)
+	if err := printDirectives(sb, schema.Directives, builtins, format); err != nil {
+		return "", err
+	}
+	printWarnings(sb, schema.warnings)
+	if err := printTypes(ctx, sb, schema.Types, builtins, format); err != nil {
+		return "", err
+	}
 
-	return sb.String()
+	return sb.String(), nil
 }
 
-func printDirectives(sb *strings.Builder, directives []introspectionDirectiveDefinition, withoutBuiltins bool) error {
+// printSchemaDefinition prints the schema root operation block, which is only required
+// when the root operation types don't follow the default Query/Mutation/Subscription
+// naming, or when a non-root type uses one of those default names and would otherwise
+// be mistaken for a root.
+func printSchemaDefinition(sb *strings.Builder, schema introspectionSchema, format SDLFormat) {
+	roots := []struct {
+		operation ast.Operation
+		name      string
+		common    string
+	}{
+		{ast.Query, schema.QueryType.Name, "Query"},
+		{ast.Mutation, schema.MutationType.Name, "Mutation"},
+		{ast.Subscription, schema.SubscriptionType.Name, "Subscription"},
+	}
+
+	common := true
+	for _, root := range roots {
+		if root.name != "" && root.name != root.common {
+			common = false
+		}
+		if root.name == "" && hasType(schema.Types, root.common) {
+			common = false
+		}
+	}
+	if common {
+		return
+	}
+
+	sb.WriteString("schema {\n")
+	for _, root := range roots {
+		if root.name != "" {
+			sb.WriteString(format.indent(1))
+			sb.WriteString(string(root.operation))
+			sb.WriteString(": ")
+			sb.WriteString(root.name)
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString("}\n\n")
+}
+
+func hasType(types []introspectionTypeDefinition, name string) bool {
+	for _, typ := range types {
+		if typ.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func printDirectives(sb *strings.Builder, directives []introspectionDirectiveDefinition, builtins builtinFilter, format SDLFormat) error {
 	for _, directive := range directives {
-		if withoutBuiltins && containsStr(directive.Name, excludeDirectives) {
+		if builtins.skipDirective(directive.Name) {
 			continue
 		}
 		printDescription(sb, directive.Description)
-		sb.WriteString(fmt.Sprintf("directive @%s", directive.Name))
-		if len(directive.Args) > 0 {
-			sb.WriteString("(\n")
-			for _, arg := range directive.Args {
-				printDescription(sb, arg.Description)
-				astType, err := introspectionTypeToAstType(arg.Type)
-				if err != nil {
-					return fmt.Errorf("convert introspection type to AST type: %w\n%v", err, arg.Type)
-				}
-				sb.WriteString(fmt.Sprintf("\t%s: %s\n", arg.Name, astType.String()))
-			}
-			sb.WriteString(")")
+		head := "directive @" + directive.Name
+		tail := &strings.Builder{}
+		if directive.IsRepeatable {
+			tail.WriteString(" repeatable")
 		}
 
-		sb.WriteString(" on ")
+		tail.WriteString(" on ")
 		for i, location := range directive.Locations {
-			sb.WriteString(string(location))
+			tail.WriteString(string(location))
 			if i < len(directive.Locations)-1 {
-				sb.WriteString(" | ")
+				tail.WriteString(" | ")
+			}
+		}
+		if len(directive.Args) > 0 {
+			if err := printArguments(sb, directive.Args, head, tail.String(), 0, format, ""); err != nil {
+				return &PrintError{Path: "@" + directive.Name, Err: err}
 			}
+		} else {
+			sb.WriteString(head + tail.String())
 		}
 		sb.WriteString("\n")
 		sb.WriteString("\n")
@@ -129,104 +641,190 @@ func printDirectives(sb *strings.Builder, directives []introspectionDirectiveDef
 	return nil
 }
 
-func printTypes(sb *strings.Builder, types []introspectionTypeDefinition, withoutBuiltins bool) error {
+// parallelPrintThreshold is the number of types from which printTypes prints them
+// concurrently. Below it, printing is faster than starting the goroutines.
+var parallelPrintThreshold = 2000
+
+// printTypes prints the types in order. Large schemas are split into a contiguous chunk
+// of types per CPU, printed concurrently into their own buffers and concatenated, so
+// the output doesn't depend on scheduling. The error of the first failing type is
+// returned.
+func printTypes(ctx context.Context, sb *strings.Builder, types []introspectionTypeDefinition, builtins builtinFilter, format SDLFormat) error {
+	workers := runtime.GOMAXPROCS(0)
+	if len(types) < parallelPrintThreshold || workers == 1 {
+		return printTypeRange(ctx, sb, types, builtins, format)
+	}
+
+	size := (len(types) + workers - 1) / workers
+	chunks := make([]strings.Builder, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i*size < len(types); i++ {
+		end := (i + 1) * size
+		if end > len(types) {
+			end = len(types)
+		}
+		wg.Add(1)
+		go func(i int, types []introspectionTypeDefinition) {
+			defer wg.Done()
+			chunks[i].Grow(estimateSize(types))
+			errs[i] = printTypeRange(ctx, &chunks[i], types, builtins, format)
+		}(i, types[i*size:end])
+	}
+	wg.Wait()
+
+	for i := range chunks {
+		if errs[i] != nil {
+			return errs[i]
+		}
+	}
+	length := 0
+	for i := range chunks {
+		length += chunks[i].Len()
+	}
+	sb.Grow(length)
+	for i := range chunks {
+		sb.WriteString(chunks[i].String())
+	}
+	return nil
+}
+
+// estimateSize estimates the length of the SDL of types, to size builders up front.
+func estimateSize(types []introspectionTypeDefinition) int {
+	size := 0
+	for _, typ := range types {
+		size += 32 + len(typ.Description) + len(typ.EnumValues)/2
+		for _, field := range typ.Fields {
+			size += 32 + len(field.Description) + 32*len(field.Args)
+		}
+		size += 32 * len(typ.InputFields)
+	}
+	return size
+}
+
+func printTypeRange(ctx context.Context, sb *strings.Builder, types []introspectionTypeDefinition, builtins builtinFilter, format SDLFormat) error {
 	for _, typ := range types {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if strings.HasPrefix(typ.Name, "__") {
 			continue
 		}
-		if withoutBuiltins && containsStr(typ.Name, excludeScalarTypes) && typ.Kind == ast.Scalar {
+		if builtins.skipType(typ) {
 			continue
 		}
 		printDescription(sb, typ.Description)
+		if err := printType(sb, typ, format); err != nil {
+			return &PrintError{Path: typ.Name, Err: err}
+		}
+		sb.WriteString("\n")
+		sb.WriteString("\n")
+	}
 
-		switch typ.Kind {
-
-		case ast.Object:
-			sb.WriteString(fmt.Sprintf("type %s ", typ.Name))
-			if len(typ.Interfaces) > 0 {
-				sb.WriteString("implements ")
-				for i, intface := range typ.Interfaces {
-					sb.WriteString(intface.Name)
-					if i < len(typ.Interfaces)-1 {
-						sb.WriteString(" & ")
-					}
-				}
-			}
-			sb.WriteString("{\n")
-			for _, field := range typ.Fields {
-				printDescription(sb, field.Description)
-				sb.WriteString(fmt.Sprintf("\t%s", field.Name))
-				if len(field.Args) > 0 {
-					sb.WriteString("(\n")
-					for _, arg := range field.Args {
-						printDescription(sb, arg.Description)
-						astType, err := introspectionTypeToAstType(arg.Type)
-						if err != nil {
-							return fmt.Errorf("convert introspection type to AST type: %w\n%v", err, arg.Type)
-						}
-						sb.WriteString(fmt.Sprintf("\t\t%s: %s\n", arg.Name, astType.String()))
-					}
-					sb.WriteString("\t)")
-				}
-				astType, err := introspectionTypeToAstType(field.Type)
-				if err != nil {
-					return fmt.Errorf("convert introspection type to AST type: %w\n%v", err, field.Type)
-				}
-				sb.WriteString(fmt.Sprintf(": %s\n", astType.String()))
-			}
-			sb.WriteString("}")
+	return nil
+}
 
-		case ast.Union:
-			sb.WriteString(fmt.Sprintf("union %s =", typ.Name))
-			var possible []*introspectedType
-			if err := json.Unmarshal(typ.PossibleTypes, &possible); err != nil {
-				return err
-			}
-			for i, typ := range possible {
-				astType, err := introspectionTypeToAstType(typ)
-				if err != nil {
-					return fmt.Errorf("convert introspection type to AST type: %w\n%v", err, typ)
-				}
-				sb.WriteString(astType.String())
-				if i < len(possible)-1 {
-					sb.WriteString(" | ")
-				}
-			}
+func printType(sb *strings.Builder, typ introspectionTypeDefinition, format SDLFormat) error {
+	switch typ.Kind {
 
-		case ast.Enum:
-			sb.WriteString(fmt.Sprintf("enum %s {\n", typ.Name))
-			var enumValues ast.EnumValueList
-			if err := json.Unmarshal(typ.EnumValues, &enumValues); err != nil {
-				return fmt.Errorf("cannot unmarshal enum values: %w\n%v", err, typ.EnumValues)
+	case ast.Object:
+		sb.WriteString("type ")
+		sb.WriteString(typ.Name)
+		sb.WriteString(" ")
+		if err := printImplements(sb, typ); err != nil {
+			return err
+		}
+		printTypeDirectives(sb, typ.AppliedDirectives)
+		sb.WriteString("{\n")
+		for _, field := range typ.Fields {
+			printDescription(sb, field.Description)
+			if err := printField(sb, field, format); err != nil {
+				return err
 			}
-			for _, value := range enumValues {
-				printDescription(sb, value.Description)
-				sb.WriteString(fmt.Sprintf("\t%s\n", value.Name))
+		}
+		sb.WriteString("}")
+
+	case ast.Union:
+		sb.WriteString("union ")
+		sb.WriteString(typ.Name)
+		sb.WriteString(" ")
+		printTypeDirectives(sb, typ.AppliedDirectives)
+		sb.WriteString("=")
+		var possible []*introspectedType
+		if err := json.Unmarshal(typ.PossibleTypes, &possible); err != nil {
+			return fmt.Errorf("cannot unmarshal possible types: %w", err)
+		}
+		for i, typ := range possible {
+			astType, err := introspectionTypeToAstType(typ)
+			if err != nil {
+				return fmt.Errorf("convert possible type: %w", err)
 			}
-			sb.WriteString("}")
-
-		case ast.Scalar:
-			sb.WriteString(fmt.Sprintf("scalar %s", typ.Name))
-
-		case ast.InputObject:
-			sb.WriteString(fmt.Sprintf("input %s {\n", typ.Name))
-			for _, field := range typ.InputFields {
-				printDescription(sb, typ.Description)
-				astType, err := introspectionTypeToAstType(field.Type)
-				if err != nil {
-					return fmt.Errorf("convert introspection type to AST type: %w\n%v", err, field.Type)
-				}
-				sb.WriteString(fmt.Sprintf("\t%s: %s\n", field.Name, astType.String()))
+			sb.WriteString(astType.String())
+			if i < len(possible)-1 {
+				sb.WriteString(" | ")
 			}
-			sb.WriteString("}")
+		}
 
-		case ast.Interface:
-			printInterface(sb, typ)
-		default:
-			return fmt.Errorf(fmt.Sprintf("not handling kind: %v", typ.Kind))
+	case ast.Enum:
+		sb.WriteString("enum ")
+		sb.WriteString(typ.Name)
+		sb.WriteString(" ")
+		printTypeDirectives(sb, typ.AppliedDirectives)
+		sb.WriteString("{\n")
+		var enumValues []introspectionEnumValue
+		if err := json.Unmarshal(typ.EnumValues, &enumValues); err != nil {
+			return fmt.Errorf("cannot unmarshal enum values: %w\n%v", err, typ.EnumValues)
 		}
-		sb.WriteString("\n")
-		sb.WriteString("\n")
+		for _, value := range enumValues {
+			printDescription(sb, value.Description)
+			sb.WriteString(format.indent(1))
+			sb.WriteString(value.Name)
+			printDeprecation(sb, value.IsDeprecated, value.DeprecationReason)
+			printAppliedDirectives(sb, value.AppliedDirectives)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("}")
+
+	case ast.Scalar:
+		sb.WriteString("scalar ")
+		sb.WriteString(typ.Name)
+		if typ.SpecifiedByURL != nil {
+			sb.WriteString(" @specifiedBy(url: ")
+			sb.WriteString(printString(*typ.SpecifiedByURL))
+			sb.WriteString(")")
+		}
+		printAppliedDirectives(sb, typ.AppliedDirectives)
+
+	case ast.InputObject:
+		sb.WriteString("input ")
+		sb.WriteString(typ.Name)
+		sb.WriteString(" ")
+		if typ.IsOneOf {
+			sb.WriteString("@oneOf ")
+		}
+		printTypeDirectives(sb, typ.AppliedDirectives)
+		sb.WriteString("{\n")
+		for _, field := range typ.InputFields {
+			printDescription(sb, field.Description)
+			astType, err := introspectionTypeToAstType(field.Type)
+			if err != nil {
+				return fmt.Errorf("convert type of input field %s: %w", field.Name, err)
+			}
+			sb.WriteString(format.indent(1))
+			sb.WriteString(field.Name)
+			sb.WriteString(": ")
+			sb.WriteString(astType.String())
+			printDefaultValue(sb, field.DefaultValue)
+			printDeprecation(sb, field.IsDeprecated, field.DeprecationReason)
+			printAppliedDirectives(sb, field.AppliedDirectives)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("}")
+
+	case ast.Interface:
+		return printInterface(sb, typ, format)
+	default:
+		return fmt.Errorf("not handling kind: %v", typ.Kind)
 	}
 
 	return nil
@@ -234,38 +832,132 @@ func printTypes(sb *strings.Builder, types []introspectionTypeDefinition, withou
 
 func printDescription(sb *strings.Builder, description string) {
 	if description != "" {
-		sb.WriteString(fmt.Sprintf(`"""%s"""`, description))
+		sb.WriteString(blockstring.Print(description))
 		sb.WriteString("\n")
 	}
 }
 
-func printInterface(sb *strings.Builder, typ introspectionTypeDefinition) error {
+// printDeprecation prints the @deprecated directive for deprecated schema members,
+// omitting the reason when it matches the specification default.
+func printDeprecation(sb *strings.Builder, isDeprecated bool, reason *string) {
+	if !isDeprecated {
+		return
+	}
+	sb.WriteString(" @deprecated")
+	if reason != nil && *reason != defaultDeprecationReason {
+		sb.WriteString("(reason: ")
+		sb.WriteString(printString(*reason))
+		sb.WriteString(")")
+	}
+}
+
+// printDefaultValue prints the default value of an argument or input field. Introspection
+// already reports default values as GraphQL literals, so they are printed verbatim.
+func printDefaultValue(sb *strings.Builder, defaultValue *string) {
+	if defaultValue != nil {
+		sb.WriteString(" = ")
+		sb.WriteString(*defaultValue)
+	}
+}
+
+const hexDigits = "0123456789abcdef"
+
+// printString quotes s as a GraphQL string value.
+func printString(s string) string {
+	sb := &strings.Builder{}
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				sb.WriteString(`\u00`)
+				sb.WriteByte(hexDigits[r>>4])
+				sb.WriteByte(hexDigits[r&0xf])
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// printImplements prints the implements clause of an object or interface, followed by a
+// space so the opening brace can be written directly after it.
+func printImplements(sb *strings.Builder, typ introspectionTypeDefinition) error {
+	names, err := interfaceNames(typ)
+	if err != nil || len(names) == 0 {
+		return err
+	}
+	sb.WriteString("implements ")
+	sb.WriteString(strings.Join(names, " & "))
+	sb.WriteString(" ")
+	return nil
+}
+
+func printInterface(sb *strings.Builder, typ introspectionTypeDefinition, format SDLFormat) error {
 	if typ.Kind != ast.Interface {
 		return fmt.Errorf("cannot print %v as %v", typ.Kind, ast.Interface)
 	}
 
-	sb.WriteString(fmt.Sprintf("interface %s {\n", typ.Name))
+	sb.WriteString("interface ")
+	sb.WriteString(typ.Name)
+	sb.WriteString(" ")
+	if err := printImplements(sb, typ); err != nil {
+		return err
+	}
+	printTypeDirectives(sb, typ.AppliedDirectives)
+	sb.WriteString("{\n")
 	for _, field := range typ.Fields {
-		printDescription(sb, typ.Description)
-		sb.WriteString(fmt.Sprintf("\t%s", field.Name))
-		if len(field.Args) > 0 {
-			sb.WriteString("(\n")
-			for _, arg := range field.Args {
-				astType, err := introspectionTypeToAstType(arg.Type)
-				if err != nil {
-					return fmt.Errorf("convert introspection type to AST type: %w\n%v", err, arg.Type)
-				}
-				sb.WriteString(fmt.Sprintf("\t\t%s: %s\n", arg.Name, astType.String()))
-			}
-			sb.WriteString("\t)")
+		printDescription(sb, field.Description)
+		if err := printField(sb, field, format); err != nil {
+			return err
 		}
-		astType, err := introspectionTypeToAstType(field.Type)
-		if err != nil {
-			return fmt.Errorf("convert introspection type to AST type: %w\n%v", err, field.Type)
-		}
-		sb.WriteString(fmt.Sprintf(": %s\n", astType.String()))
 	}
 	sb.WriteString("}")
 
 	return nil
 }
+
+// printField prints a field of an object or interface, with its arguments.
+func printField(sb *strings.Builder, field introspectedTypeField, format SDLFormat) error {
+	astType, err := introspectionTypeToAstType(field.Type)
+	if err != nil {
+		return fmt.Errorf("convert type of field %s: %w", field.Name, err)
+	}
+	printTail := func(sb *strings.Builder) {
+		sb.WriteString(": ")
+		sb.WriteString(astType.String())
+		printDeprecation(sb, field.IsDeprecated, field.DeprecationReason)
+		printAppliedDirectives(sb, field.AppliedDirectives)
+	}
+
+	if len(field.Args) == 0 {
+		sb.WriteString(format.indent(1))
+		sb.WriteString(field.Name)
+		printTail(sb)
+		sb.WriteString("\n")
+		return nil
+	}
+	tail := &strings.Builder{}
+	printTail(tail)
+	if err := printArguments(sb, field.Args, format.indent(1)+field.Name, tail.String(), 1, format, field.Name+"."); err != nil {
+		return err
+	}
+	sb.WriteString("\n")
+	return nil
+}
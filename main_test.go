@@ -1,11 +1,22 @@
 package gqlfetch
 
 import (
+	"context"
 	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/vektah/gqlparser/ast"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
 )
 
 func strPtr(s string) *string { return &s }
@@ -58,7 +69,7 @@ func Test_printInterface(t *testing.T) {
 									Description: "",
 									Type: &introspectedType{
 										Name: strPtr("Int"),
-										Kind: NON_NULL,
+										Kind: SCALAR,
 									},
 								},
 							},
@@ -91,14 +102,14 @@ func Test_printInterface(t *testing.T) {
 									Name: "argOne",
 									Type: &introspectedType{
 										Name: strPtr("Int"),
-										Kind: NON_NULL,
+										Kind: SCALAR,
 									},
 								},
 								{
 									Name: "argTwo",
 									Type: &introspectedType{
 										Name: strPtr("Int"),
-										Kind: NON_NULL,
+										Kind: SCALAR,
 									},
 								},
 							},
@@ -113,10 +124,33 @@ func Test_printInterface(t *testing.T) {
 	): myResponseObject
 }`,
 		},
+		"implementing interfaces": {
+			args: args{
+				sb: strings.Builder{},
+				typ: introspectionTypeDefinition{
+					Kind: ast.Interface,
+					Name: "Node",
+					Interfaces: []introspectedType{
+						{Name: strPtr("Entity"), Kind: "INTERFACE"},
+						{Name: strPtr("Resource"), Kind: "INTERFACE"},
+					},
+					Fields: []introspectedTypeField{
+						{
+							Name: "id",
+							Type: &introspectedType{
+								Name: strPtr("ID"),
+								Kind: "SCALAR",
+							},
+						},
+					},
+				},
+			},
+			expect: "interface Node implements Entity & Resource {\n\tid: ID\n}",
+		},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			printInterface(&tt.args.sb, tt.args.typ)
+			printInterface(&tt.args.sb, tt.args.typ, SDLFormat{})
 			got := tt.args.sb.String()
 			if got != tt.expect {
 				t.Errorf("printing ast.Interface expect: %v got: %v", tt.expect, got)
@@ -124,3 +158,1075 @@ func Test_printInterface(t *testing.T) {
 		})
 	}
 }
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestBuildClientSchemaWithOptions_HTTPClient(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+
+	var calls int
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint:   server.URL,
+		Method:     http.MethodPost,
+		HTTPClient: client,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected injected client to be used once, got %d calls", calls)
+	}
+
+	for name, options := range map[string]BuildClientSchemaOptions{
+		"TLS":        {Endpoint: server.URL, TLS: &TLSOptions{InsecureSkipVerify: true}},
+		"connection": {Endpoint: server.URL, Connection: &ConnectionOptions{HTTPVersion: HTTP1}},
+		"proxy":      {Endpoint: server.URL, ProxyURL: "http://proxy.internal"},
+		"cookie jar": {Endpoint: server.URL, CookieJar: &cookiejar.Jar{}},
+		"unix":       {Endpoint: "unix:///var/run/app.sock:/graphql"},
+	} {
+		options.HTTPClient = client
+		if _, err := BuildClientSchemaWithOptions(context.Background(), options); err == nil || !strings.Contains(err.Error(), "can't be combined with HTTPClient") {
+			t.Errorf("expect %s combined with HTTPClient to fail got: %v", name, err)
+		}
+	}
+}
+
+func Test_printSchemaDefinition(t *testing.T) {
+	tests := map[string]struct {
+		schema introspectionSchema
+		expect string
+	}{
+		"default root names": {
+			schema: introspectionSchema{
+				QueryType:    introspectionRootType{Name: "Query"},
+				MutationType: introspectionRootType{Name: "Mutation"},
+			},
+			expect: "",
+		},
+		"custom query root name": {
+			schema: introspectionSchema{
+				QueryType: introspectionRootType{Name: "QueryRoot"},
+			},
+			expect: "schema {\n\tquery: QueryRoot\n}\n\n",
+		},
+		"custom mutation root name": {
+			schema: introspectionSchema{
+				QueryType:    introspectionRootType{Name: "Query"},
+				MutationType: introspectionRootType{Name: "MutationRoot"},
+			},
+			expect: "schema {\n\tquery: Query\n\tmutation: MutationRoot\n}\n\n",
+		},
+		"non-root type with a default root name": {
+			schema: introspectionSchema{
+				QueryType: introspectionRootType{Name: "Query"},
+				Types: []introspectionTypeDefinition{
+					{Kind: ast.Object, Name: "Query"},
+					{Kind: ast.Object, Name: "Subscription"},
+				},
+			},
+			expect: "schema {\n\tquery: Query\n}\n\n",
+		},
+		"custom subscription root name": {
+			schema: introspectionSchema{
+				QueryType:        introspectionRootType{Name: "Query"},
+				SubscriptionType: introspectionRootType{Name: "SubscriptionRoot"},
+			},
+			expect: "schema {\n\tquery: Query\n\tsubscription: SubscriptionRoot\n}\n\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sb := strings.Builder{}
+			printSchemaDefinition(&sb, tt.schema, SDLFormat{})
+			if got := sb.String(); got != tt.expect {
+				t.Errorf("printing schema definition expect: %q got: %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func Test_validateRootOperationTypes(t *testing.T) {
+	tests := map[string]struct {
+		schema    introspectionSchema
+		expectErr bool
+	}{
+		"all roots present": {
+			schema: introspectionSchema{
+				QueryType:        introspectionRootType{Name: "Query"},
+				SubscriptionType: introspectionRootType{Name: "Subscription"},
+				Types: []introspectionTypeDefinition{
+					{Kind: ast.Object, Name: "Query"},
+					{Kind: ast.Object, Name: "Subscription"},
+				},
+			},
+		},
+		"missing subscription root": {
+			schema: introspectionSchema{
+				QueryType:        introspectionRootType{Name: "Query"},
+				SubscriptionType: introspectionRootType{Name: "Subscription"},
+				Types: []introspectionTypeDefinition{
+					{Kind: ast.Object, Name: "Query"},
+				},
+			},
+			expectErr: true,
+		},
+		"root is not an object": {
+			schema: introspectionSchema{
+				QueryType: introspectionRootType{Name: "Query"},
+				Types: []introspectionTypeDefinition{
+					{Kind: ast.Interface, Name: "Query"},
+				},
+			},
+			expectErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateRootOperationTypes(tt.schema)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("validating root operation types expect error: %v got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func Test_printDeprecation(t *testing.T) {
+	tests := map[string]struct {
+		isDeprecated bool
+		reason       *string
+		expect       string
+	}{
+		"not deprecated":            {isDeprecated: false, reason: strPtr("ignored"), expect: ""},
+		"deprecated without reason": {isDeprecated: true, expect: " @deprecated"},
+		"deprecated default reason": {isDeprecated: true, reason: strPtr("No longer supported"), expect: " @deprecated"},
+		"deprecated with reason":    {isDeprecated: true, reason: strPtr("Use `name`."), expect: " @deprecated(reason: \"Use `name`.\")"},
+		"deprecated escaped reason": {isDeprecated: true, reason: strPtr("say \"hi\"\n"), expect: ` @deprecated(reason: "say \"hi\"\n")`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sb := strings.Builder{}
+			printDeprecation(&sb, tt.isDeprecated, tt.reason)
+			if got := sb.String(); got != tt.expect {
+				t.Errorf("printing deprecation expect: %v got: %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemaWithOptions_DefaultValues(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+
+	schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint: server.URL,
+		Method:   http.MethodPost,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expect := range []string{
+		"\t\tlimit: Int = 10\n",
+		"\trole: Role = USER\n",
+		"\treason: String = \"No longer supported\"\n",
+		"\trequires: Role = ADMIN\n",
+	} {
+		if !strings.Contains(schema, expect) {
+			t.Errorf("expected schema to contain %q got:\n%v", expect, schema)
+		}
+	}
+}
+
+func TestBuildSchemaFromIntrospectionJSON(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(fixture, &response); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+
+	tests := map[string]struct {
+		input     string
+		options   BuildClientSchemaOptions
+		expect    string
+		absent    string
+		expectErr bool
+	}{
+		"full response":    {input: string(fixture), expect: "type User implements Node"},
+		"bare export":      {input: string(response.Data), expect: "type User implements Node"},
+		"missing __schema": {input: "{}", expectErr: true},
+		"type prefix":      {input: string(fixture), options: BuildClientSchemaOptions{TypePrefix: "Users_"}, expect: "type Users_User implements Users_Node"},
+		"excluded types":   {input: string(fixture), options: BuildClientSchemaOptions{ExcludeTypes: []string{"User"}}, expect: "type Query {", absent: "type User "},
+		"introspection JSON": {
+			input:   string(fixture),
+			options: BuildClientSchemaOptions{OutputFormat: OutputIntrospectionJSON, SortSchema: true},
+			expect:  `"__schema": {`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			schema, err := BuildSchemaFromIntrospectionJSON(strings.NewReader(tt.input), tt.options)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+			if !strings.Contains(schema, tt.expect) {
+				t.Errorf("expected schema to contain %q got:\n%v", tt.expect, schema)
+			}
+			if tt.absent != "" && strings.Contains(schema, tt.absent) {
+				t.Errorf("expected schema not to contain %q got:\n%v", tt.absent, schema)
+			}
+		})
+	}
+}
+
+func TestBuildSchemaFromIntrospectionJSON_FieldDescriptions(t *testing.T) {
+	input := `{"__schema": {"queryType": {"name": "Query"}, "types": [
+		{"kind": "OBJECT", "name": "Query", "interfaces": [], "fields": [{"name": "node", "args": [{"name": "filter", "type": {"kind": "INPUT_OBJECT", "name": "Filter"}}], "type": {"kind": "INTERFACE", "name": "Node"}}]},
+		{"kind": "INTERFACE", "name": "Node", "description": "An object with an ID.", "interfaces": [], "fields": [
+			{"name": "id", "description": "The ID of the object.", "args": [], "type": {"kind": "SCALAR", "name": "ID"}},
+			{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "ID"}}
+		]},
+		{"kind": "INPUT_OBJECT", "name": "Filter", "description": "Filters nodes.", "inputFields": [
+			{"name": "id", "description": "Only the node with this ID.", "type": {"kind": "SCALAR", "name": "ID"}},
+			{"name": "name", "type": {"kind": "SCALAR", "name": "ID"}}
+		]},
+		{"kind": "SCALAR", "name": "ID"}
+	], "directives": []}}`
+
+	schema, err := BuildSchemaFromIntrospectionJSON(strings.NewReader(input), BuildClientSchemaOptions{WithoutBuiltins: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expect := range []string{
+		`"""An object with an ID."""` + "\ninterface Node {\n" + `"""The ID of the object."""` + "\n\tid: ID\n\tname: ID\n}",
+		`"""Filters nodes."""` + "\ninput Filter {\n" + `"""Only the node with this ID."""` + "\n\tid: ID\n\tname: ID\n}",
+	} {
+		if !strings.Contains(schema, expect) {
+			t.Errorf("expect schema to contain:\n%s\ngot:\n%s", expect, schema)
+		}
+	}
+}
+
+func TestBuildClientSchemaWithOptions_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	start := time.Now()
+	_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint: server.URL,
+		Method:   http.MethodPost,
+		Timeout:  50 * time.Millisecond,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded error got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected fetch to fail fast, took %v", elapsed)
+	}
+}
+
+func TestBuildClientSchemaWithOptions_Method(t *testing.T) {
+	body, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	tests := map[string]struct {
+		method string
+		expect string
+	}{
+		"get encodes query parameters":  {method: http.MethodGet, expect: http.MethodGet},
+		"post encodes json body":        {method: http.MethodPost, expect: http.MethodPost},
+		"empty method defaults to post": {method: "", expect: http.MethodPost},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != tt.expect {
+					t.Errorf("expect method %s got: %s", tt.expect, r.Method)
+				}
+				var params requestParams
+				if r.Method == http.MethodGet {
+					params.Query = r.URL.Query().Get("query")
+					params.OperationName = r.URL.Query().Get("operationName")
+				} else if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Errorf("decode request body: %v", err)
+				}
+				if !strings.Contains(params.Query, "__schema") || params.OperationName != "IntrospectionQuery" {
+					t.Errorf("expect introspection query got: %+v", params)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint: server.URL + "?tenant=acme",
+				Method:   tt.method,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemaWithOptions_IntrospectionJSON(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+
+	out, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint:     server.URL,
+		Method:       http.MethodPost,
+		OutputFormat: OutputIntrospectionJSON,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var exported struct {
+		Schema struct {
+			QueryType        *struct{ Name string } `json:"queryType"`
+			SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+			Types            []struct {
+				Name       string            `json:"name"`
+				Interfaces []json.RawMessage `json:"interfaces"`
+			} `json:"types"`
+		} `json:"__schema"`
+	}
+	if err := json.Unmarshal([]byte(out), &exported); err != nil {
+		t.Fatalf("expect valid JSON got: %v\n%v", err, out)
+	}
+	if exported.Schema.QueryType == nil || exported.Schema.QueryType.Name != "Query" {
+		t.Errorf("expect queryType Query got: %+v", exported.Schema.QueryType)
+	}
+	if exported.Schema.SubscriptionType != nil {
+		t.Errorf("expect absent subscriptionType to be null got: %+v", exported.Schema.SubscriptionType)
+	}
+
+	// The exported JSON must round trip to the same SDL.
+	sdl, err := BuildSchemaFromIntrospectionJSON(strings.NewReader(out), BuildClientSchemaOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{Endpoint: server.URL, Method: http.MethodPost})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sdl != expected {
+		t.Errorf("expect exported JSON to round trip got:\n%v\nexpected:\n%v", sdl, expected)
+	}
+}
+
+func Test_printDirectives(t *testing.T) {
+	tests := map[string]struct {
+		directive introspectionDirectiveDefinition
+		expect    string
+	}{
+		"non repeatable": {
+			directive: introspectionDirectiveDefinition{Name: "auth", Locations: []ast.DirectiveLocation{ast.LocationFieldDefinition}},
+			expect:    "directive @auth on FIELD_DEFINITION\n\n",
+		},
+		"repeatable": {
+			directive: introspectionDirectiveDefinition{Name: "tag", IsRepeatable: true, Locations: []ast.DirectiveLocation{ast.LocationObject, ast.LocationFieldDefinition}},
+			expect:    "directive @tag repeatable on OBJECT | FIELD_DEFINITION\n\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sb := &strings.Builder{}
+			if err := printDirectives(sb, []introspectionDirectiveDefinition{tt.directive}, builtinFilter{}, SDLFormat{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := sb.String(); got != tt.expect {
+				t.Errorf("printing directive expect: %q got: %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func Test_introspectionQuery_DirectiveIsRepeatable(t *testing.T) {
+	for _, repeatable := range []bool{false, true} {
+		query, err := introspectionQuery(introspectionQueryOptions{DirectiveIsRepeatable: repeatable})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := strings.Contains(query, "isRepeatable"); got != repeatable {
+			t.Errorf("expect isRepeatable requested: %v got: %v", repeatable, got)
+		}
+	}
+}
+
+func Test_introspectionQuery_WithoutDeprecated(t *testing.T) {
+	tests := map[string]struct {
+		options        introspectionQueryOptions
+		expectContains []string
+		expectMissing  []string
+	}{
+		"default": {
+			expectContains: []string{"fields(includeDeprecated: true)", "enumValues(includeDeprecated: true)"},
+			expectMissing:  []string{"includeDeprecated: false"},
+		},
+		"without deprecated": {
+			options:        introspectionQueryOptions{WithoutDeprecated: true},
+			expectContains: []string{"fields(includeDeprecated: false)", "enumValues(includeDeprecated: false)"},
+			expectMissing:  []string{"includeDeprecated: true"},
+		},
+		"without deprecated input values": {
+			options:        introspectionQueryOptions{WithoutDeprecated: true, InputValueDeprecation: true},
+			expectContains: []string{"args(includeDeprecated: false)", "inputFields(includeDeprecated: false)"},
+			expectMissing:  []string{"includeDeprecated: true"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			query, err := introspectionQuery(tt.options)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := parser.ParseQuery(&ast.Source{Input: query}); err != nil {
+				t.Fatalf("expect a valid query got: %v\n%v", err, query)
+			}
+			for _, expect := range tt.expectContains {
+				if !strings.Contains(query, expect) {
+					t.Errorf("expect query to contain %q got:\n%v", expect, query)
+				}
+			}
+			for _, expect := range tt.expectMissing {
+				if strings.Contains(query, expect) {
+					t.Errorf("expect query not to contain %q got:\n%v", expect, query)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemaWithOptions_OnResponse(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html>Request blocked</html>"))
+	}))
+	defer server.Close()
+
+	var responses []ResponseInfo
+	_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint:    server.URL,
+		RetryPolicy: &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+		OnResponse:  func(info ResponseInfo) { responses = append(responses, info) },
+	})
+	if err == nil {
+		t.Fatalf("expect HTML response to fail")
+	}
+
+	if len(responses) != 2 {
+		t.Fatalf("expect a response per attempt got: %+v", responses)
+	}
+	if responses[0].Attempt != 1 || responses[0].StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expect retried 503 response got: %+v", responses[0])
+	}
+	last := responses[1]
+	if last.Attempt != 2 || last.StatusCode != http.StatusOK || last.Header.Get("Content-Type") != "text/html" {
+		t.Errorf("expect HTML response got: %+v", last)
+	}
+	if last.ContentLength != int64(len("<html>Request blocked</html>")) || last.Duration <= responses[0].Duration {
+		t.Errorf("expect content length and growing duration got: %+v", last)
+	}
+}
+
+func TestBuildClientSchemaWithOptions_NonGraphQLResponse(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	tests := map[string]struct {
+		status      int
+		contentType string
+		body        string
+		expectErr   bool
+	}{
+		"login page":       {status: http.StatusOK, contentType: "text/html; charset=utf-8", body: "<html>Sign in</html>", expectErr: true},
+		"not found":        {status: http.StatusNotFound, contentType: "text/plain", body: "404 page not found", expectErr: true},
+		"empty body":       {status: http.StatusOK, contentType: "application/json", expectErr: true},
+		"json as text":     {status: http.StatusOK, contentType: "text/plain", body: string(fixture)},
+		"graphql response": {status: http.StatusOK, contentType: "application/graphql-response+json", body: string(fixture)},
+		"no content type":  {status: http.StatusOK, body: string(fixture)},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header()["Content-Type"] = []string{tt.contentType}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{Endpoint: server.URL})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if !tt.expectErr {
+				return
+			}
+			var responseErr *NonGraphQLResponseError
+			if !errors.As(err, &responseErr) || !errors.Is(err, ErrNonGraphQLResponse) {
+				t.Fatalf("expect a NonGraphQLResponseError got: %v", err)
+			}
+			if responseErr.StatusCode != tt.status || responseErr.Body != tt.body {
+				t.Errorf("expect status %d and body %q got: %+v", tt.status, tt.body, responseErr)
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemaWithOptions_GraphQLErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errors": [{
+			"message": "GraphQL introspection is not allowed",
+			"locations": [{"line": 2, "column": 3}],
+			"path": ["__schema"],
+			"extensions": {"code": "GRAPHQL_VALIDATION_FAILED"}
+		}]}`))
+	}))
+	defer server.Close()
+
+	_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{Endpoint: server.URL})
+	var gqlErrs GraphQLErrors
+	if !errors.As(err, &gqlErrs) || len(gqlErrs) != 1 {
+		t.Fatalf("expect GraphQLErrors got: %v", err)
+	}
+	gqlErr := gqlErrs[0]
+	if gqlErr.Message != "GraphQL introspection is not allowed" || gqlErr.Extensions["code"] != "GRAPHQL_VALIDATION_FAILED" {
+		t.Errorf("expect message and extensions got: %+v", gqlErr)
+	}
+	if len(gqlErr.Locations) != 1 || gqlErr.Locations[0] != (GraphQLErrorLocation{Line: 2, Column: 3}) {
+		t.Errorf("expect location 2:3 got: %+v", gqlErr.Locations)
+	}
+	if len(gqlErr.Path) != 1 || gqlErr.Path[0] != "__schema" {
+		t.Errorf("expect path [__schema] got: %v", gqlErr.Path)
+	}
+}
+
+func TestBuildClientSchemaWithOptions_NegotiateQuery(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	tests := map[string]struct {
+		negotiate      bool
+		expectErr      bool
+		expectRequests int
+	}{
+		"rejected without negotiation": {expectErr: true, expectRequests: 1},
+		"negotiates a smaller query":   {negotiate: true, expectRequests: 3},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				var params requestParams
+				if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+					t.Errorf("decode request: %v", err)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				for _, unknown := range []string{"isRepeatable", "description"} {
+					if strings.Contains(params.Query, unknown) {
+						w.Write([]byte(`{"errors": [{"message": "Cannot query field \"` + unknown + `\"."}]}`))
+						return
+					}
+				}
+				w.Write(fixture)
+			}))
+			defer server.Close()
+
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:              server.URL,
+				DirectiveIsRepeatable: true,
+				NegotiateQuery:        tt.negotiate,
+			})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if requests != tt.expectRequests {
+				t.Errorf("expect %d requests got: %d", tt.expectRequests, requests)
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemaWithOptions_IntrospectionQuery(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	query, err := introspectionQuery(introspectionQueryOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	custom := strings.Replace(query, "fields(includeDeprecated: true)", "fields(includeDeprecated: false)", 1)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var params requestParams
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if params.Query != custom || params.OperationName != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"errors": [{"message": "Unknown argument \"includeDeprecated\"."}]}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	_, err = BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint:           server.URL,
+		IntrospectionQuery: custom,
+		NegotiateQuery:     true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expect the custom query to be sent once got: %d requests", requests)
+	}
+}
+
+func TestBuildClientSchemaWithOptions_WithoutDescriptions(t *testing.T) {
+	var query string
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params requestParams
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		query = params.Query
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint:            server.URL,
+		WithoutDescriptions: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(query, "description") {
+		t.Errorf("expect descriptions not to be requested got:\n%v", query)
+	}
+	// The fixture server answers with descriptions regardless of the query.
+	if strings.Contains(schema, `"""`) {
+		t.Errorf("expect descriptions not to be printed got:\n%v", schema)
+	}
+}
+
+func TestBuildClientSchemaWithOptions_SortSchema(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var reversed introspectionResults
+	if err := json.Unmarshal(fixture, &reversed); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+	types := reversed.Data.Schema.Types
+	for i, j := 0, len(types)-1; i < j; i, j = i+1, j-1 {
+		types[i], types[j] = types[j], types[i]
+	}
+	for _, typ := range types {
+		for i, j := 0, len(typ.Fields)-1; i < j; i, j = i+1, j-1 {
+			typ.Fields[i], typ.Fields[j] = typ.Fields[j], typ.Fields[i]
+		}
+	}
+	reversedFixture, err := json.Marshal(reversed)
+	if err != nil {
+		t.Fatalf("encode reversed fixture: %v", err)
+	}
+
+	fetch := func(body []byte, sorted bool) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(body)
+		}))
+		defer server.Close()
+		schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{Endpoint: server.URL, SortSchema: sorted})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return schema
+	}
+
+	if fetch(fixture, false) == fetch(reversedFixture, false) {
+		t.Fatalf("expect the server ordering to change the unsorted output")
+	}
+	if sorted, sortedReversed := fetch(fixture, true), fetch(reversedFixture, true); sorted != sortedReversed {
+		t.Errorf("expect sorted output to be independent of the server ordering got:\n%v\nand:\n%v", sorted, sortedReversed)
+	}
+}
+
+func TestBuildClientSchemaWithOptions_GQLParserFormatter(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	printed, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{Endpoint: server.URL, WithoutBuiltins: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	formatted, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{Endpoint: server.URL, GQLParserFormatter: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if formatted == printed {
+		t.Fatalf("expect the formatter to lay out the schema differently got:\n%v", formatted)
+	}
+	if _, err := LoadSchema(&ast.Source{Input: formatted}); err != nil {
+		t.Errorf("expect the formatted schema to load got: %v", err)
+	}
+	if strings.Contains(formatted, "scalar String") || !strings.Contains(formatted, "search(term: String!, limit: Int = 10): [SearchResult!]!") {
+		t.Errorf("expect the schema without built-ins got:\n%v", formatted)
+	}
+	if strings.Index(formatted, "type Query") > strings.Index(formatted, "type User") {
+		t.Errorf("expect the server ordering to be kept got:\n%v", formatted)
+	}
+}
+
+func Test_printSchema_Errors(t *testing.T) {
+	tests := map[string]struct {
+		schema     introspectionSchema
+		expectPath string
+	}{
+		"unknown kind": {
+			schema:     introspectionSchema{Types: []introspectionTypeDefinition{{Kind: "UNKNOWN", Name: "Odd"}}},
+			expectPath: "Odd",
+		},
+		"field without type": {
+			schema: introspectionSchema{Types: []introspectionTypeDefinition{{
+				Kind:   ast.Object,
+				Name:   "Query",
+				Fields: []introspectedTypeField{{Name: "broken"}},
+			}}},
+			expectPath: "Query",
+		},
+		"interface field without type": {
+			schema: introspectionSchema{Types: []introspectionTypeDefinition{{
+				Kind:   ast.Interface,
+				Name:   "Node",
+				Fields: []introspectedTypeField{{Name: "id"}},
+			}}},
+			expectPath: "Node",
+		},
+		"directive argument without type": {
+			schema: introspectionSchema{Directives: []introspectionDirectiveDefinition{{
+				Name: "auth",
+				Args: []introspectionInputField{{Name: "requires"}},
+			}}},
+			expectPath: "@auth",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sdl, err := printSchema(context.Background(), tt.schema, builtinFilter{}, SDLFormat{})
+			var printErr *PrintError
+			if !errors.As(err, &printErr) {
+				t.Fatalf("expect a PrintError got: %v", err)
+			}
+			if printErr.Path != tt.expectPath {
+				t.Errorf("expect path: %v got: %v", tt.expectPath, printErr.Path)
+			}
+			if sdl != "" {
+				t.Errorf("expect no partial schema got: %v", sdl)
+			}
+		})
+	}
+}
+
+// largeSchema returns a schema of n object types, each with a field per scalar, a
+// field with arguments and a reference to the next type.
+func largeSchema(n int) introspectionSchema {
+	schema := introspectionSchema{QueryType: introspectionRootType{Name: "Query"}}
+	schema.Types = append(schema.Types, introspectionTypeDefinition{
+		Kind:   ast.Object,
+		Name:   "Query",
+		Fields: []introspectedTypeField{{Name: "root", Type: &introspectedType{Kind: OBJECT, Name: strPtr("Type0")}}},
+	})
+	for i := 0; i < n; i++ {
+		next := fmt.Sprintf("Type%d", (i+1)%n)
+		schema.Types = append(schema.Types, introspectionTypeDefinition{
+			Kind:        ast.Object,
+			Name:        fmt.Sprintf("Type%d", i),
+			Description: "A generated type.",
+			Fields: []introspectedTypeField{
+				{Name: "id", Type: &introspectedType{Kind: NON_NULL, OfType: &introspectedType{Kind: "SCALAR", Name: strPtr("ID")}}},
+				{Name: "name", Description: "The name.", Type: &introspectedType{Kind: "SCALAR", Name: strPtr("String")}},
+				{Name: "count", Type: &introspectedType{Kind: "SCALAR", Name: strPtr("Int")}},
+				{Name: "items", Args: []introspectionInputField{
+					{Name: "first", Type: &introspectedType{Kind: "SCALAR", Name: strPtr("Int")}, DefaultValue: strPtr("10")},
+					{Name: "after", Type: &introspectedType{Kind: "SCALAR", Name: strPtr("String")}},
+				}, Type: &introspectedType{Kind: LIST, OfType: &introspectedType{Kind: OBJECT, Name: strPtr(next)}}},
+			},
+			Interfaces: []introspectedType{},
+		})
+	}
+	for _, scalar := range []string{"ID", "String", "Int", "Boolean"} {
+		schema.Types = append(schema.Types, introspectionTypeDefinition{Kind: ast.Scalar, Name: scalar})
+	}
+	return schema
+}
+
+func Test_printTypes_Parallel(t *testing.T) {
+	schema := largeSchema(5000)
+	schema.Types[4000].Fields[0].Type = &introspectedType{Kind: NON_NULL}
+	valid := largeSchema(5000)
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	print := func(schema introspectionSchema, threshold int) (string, error) {
+		defer func(threshold int) { parallelPrintThreshold = threshold }(parallelPrintThreshold)
+		parallelPrintThreshold = threshold
+		return printSchema(context.Background(), schema, builtinFilter{}, SDLFormat{})
+	}
+	sequential, err := print(valid, len(valid.Types)+1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parallel, err := print(valid, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parallel != sequential {
+		t.Errorf("expect the parallel printer to print the same schema")
+	}
+
+	_, err = print(schema, 1)
+	var printErr *PrintError
+	if !errors.As(err, &printErr) || printErr.Path != "Type3999" {
+		t.Errorf("expect the failing type to be reported got: %v", err)
+	}
+}
+
+func BenchmarkPrintSchema(b *testing.B) {
+	for _, size := range []int{1000, 20000} {
+		schema := largeSchema(size)
+		for _, threshold := range []int{len(schema.Types) + 1, 1} {
+			mode := "parallel"
+			if threshold > len(schema.Types) {
+				mode = "sequential"
+			}
+			b.Run(fmt.Sprintf("%d types %s", size, mode), func(b *testing.B) {
+				defer func(threshold int) { parallelPrintThreshold = threshold }(parallelPrintThreshold)
+				parallelPrintThreshold = threshold
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := printSchema(context.Background(), schema, builtinFilter{}, SDLFormat{}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkPrintSchema_10MB(b *testing.B) {
+	schema := largeSchema(66000)
+	sdl, err := printSchema(context.Background(), schema, builtinFilter{}, SDLFormat{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(sdl)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := printSchema(context.Background(), schema, builtinFilter{}, SDLFormat{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// wrappedTypeRef returns the introspection type reference of typ, such as [[Int!]!]!,
+// whose named type is a scalar.
+func wrappedTypeRef(typ string) *introspectedType {
+	switch {
+	case strings.HasSuffix(typ, "!"):
+		return &introspectedType{Kind: NON_NULL, OfType: wrappedTypeRef(strings.TrimSuffix(typ, "!"))}
+	case strings.HasPrefix(typ, "["):
+		return &introspectedType{Kind: LIST, OfType: wrappedTypeRef(typ[1 : len(typ)-1])}
+	default:
+		return &introspectedType{Kind: SCALAR, Name: strPtr(typ)}
+	}
+}
+
+func Test_printSchema_WrappedTypes(t *testing.T) {
+	types := []string{
+		"Int", "Int!",
+		"[Int]", "[Int!]", "[Int]!", "[Int!]!",
+		"[[Int]]", "[[Int!]]", "[[Int]!]", "[[Int!]!]",
+		"[[Int]]!", "[[Int!]]!", "[[Int]!]!", "[[Int!]!]!",
+		"[[[Int!]]!]", "[[[Int]!]!]!", "[[[Int!]!]!]!",
+	}
+	for _, typ := range types {
+		t.Run(typ, func(t *testing.T) {
+			schema := introspectionSchema{
+				QueryType: introspectionRootType{Name: "Query"},
+				Types: []introspectionTypeDefinition{
+					{Kind: ast.Object, Name: "Query", Interfaces: []introspectedType{}, Fields: []introspectedTypeField{
+						{Name: "field", Args: []introspectionInputField{{Name: "arg", Type: wrappedTypeRef(typ)}}, Type: wrappedTypeRef(typ)},
+						{Name: "plain", Type: wrappedTypeRef(typ)},
+					}},
+					{Kind: ast.Interface, Name: "Node", Fields: []introspectedTypeField{{Name: "field", Type: wrappedTypeRef(typ)}}},
+					{Kind: ast.InputObject, Name: "Filter", InputFields: []introspectionInputField{{Name: "field", Type: wrappedTypeRef(typ)}}},
+					{Kind: ast.Scalar, Name: "Int"},
+				},
+			}
+
+			sdl, err := printSchema(context.Background(), schema, builtinFilter{without: true}, SDLFormat{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expect := range []string{
+				"\tfield(\n\t\targ: " + typ + "\n\t): " + typ + "\n",
+				"\tplain: " + typ + "\n",
+				"interface Node {\n\tfield: " + typ + "\n}",
+				"input Filter {\n\tfield: " + typ + "\n}",
+			} {
+				if !strings.Contains(sdl, expect) {
+					t.Errorf("expect SDL to contain:\n%s\ngot:\n%s", expect, sdl)
+				}
+			}
+
+			parsed, gqlErr := parser.ParseSchema(&ast.Source{Input: sdl})
+			if gqlErr != nil {
+				t.Fatalf("parse printed SDL: %v", gqlErr)
+			}
+			if got := parsed.Definitions.ForName("Query").Fields.ForName("plain").Type.String(); got != typ {
+				t.Errorf("expect parsed field type %s got: %s", typ, got)
+			}
+
+			astSchema, err := buildASTSchema(schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			field := astSchema.Types["Query"].Fields.ForName("field")
+			if got := field.Type.String(); got != typ {
+				t.Errorf("expect field type %s got: %s", typ, got)
+			}
+			if got := field.Arguments.ForName("arg").Type.String(); got != typ {
+				t.Errorf("expect argument type %s got: %s", typ, got)
+			}
+			if got := astSchema.Types["Filter"].Fields.ForName("field").Type.String(); got != typ {
+				t.Errorf("expect input field type %s got: %s", typ, got)
+			}
+
+			formatted, err := formatSchema(schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expect := "plain: " + typ + "\n"; !strings.Contains(formatted, expect) {
+				t.Errorf("expect formatted SDL to contain %q got:\n%s", expect, formatted)
+			}
+		})
+	}
+}
+
+func Test_printSchema_Builtins(t *testing.T) {
+	var response introspectionResults
+	err := json.Unmarshal([]byte(`{"data": {"__schema": {"queryType": {"name": "Query"}, "types": [
+		{"kind": "OBJECT", "name": "Query", "interfaces": [], "fields": [{"name": "id", "args": [], "type": {"kind": "SCALAR", "name": "ID"}}]},
+		{"kind": "SCALAR", "name": "ID"},
+		{"kind": "SCALAR", "name": "String"}
+	], "directives": [
+		{"name": "skip", "locations": ["FIELD"], "args": [{"name": "if", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Boolean"}}}]},
+		{"name": "specifiedBy", "locations": ["SCALAR"], "args": [{"name": "url", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}]}
+	]}}}`), &response)
+	if err != nil {
+		t.Fatalf("decode schema: %v", err)
+	}
+
+	tests := map[string]struct {
+		builtins      builtinFilter
+		expectPrinted []string
+		expectOmitted []string
+	}{
+		"with built-ins": {
+			builtins:      builtinFilter{},
+			expectPrinted: []string{"directive @skip", "directive @specifiedBy", "scalar ID", "scalar String"},
+		},
+		"without built-ins": {
+			builtins:      builtinFilter{without: true},
+			expectOmitted: []string{"directive @skip", "directive @specifiedBy", "scalar ID", "scalar String"},
+		},
+		"keep directives": {
+			builtins:      builtinFilter{without: true, keepDirectives: []string{"skip", "specifiedBy"}},
+			expectPrinted: []string{"directive @skip", "directive @specifiedBy"},
+			expectOmitted: []string{"scalar ID", "scalar String"},
+		},
+		"keep scalars": {
+			builtins:      builtinFilter{without: true, keepScalars: []string{"ID"}},
+			expectPrinted: []string{"scalar ID"},
+			expectOmitted: []string{"directive @skip", "directive @specifiedBy", "scalar String"},
+		},
+		"keep ignored with built-ins": {
+			builtins:      builtinFilter{keepScalars: []string{"ID"}},
+			expectPrinted: []string{"directive @skip", "scalar ID", "scalar String"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sdl, err := printSchema(context.Background(), response.Data.Schema, tt.builtins, SDLFormat{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expect := range tt.expectPrinted {
+				if !strings.Contains(sdl, expect) {
+					t.Errorf("expect %q to be printed got:\n%s", expect, sdl)
+				}
+			}
+			for _, expect := range tt.expectOmitted {
+				if strings.Contains(sdl, expect) {
+					t.Errorf("expect %q to be left out got:\n%s", expect, sdl)
+				}
+			}
+			if err := validateSDL(sdl); err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package gqlfetch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// MergeStrategy decides how types defined by more than one schema are combined.
+type MergeStrategy int
+
+const (
+	// MergeIdentical deduplicates types defined identically by several schemas, any
+	// other redefinition is a conflict.
+	MergeIdentical MergeStrategy = iota
+	// MergeMembers also combines differing definitions of the same object, interface,
+	// input object, enum or union type, as long as the members they share are identical.
+	MergeMembers
+)
+
+// MergeConflict describes a type, member or directive that couldn't be merged.
+type MergeConflict struct {
+	// Path is the conflicting type, "Type.member" or "@directive".
+	Path   string
+	Reason string
+}
+
+// MergeError reports every conflict found by MergeSchemas.
+type MergeError struct {
+	Conflicts []MergeConflict
+}
+
+func (e *MergeError) Error() string {
+	var conflicts []string
+	for _, conflict := range e.Conflicts {
+		conflicts = append(conflicts, fmt.Sprintf("%s: %s", conflict.Path, conflict.Reason))
+	}
+	return "failed to merge schemas: " + strings.Join(conflicts, ", ")
+}
+
+// MergeSchemas combines the given schemas into a single SDL document. The fields of the
+// root operation types are always combined, under the default root names. Conflicts
+// are reported as a *MergeError.
+func MergeSchemas(schemas []*ast.Schema, strategy MergeStrategy) (string, error) {
+	merged := map[string]*ast.Definition{}
+	directives := map[string]*ast.DirectiveDefinition{}
+	var conflicts []MergeConflict
+
+	for _, schema := range schemas {
+		doc := userSchemaDocument(schema)
+
+		for _, dir := range doc.Directives {
+			existing := directives[dir.Name]
+			switch {
+			case existing == nil:
+				directives[dir.Name] = dir
+			case formatDirectiveDefinition(existing) != formatDirectiveDefinition(dir):
+				conflicts = append(conflicts, MergeConflict{Path: "@" + dir.Name, Reason: "directive is defined differently"})
+			}
+		}
+
+		roots := map[string]ast.Operation{}
+		for operation, def := range map[ast.Operation]*ast.Definition{ast.Query: schema.Query, ast.Mutation: schema.Mutation, ast.Subscription: schema.Subscription} {
+			if def != nil {
+				roots[def.Name] = operation
+			}
+		}
+
+		for _, def := range doc.Definitions {
+			if operation, ok := roots[def.Name]; ok {
+				def = copyDefinition(def)
+				def.Name = defaultRootNames[operation]
+				if existing := merged[def.Name]; existing != nil {
+					conflicts = append(conflicts, mergeMembers(existing, def)...)
+					continue
+				}
+			}
+
+			existing := merged[def.Name]
+			switch {
+			case existing == nil:
+				merged[def.Name] = copyDefinition(def)
+			case formatDefinition(existing) == formatDefinition(def):
+			case strategy == MergeMembers && existing.Kind == def.Kind:
+				conflicts = append(conflicts, mergeMembers(existing, def)...)
+			default:
+				conflicts = append(conflicts, MergeConflict{Path: def.Name, Reason: "type is defined differently"})
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return "", &MergeError{Conflicts: conflicts}
+	}
+
+	doc := &ast.SchemaDocument{}
+	for _, dir := range directives {
+		doc.Directives = append(doc.Directives, dir)
+	}
+	sort.Slice(doc.Directives, func(i, j int) bool { return doc.Directives[i].Name < doc.Directives[j].Name })
+	for _, def := range merged {
+		doc.Definitions = append(doc.Definitions, def)
+	}
+	sort.Slice(doc.Definitions, func(i, j int) bool { return doc.Definitions[i].Name < doc.Definitions[j].Name })
+	return formatSchemaDocument(doc), nil
+}
+
+// MergeSchemaSDL loads every SDL document and merges them with MergeSchemas.
+func MergeSchemaSDL(sdls []string, strategy MergeStrategy) (string, error) {
+	var schemas []*ast.Schema
+	for i, sdl := range sdls {
+		schema, err := LoadSchema(&ast.Source{Name: fmt.Sprintf("schema %d", i), Input: sdl})
+		if err != nil {
+			return "", fmt.Errorf("failed to load schema %d: %w", i, err)
+		}
+		schemas = append(schemas, schema)
+	}
+	return MergeSchemas(schemas, strategy)
+}
+
+// mergeMembers adds the fields, enum values and union members of def missing from
+// existing, reporting shared members that differ.
+func mergeMembers(existing, def *ast.Definition) []MergeConflict {
+	var conflicts []MergeConflict
+	for _, field := range def.Fields {
+		current := existing.Fields.ForName(field.Name)
+		switch {
+		case current == nil:
+			existing.Fields = append(existing.Fields, field)
+		case formatField(current) != formatField(field):
+			conflicts = append(conflicts, MergeConflict{Path: def.Name + "." + field.Name, Reason: "field is defined differently"})
+		}
+	}
+	for _, value := range def.EnumValues {
+		if existing.EnumValues.ForName(value.Name) == nil {
+			existing.EnumValues = append(existing.EnumValues, value)
+		}
+	}
+	for _, name := range def.Types {
+		if !containsStr(name, existing.Types) {
+			existing.Types = append(existing.Types, name)
+		}
+	}
+	for _, name := range def.Interfaces {
+		if !containsStr(name, existing.Interfaces) {
+			existing.Interfaces = append(existing.Interfaces, name)
+		}
+	}
+	return conflicts
+}
+
+// copyDefinition copies the member lists of def, so merging never modifies the input
+// schemas.
+func copyDefinition(def *ast.Definition) *ast.Definition {
+	copied := *def
+	copied.Fields = append(ast.FieldList{}, def.Fields...)
+	copied.EnumValues = append(ast.EnumValueList{}, def.EnumValues...)
+	copied.Types = append([]string{}, def.Types...)
+	copied.Interfaces = append([]string{}, def.Interfaces...)
+	return &copied
+}
+
+func formatDefinition(def *ast.Definition) string {
+	return formatSchemaDocument(&ast.SchemaDocument{Definitions: ast.DefinitionList{def}})
+}
+
+func formatField(field *ast.FieldDefinition) string {
+	return formatDefinition(&ast.Definition{Kind: ast.Object, Name: "Field", Fields: ast.FieldList{field}})
+}
+
+func formatDirectiveDefinition(dir *ast.DirectiveDefinition) string {
+	return formatSchemaDocument(&ast.SchemaDocument{Directives: ast.DirectiveDefinitionList{dir}})
+}
@@ -0,0 +1,102 @@
+package gqlfetch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestMergeSchemaSDL(t *testing.T) {
+	users := `
+type Query { user(id: ID!): User }
+type User { id: ID! name: String }
+enum Role { ADMIN }
+`
+	billing := `
+schema { query: QueryRoot }
+type QueryRoot { invoice(id: ID!): Invoice }
+type Invoice { id: ID! owner: User }
+type User { id: ID! name: String }
+`
+	billingExtended := `
+type Query { invoice(id: ID!): Invoice }
+type Invoice { id: ID! }
+type User { id: ID! email: String }
+enum Role { BILLING }
+`
+	renamedRoot := `
+directive @audit on OBJECT
+schema { query: QueryRoot }
+interface Node { id: ID! }
+type QueryRoot implements Node @audit { id: ID! node(id: ID!): Node }
+`
+	conflicting := `
+type Query { invoice(id: ID!): String }
+type User { id: ID! name: Int }
+`
+
+	tests := map[string]struct {
+		sdls            []string
+		strategy        MergeStrategy
+		expect          []string
+		expectConflicts []string
+	}{
+		"identical types are deduplicated and roots combined": {
+			sdls:     []string{users, billing},
+			strategy: MergeIdentical,
+			expect:   []string{"user(id: ID!): User", "invoice(id: ID!): Invoice", "type Invoice"},
+		},
+		"renamed roots keep their interfaces and directives": {
+			sdls:     []string{renamedRoot, users},
+			strategy: MergeIdentical,
+			expect:   []string{"type Query implements Node @audit", "node(id: ID!): Node", "user(id: ID!): User"},
+		},
+		"differing types conflict with identical strategy": {
+			sdls:            []string{users, billingExtended},
+			strategy:        MergeIdentical,
+			expectConflicts: []string{"Role", "User"},
+		},
+		"differing types are combined with members strategy": {
+			sdls:     []string{users, billingExtended},
+			strategy: MergeMembers,
+			expect:   []string{"email: String", "name: String", "BILLING"},
+		},
+		"differing shared members conflict": {
+			sdls:            []string{users, billing, conflicting},
+			strategy:        MergeMembers,
+			expectConflicts: []string{"Query.invoice", "User.name"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sdl, err := MergeSchemaSDL(tt.sdls, tt.strategy)
+			if len(tt.expectConflicts) > 0 {
+				var mergeErr *MergeError
+				if !errors.As(err, &mergeErr) {
+					t.Fatalf("expect merge error got: %v", err)
+				}
+				var paths []string
+				for _, conflict := range mergeErr.Conflicts {
+					paths = append(paths, conflict.Path)
+				}
+				if strings.Join(paths, ",") != strings.Join(tt.expectConflicts, ",") {
+					t.Errorf("expect conflicts %v got: %v", tt.expectConflicts, paths)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expect := range tt.expect {
+				if !strings.Contains(sdl, expect) {
+					t.Errorf("expect merged schema to contain %q got:\n%v", expect, sdl)
+				}
+			}
+			if _, err := LoadSchema(&ast.Source{Input: sdl}); err != nil {
+				t.Errorf("expect merged schema to load got: %v\n%v", err, sdl)
+			}
+		})
+	}
+}
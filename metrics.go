@@ -0,0 +1,30 @@
+package gqlfetch
+
+import "time"
+
+// Metrics records the health of schema fetches, typically as Prometheus counters and
+// histograms labelled by endpoint. Every call to BuildClientSchemaWithOptions counts
+// as one fetch, including the ones served from Cache.
+type Metrics interface {
+	IncFetches(endpoint string)
+	IncErrors(endpoint string)
+	IncRetries(endpoint string)
+	ObserveDuration(endpoint string, duration time.Duration)
+	// ObserveSchemaSize observes the size in bytes of the returned schema.
+	ObserveSchemaSize(endpoint string, size int)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) IncFetches(string)                     {}
+func (noopMetrics) IncErrors(string)                      {}
+func (noopMetrics) IncRetries(string)                     {}
+func (noopMetrics) ObserveDuration(string, time.Duration) {}
+func (noopMetrics) ObserveSchemaSize(string, int)         {}
+
+func (options BuildClientSchemaOptions) metrics() Metrics {
+	if options.Metrics == nil {
+		return noopMetrics{}
+	}
+	return options.Metrics
+}
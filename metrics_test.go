@@ -0,0 +1,71 @@
+package gqlfetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+type recordingMetrics struct {
+	fetches, errors, retries int
+	durations                []time.Duration
+	sizes                    []int
+}
+
+func (m *recordingMetrics) IncFetches(string) { m.fetches++ }
+func (m *recordingMetrics) IncErrors(string)  { m.errors++ }
+func (m *recordingMetrics) IncRetries(string) { m.retries++ }
+func (m *recordingMetrics) ObserveDuration(endpoint string, duration time.Duration) {
+	m.durations = append(m.durations, duration)
+}
+func (m *recordingMetrics) ObserveSchemaSize(endpoint string, size int) {
+	m.sizes = append(m.sizes, size)
+}
+
+func TestBuildClientSchemaWithOptions_Metrics(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(fixture)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	metrics := &recordingMetrics{}
+	options := BuildClientSchemaOptions{
+		Endpoint:    server.URL,
+		RetryPolicy: &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+		Metrics:     metrics,
+	}
+	schema, err := BuildClientSchemaWithOptions(context.Background(), options)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := BuildClientSchemaWithOptions(context.Background(), options); err == nil {
+		t.Fatalf("expect the second fetch to fail")
+	}
+
+	if metrics.fetches != 2 || metrics.errors != 1 || metrics.retries != 1 {
+		t.Errorf("expect 2 fetches, 1 error and 1 retry got: %d, %d and %d", metrics.fetches, metrics.errors, metrics.retries)
+	}
+	if len(metrics.durations) != 2 {
+		t.Errorf("expect 2 durations got: %v", metrics.durations)
+	}
+	if len(metrics.sizes) != 1 || metrics.sizes[0] != len(schema) {
+		t.Errorf("expect schema size: %d got: %v", len(schema), metrics.sizes)
+	}
+}
@@ -0,0 +1,56 @@
+package gqlfetch
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultConcurrency bounds the number of endpoints BuildClientSchemas fetches at once.
+const DefaultConcurrency = 4
+
+// SchemaResult is the outcome of fetching a single endpoint of BuildClientSchemas.
+type SchemaResult struct {
+	Endpoint string
+	Schema   string
+	// Hash is the SchemaHash of Schema, empty when the fetch failed.
+	Hash string
+	// Warnings are the warnings of BuildClientSchemaResult.
+	Warnings []Warning
+	Err      error
+}
+
+// BuildClientSchemas fetches the schemas of many endpoints in parallel, at most
+// DefaultConcurrency at a time. Results are returned in the order of options, a failing
+// endpoint doesn't prevent the others from being fetched.
+func BuildClientSchemas(ctx context.Context, options []BuildClientSchemaOptions) []SchemaResult {
+	return BuildClientSchemasWithConcurrency(ctx, options, DefaultConcurrency)
+}
+
+// BuildClientSchemasWithConcurrency is BuildClientSchemas fetching at most concurrency
+// endpoints at a time.
+func BuildClientSchemasWithConcurrency(ctx context.Context, options []BuildClientSchemaOptions, concurrency int) []SchemaResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]SchemaResult, len(options))
+	semaphore := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range options {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result, err := BuildClientSchemaResult(ctx, options[i])
+			results[i] = SchemaResult{Endpoint: options[i].Endpoint, Schema: result.Schema, Warnings: result.Warnings, Err: err}
+			if err == nil {
+				results[i].Hash = SchemaHash(result.Schema)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
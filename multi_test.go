@@ -0,0 +1,64 @@
+package gqlfetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBuildClientSchemasWithConcurrency(t *testing.T) {
+	body, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	var active, maxActive int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			seen := atomic.LoadInt32(&maxActive)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxActive, seen, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	var options []BuildClientSchemaOptions
+	for _, path := range []string{"/a", "/b", "/broken", "/c", "/d"} {
+		options = append(options, BuildClientSchemaOptions{Endpoint: server.URL + path, Method: http.MethodPost})
+	}
+
+	results := BuildClientSchemasWithConcurrency(context.Background(), options, 2)
+	if len(results) != len(options) {
+		t.Fatalf("expect %d results got: %d", len(options), len(results))
+	}
+	for i, result := range results {
+		if result.Endpoint != options[i].Endpoint {
+			t.Errorf("expect result %d for %s got: %s", i, options[i].Endpoint, result.Endpoint)
+		}
+		broken := i == 2
+		if (result.Err != nil) != broken {
+			t.Errorf("expect %s error: %v got: %v", result.Endpoint, broken, result.Err)
+		}
+		if !broken && result.Schema == "" {
+			t.Errorf("expect %s to have a schema", result.Endpoint)
+		}
+	}
+	if got := atomic.LoadInt32(&maxActive); got > 2 {
+		t.Errorf("expect at most 2 concurrent fetches got: %d", got)
+	}
+}
@@ -0,0 +1,96 @@
+package gqlfetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// withTypePrefix prefixes the names of the types of schema, other than the root
+// operation types and built-ins, along with every reference to them.
+func withTypePrefix(schema introspectionSchema, prefix string) (introspectionSchema, error) {
+	if !graphQLName.MatchString(prefix) || strings.HasPrefix(prefix, "__") {
+		return introspectionSchema{}, fmt.Errorf("invalid type prefix %q, expected a GraphQL name not starting with __", prefix)
+	}
+	roots := map[string]bool{schema.QueryType.Name: true, schema.MutationType.Name: true, schema.SubscriptionType.Name: true}
+	renamed := map[string]bool{}
+	for _, typ := range schema.Types {
+		renamed[typ.Name] = !roots[typ.Name] && !isBuiltinType(typ)
+	}
+
+	var rename func(ref *introspectedType) *introspectedType
+	rename = func(ref *introspectedType) *introspectedType {
+		if ref == nil {
+			return nil
+		}
+		renamedRef := *ref
+		if ref.Name != nil && renamed[*ref.Name] {
+			name := prefix + *ref.Name
+			renamedRef.Name = &name
+		}
+		renamedRef.OfType = rename(ref.OfType)
+		return &renamedRef
+	}
+	renameArgs := func(args []introspectionInputField) []introspectionInputField {
+		if args == nil {
+			return nil
+		}
+		renamedArgs := make([]introspectionInputField, len(args))
+		for i, arg := range args {
+			arg.Type = rename(arg.Type)
+			renamedArgs[i] = arg
+		}
+		return renamedArgs
+	}
+
+	directives := make([]introspectionDirectiveDefinition, len(schema.Directives))
+	for i, directive := range schema.Directives {
+		directive.Args = renameArgs(directive.Args)
+		directives[i] = directive
+	}
+	if schema.Directives != nil {
+		schema.Directives = directives
+	}
+
+	types := make([]introspectionTypeDefinition, len(schema.Types))
+	for i, typ := range schema.Types {
+		if renamed[typ.Name] {
+			typ.Name = prefix + typ.Name
+		}
+		if typ.Fields != nil {
+			fields := make([]introspectedTypeField, len(typ.Fields))
+			for j, field := range typ.Fields {
+				field.Type = rename(field.Type)
+				field.Args = renameArgs(field.Args)
+				fields[j] = field
+			}
+			typ.Fields = fields
+		}
+		typ.InputFields = renameArgs(typ.InputFields)
+		if typ.Interfaces != nil {
+			interfaces := make([]introspectedType, len(typ.Interfaces))
+			for j := range typ.Interfaces {
+				interfaces[j] = *rename(&typ.Interfaces[j])
+			}
+			typ.Interfaces = interfaces
+		}
+
+		if len(typ.PossibleTypes) > 0 && string(typ.PossibleTypes) != "null" {
+			var possible []introspectedType
+			if err := json.Unmarshal(typ.PossibleTypes, &possible); err != nil {
+				return introspectionSchema{}, fmt.Errorf("cannot unmarshal possible types: %w\n%v", err, typ.PossibleTypes)
+			}
+			for j := range possible {
+				possible[j] = *rename(&possible[j])
+			}
+			encoded, err := json.Marshal(possible)
+			if err != nil {
+				return introspectionSchema{}, fmt.Errorf("cannot marshal possible types: %w", err)
+			}
+			typ.PossibleTypes = encoded
+		}
+		types[i] = typ
+	}
+	schema.Types = types
+	return schema, nil
+}
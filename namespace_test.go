@@ -0,0 +1,91 @@
+package gqlfetch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildClientSchemaWithOptions_TypePrefix(t *testing.T) {
+	tests := map[string]struct {
+		prefix    string
+		expected  []string
+		expectErr bool
+	}{
+		"prefixed types": {
+			prefix: "Users_",
+			expected: []string{
+				"type Query {",
+				"type Mutation {",
+				"): Users_User\n",
+				"): [Users_SearchResult!]!\n",
+				"input: Users_CreateUserInput!\n",
+				"type Users_User implements Users_Node {",
+				"\tcreatedAt: Users_DateTime\n",
+				"union Users_SearchResult =Users_User",
+				"requires: Users_Role = ADMIN",
+				"scalar Users_DateTime",
+				"\tid: ID!\n",
+			},
+		},
+		"invalid prefix":  {prefix: "Users-", expectErr: true},
+		"reserved prefix": {prefix: "__", expectErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:        "file://testdata/introspection.json",
+				WithoutBuiltins: true,
+				TypePrefix:      tt.prefix,
+			})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(schema, expected) {
+					t.Errorf("expect schema to contain %q got:\n%s", expected, schema)
+				}
+			}
+			if !tt.expectErr && strings.Contains(schema, "type User ") {
+				t.Errorf("expect every type to be prefixed got:\n%s", schema)
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemas_TypePrefixMerge(t *testing.T) {
+	billing := filepath.Join(t.TempDir(), "billing.json")
+	err := os.WriteFile(billing, []byte(`{"data": {"__schema": {"queryType": {"name": "Query"}, "types": [
+		{"kind": "OBJECT", "name": "Query", "interfaces": [], "fields": [{"name": "invoices", "args": [], "type": {"kind": "LIST", "ofType": {"kind": "OBJECT", "name": "Invoice"}}}]},
+		{"kind": "OBJECT", "name": "Invoice", "interfaces": [], "fields": [{"name": "user", "args": [], "type": {"kind": "OBJECT", "name": "User"}}]},
+		{"kind": "OBJECT", "name": "User", "interfaces": [], "fields": [{"name": "id", "args": [], "type": {"kind": "SCALAR", "name": "ID"}}]},
+		{"kind": "SCALAR", "name": "ID"}
+	], "directives": []}}}`), 0o644)
+	if err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	results := BuildClientSchemas(context.Background(), []BuildClientSchemaOptions{
+		{Endpoint: "file://testdata/introspection.json", TypePrefix: "Users_"},
+		{Endpoint: "file://" + billing, TypePrefix: "Billing_"},
+	})
+	var sdls []string
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatalf("unexpected error: %v", result.Err)
+		}
+		sdls = append(sdls, result.Schema)
+	}
+
+	merged, err := MergeSchemaSDL(sdls, MergeMembers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"type Users_User implements Users_Node", "type Billing_User", "invoices: [Billing_Invoice]", "user(id: ID!): Users_User"} {
+		if !strings.Contains(merged, expected) {
+			t.Errorf("expect merged schema to contain %q got:\n%s", expected, merged)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package gqlfetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+// errPersistedQueryNotFound is matched by the GraphQLErrors of servers that don't know
+// the hash of an automatic persisted query yet.
+var errPersistedQueryNotFound = errors.New("persisted query not found")
+
+// executePersistedQuery follows the automatic persisted queries protocol: the request
+// first carries only the SHA-256 hash of the query, and is repeated with the query when
+// the server doesn't know the hash yet, which registers it.
+func executePersistedQuery(ctx context.Context, options BuildClientSchemaOptions, params requestParams, decode func(io.Reader) error) error {
+	sum := sha256.Sum256([]byte(params.Query))
+	extensions := make(map[string]interface{}, len(params.Extensions)+1)
+	for name, value := range params.Extensions {
+		extensions[name] = value
+	}
+	extensions["persistedQuery"] = map[string]interface{}{
+		"version":    1,
+		"sha256Hash": hex.EncodeToString(sum[:]),
+	}
+
+	hashed := params
+	hashed.Query = ""
+	hashed.Extensions = extensions
+	err := executeHTTP(ctx, options, hashed, decode)
+	if !errors.Is(err, errPersistedQueryNotFound) {
+		return err
+	}
+	options.debug("registering persisted query", "endpoint", options.Endpoint)
+
+	params.Extensions = extensions
+	return executeHTTP(ctx, options, params, decode)
+}
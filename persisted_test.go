@@ -0,0 +1,79 @@
+package gqlfetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestBuildClientSchemaWithOptions_PersistedQueries(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodGet} {
+		t.Run(method, func(t *testing.T) {
+			registered := map[string]bool{}
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				var params struct {
+					Query      string `json:"query"`
+					Extensions struct {
+						PersistedQuery struct {
+							Version    int    `json:"version"`
+							SHA256Hash string `json:"sha256Hash"`
+						} `json:"persistedQuery"`
+					} `json:"extensions"`
+				}
+				if r.Method == http.MethodGet {
+					params.Query = r.URL.Query().Get("query")
+					json.Unmarshal([]byte(r.URL.Query().Get("extensions")), &params.Extensions)
+				} else {
+					json.NewDecoder(r.Body).Decode(&params)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				hash := params.Extensions.PersistedQuery.SHA256Hash
+				if params.Extensions.PersistedQuery.Version != 1 || hash == "" {
+					w.Write([]byte(`{"errors": [{"message": "persisted queries are required"}]}`))
+					return
+				}
+				if params.Query != "" {
+					sum := sha256.Sum256([]byte(params.Query))
+					if hex.EncodeToString(sum[:]) != hash {
+						w.Write([]byte(`{"errors": [{"message": "provided sha does not match query"}]}`))
+						return
+					}
+					registered[hash] = true
+				}
+				if !registered[hash] {
+					w.Write([]byte(`{"errors": [{"message": "PersistedQueryNotFound", "extensions": {"code": "PERSISTED_QUERY_NOT_FOUND"}}]}`))
+					return
+				}
+				w.Write(fixture)
+			}))
+			defer server.Close()
+
+			options := BuildClientSchemaOptions{
+				Endpoint:         server.URL,
+				Method:           method,
+				PersistedQueries: true,
+			}
+			for _, expectRequests := range []int{2, 3} {
+				if _, err := BuildClientSchemaWithOptions(context.Background(), options); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if requests != expectRequests {
+					t.Errorf("expect %d requests got: %d", expectRequests, requests)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package gqlfetch
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Preset fills in the options of a well-known GraphQL API, selected by the Preset
+// option.
+type Preset struct {
+	// Endpoint is used when the options have none.
+	Endpoint string
+	// Headers are sent unless the options set the same header.
+	Headers http.Header
+	// Configure adjusts the options to the quirks of the API once Endpoint and Headers
+	// are filled in, and reports missing settings such as credentials.
+	Configure func(options *BuildClientSchemaOptions) error
+}
+
+var (
+	presetsMu sync.RWMutex
+	presets   = map[string]Preset{
+		"github": {
+			Endpoint: "https://api.github.com/graphql",
+			Headers: http.Header{
+				"User-Agent": {"gqlfetch"},
+				// Expose the schema members still in preview.
+				"Accept": {"application/vnd.github.merge-info-preview+json, application/vnd.github.update-refs-preview+json"},
+			},
+			Configure: requireCredentials("the GitHub GraphQL API requires a token", "Authorization"),
+		},
+		"gitlab": {
+			Endpoint: "https://gitlab.com/api/graphql",
+		},
+		"shopify": {
+			Configure: func(options *BuildClientSchemaOptions) error {
+				if options.Endpoint == "" {
+					return errors.New("the Shopify preset requires an endpoint such as https://{shop}.myshopify.com/admin/api/{version}/graphql.json")
+				}
+				return requireCredentials("the Shopify Admin API requires an access token", "X-Shopify-Access-Token", "X-Shopify-Storefront-Access-Token")(options)
+			},
+		},
+	}
+)
+
+// RegisterPreset makes preset available under name, replacing any preset of that name.
+func RegisterPreset(name string, preset Preset) {
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+	presets[name] = preset
+}
+
+// requireCredentials fails unless Auth or one of headers is set.
+func requireCredentials(message string, headers ...string) func(options *BuildClientSchemaOptions) error {
+	return func(options *BuildClientSchemaOptions) error {
+		if options.Auth != nil {
+			return nil
+		}
+		for _, header := range headers {
+			if options.Headers.Get(header) != "" {
+				return nil
+			}
+		}
+		return errors.New(message)
+	}
+}
+
+// withPreset returns options filled in by options.Preset.
+func (options BuildClientSchemaOptions) withPreset() (BuildClientSchemaOptions, error) {
+	if options.Preset == "" {
+		return options, nil
+	}
+	presetsMu.RLock()
+	preset, ok := presets[options.Preset]
+	presetsMu.RUnlock()
+	if !ok {
+		return options, fmt.Errorf("unknown preset %q", options.Preset)
+	}
+	options.Preset = ""
+
+	if options.Endpoint == "" {
+		options.Endpoint = preset.Endpoint
+	}
+	options.Headers = options.Headers.Clone()
+	if options.Headers == nil {
+		options.Headers = make(http.Header)
+	}
+	for name, values := range preset.Headers {
+		if _, ok := options.Headers[name]; !ok {
+			options.Headers[name] = values
+		}
+	}
+	if preset.Configure != nil {
+		if err := preset.Configure(&options); err != nil {
+			return options, err
+		}
+	}
+	return options, nil
+}
@@ -0,0 +1,70 @@
+package gqlfetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBuildClientSchemaWithOptions_Preset(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Client") != "preset" || r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+	RegisterPreset("test", Preset{
+		Endpoint:  server.URL,
+		Headers:   http.Header{"X-Client": {"preset"}, "X-Token": {"default"}},
+		Configure: requireCredentials("token required", "X-Token"),
+	})
+
+	tests := map[string]struct {
+		options   BuildClientSchemaOptions
+		expectErr string
+	}{
+		"preset fills in endpoint and headers": {
+			options: BuildClientSchemaOptions{Preset: "test", Headers: http.Header{"X-Token": {"secret"}}},
+		},
+		"preset headers don't override options": {
+			options:   BuildClientSchemaOptions{Preset: "test", Headers: http.Header{"X-Client": {"other"}, "X-Token": {"secret"}}},
+			expectErr: "401",
+		},
+		"unknown preset": {
+			options:   BuildClientSchemaOptions{Preset: "unknown"},
+			expectErr: `unknown preset "unknown"`,
+		},
+		"GitHub requires a token": {
+			options:   BuildClientSchemaOptions{Preset: "github"},
+			expectErr: "requires a token",
+		},
+		"Shopify requires an endpoint": {
+			options:   BuildClientSchemaOptions{Preset: "shopify", Headers: http.Header{"X-Shopify-Access-Token": {"token"}}},
+			expectErr: "requires an endpoint",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := BuildClientSchemaWithOptions(context.Background(), tt.options)
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("expect error containing %q got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	probeTypenameQuery      = "query ProbeTypename { __typename }"
+	probeIntrospectionQuery = "query ProbeIntrospection { __schema { queryType { name } } }"
+)
+
+// ProbeReason is why an endpoint failed a check of Probe.
+type ProbeReason string
+
+const (
+	// ProbeInvalidOptions is reported for an unknown Preset, or an endpoint that isn't
+	// an HTTP, WebSocket or unix:// URL.
+	ProbeInvalidOptions ProbeReason = "invalid_options"
+	// ProbeUnreachable is reported when no response was received.
+	ProbeUnreachable ProbeReason = "unreachable"
+	// ProbeRateLimited is reported when the endpoint asked to retry later.
+	ProbeRateLimited ProbeReason = "rate_limited"
+	// ProbeNotGraphQL is reported when the endpoint responded with something else than
+	// a GraphQL response, such as a login page.
+	ProbeNotGraphQL ProbeReason = "not_graphql"
+	// ProbeGraphQLErrors is reported when { __typename } failed with GraphQL errors,
+	// such as missing credentials.
+	ProbeGraphQLErrors ProbeReason = "graphql_errors"
+	// ProbeIntrospectionDisabled is reported when the endpoint doesn't allow
+	// introspection.
+	ProbeIntrospectionDisabled ProbeReason = "introspection_disabled"
+	// ProbeIntrospectionFailed is reported when introspection failed with other GraphQL
+	// errors.
+	ProbeIntrospectionFailed ProbeReason = "introspection_failed"
+)
+
+// ProbeResult is what Probe found out about an endpoint. Each check is only made once
+// the previous one passed.
+type ProbeResult struct {
+	Endpoint string `json:"endpoint"`
+	// Reachable reports whether the endpoint responded.
+	Reachable bool `json:"reachable"`
+	// GraphQL reports whether it answered { __typename }.
+	GraphQL bool `json:"graphql"`
+	// Introspectable reports whether it answered a minimal introspection query.
+	Introspectable bool `json:"introspectable"`
+	// Reason is why the first failing check failed, empty when every check passed.
+	Reason ProbeReason `json:"reason,omitempty"`
+	// Err is the error of the first failing check.
+	Err      error         `json:"-"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Probe checks that the endpoint of options is reachable, speaks GraphQL and allows
+// introspection with a { __typename } query followed by a minimal introspection query,
+// as a fast preflight before fetching the schema. Requests are sent once, without
+// retries or waiting for rate limits.
+func Probe(ctx context.Context, options BuildClientSchemaOptions) ProbeResult {
+	start := time.Now()
+	result := probe(ctx, options)
+	result.Duration = time.Since(start)
+	return result
+}
+
+func probe(ctx context.Context, options BuildClientSchemaOptions) ProbeResult {
+	result := ProbeResult{Endpoint: options.Endpoint}
+	options, err := options.withPreset()
+	if err == nil && isLocalEndpoint(options.Endpoint) {
+		err = fmt.Errorf("cannot probe local endpoint %s", options.Endpoint)
+	}
+	if err == nil && isUnixEndpoint(options.Endpoint) {
+		_, _, err = parseUnixEndpoint(options.Endpoint)
+	}
+	if err != nil {
+		result.Reason, result.Err = ProbeInvalidOptions, err
+		return result
+	}
+	result.Endpoint = options.Endpoint
+	options.RetryPolicy = nil
+	options.IgnoreRetryAfter = true
+
+	var typename struct {
+		Errors GraphQLErrors `json:"errors"`
+		Data   *struct {
+			Typename string `json:"__typename"`
+		} `json:"data"`
+	}
+	err = execute(ctx, options, requestParams{Query: probeTypenameQuery, OperationName: "ProbeTypename"}, func(body io.Reader) error {
+		if err := json.NewDecoder(body).Decode(&typename); err != nil {
+			return fmt.Errorf("%w: %v", ErrNonGraphQLResponse, err)
+		}
+		if len(typename.Errors) > 0 {
+			return typename.Errors
+		}
+		if typename.Data == nil || typename.Data.Typename == "" {
+			return fmt.Errorf("%w: no data.__typename in the response", ErrNonGraphQLResponse)
+		}
+		return nil
+	})
+	var gqlErrs GraphQLErrors
+	var rateLimited *RateLimitedError
+	switch {
+	case err == nil:
+		result.Reachable, result.GraphQL = true, true
+	case errors.As(err, &gqlErrs):
+		result.Reachable, result.GraphQL = true, true
+		result.Reason, result.Err = ProbeGraphQLErrors, err
+		return result
+	case errors.As(err, &rateLimited):
+		result.Reachable = true
+		result.Reason, result.Err = ProbeRateLimited, err
+		return result
+	case errors.Is(err, ErrNonGraphQLResponse):
+		result.Reachable = true
+		result.Reason, result.Err = ProbeNotGraphQL, err
+		return result
+	default:
+		result.Reason, result.Err = ProbeUnreachable, err
+		return result
+	}
+
+	var introspection struct {
+		Errors GraphQLErrors `json:"errors"`
+		Data   struct {
+			Schema *struct {
+				QueryType struct {
+					Name string `json:"name"`
+				} `json:"queryType"`
+			} `json:"__schema"`
+		} `json:"data"`
+	}
+	err = execute(ctx, options, requestParams{Query: probeIntrospectionQuery, OperationName: "ProbeIntrospection"}, func(body io.Reader) error {
+		if err := json.NewDecoder(body).Decode(&introspection); err != nil {
+			return fmt.Errorf("%w: %v", ErrNonGraphQLResponse, err)
+		}
+		if len(introspection.Errors) > 0 {
+			return introspection.Errors
+		}
+		if introspection.Data.Schema == nil {
+			return ErrIntrospectionDisabled
+		}
+		return nil
+	})
+	switch {
+	case err == nil:
+		result.Introspectable = true
+	case errors.Is(err, ErrIntrospectionDisabled):
+		result.Reason, result.Err = ProbeIntrospectionDisabled, err
+	case errors.As(err, &gqlErrs):
+		result.Reason, result.Err = ProbeIntrospectionFailed, err
+	case errors.As(err, &rateLimited):
+		result.Reason, result.Err = ProbeRateLimited, err
+	case errors.Is(err, ErrNonGraphQLResponse):
+		result.Reason, result.Err = ProbeNotGraphQL, err
+	default:
+		result.Reason, result.Err = ProbeUnreachable, err
+	}
+	return result
+}
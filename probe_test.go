@@ -0,0 +1,107 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProbe(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := map[string]struct {
+		handler          http.HandlerFunc
+		endpoint         string
+		expectReachable  bool
+		expectGraphQL    bool
+		expectIntrospect bool
+		expectReason     ProbeReason
+	}{
+		"introspectable": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				body := `{"data": {"__typename": "Query"}}`
+				var params requestParams
+				json.NewDecoder(r.Body).Decode(&params)
+				if params.OperationName == "ProbeIntrospection" {
+					body = `{"data": {"__schema": {"queryType": {"name": "Query"}}}}`
+				}
+				w.Write([]byte(body))
+			},
+			expectReachable: true, expectGraphQL: true, expectIntrospect: true,
+		},
+		"introspection disabled": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				var params requestParams
+				json.NewDecoder(r.Body).Decode(&params)
+				if params.OperationName == "ProbeIntrospection" {
+					w.Write([]byte(`{"errors": [{"message": "GraphQL introspection is not allowed"}]}`))
+					return
+				}
+				w.Write([]byte(`{"data": {"__typename": "Query"}}`))
+			},
+			expectReachable: true, expectGraphQL: true, expectReason: ProbeIntrospectionDisabled,
+		},
+		"null schema": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"data": {"__typename": "Query", "__schema": null}}`))
+			},
+			expectReachable: true, expectGraphQL: true, expectReason: ProbeIntrospectionDisabled,
+		},
+		"unauthorized": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"errors": [{"message": "Unauthorized"}]}`))
+			},
+			expectReachable: true, expectGraphQL: true, expectReason: ProbeGraphQLErrors,
+		},
+		"login page": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.Write([]byte(`<html><body>Sign in</body></html>`))
+			},
+			expectReachable: true, expectReason: ProbeNotGraphQL,
+		},
+		"json without __typename": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"status": "ok"}`))
+			},
+			expectReachable: true, expectReason: ProbeNotGraphQL,
+		},
+		"rate limited": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "60")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			expectReachable: true, expectReason: ProbeRateLimited,
+		},
+		"unreachable":    {endpoint: closed.URL, expectReason: ProbeUnreachable},
+		"local endpoint": {endpoint: "file://testdata/introspection.json", expectReason: ProbeInvalidOptions},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			endpoint := tt.endpoint
+			if tt.handler != nil {
+				server := httptest.NewServer(tt.handler)
+				defer server.Close()
+				endpoint = server.URL
+			}
+
+			result := Probe(context.Background(), BuildClientSchemaOptions{Endpoint: endpoint})
+			if result.Reachable != tt.expectReachable || result.GraphQL != tt.expectGraphQL || result.Introspectable != tt.expectIntrospect {
+				t.Errorf("expect reachable %v, GraphQL %v and introspectable %v got: %+v", tt.expectReachable, tt.expectGraphQL, tt.expectIntrospect, result)
+			}
+			if result.Reason != tt.expectReason {
+				t.Errorf("expect reason %q got: %q (%v)", tt.expectReason, result.Reason, result.Err)
+			}
+			if (result.Err != nil) != (tt.expectReason != "") {
+				t.Errorf("expect an error only with a reason got: %v", result.Err)
+			}
+			if result.Endpoint != endpoint {
+				t.Errorf("expect endpoint %s got: %s", endpoint, result.Endpoint)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package gqlfetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBuildClientSchemaWithOptions_ProxyURL(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer proxy.Close()
+
+	tests := map[string]struct {
+		proxyURL  string
+		expectErr string
+	}{
+		"http proxy":         {proxyURL: proxy.URL},
+		"unsupported scheme": {proxyURL: "ftp://proxy.internal", expectErr: `unsupported proxy scheme "ftp"`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			proxied = nil
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint: "http://graphql.internal/query",
+				ProxyURL: tt.proxyURL,
+			})
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(proxied) != 1 || proxied[0] != "http://graphql.internal/query" {
+				t.Errorf("expect the request to go through the proxy got: %v", proxied)
+			}
+		})
+	}
+}
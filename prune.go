@@ -0,0 +1,245 @@
+package gqlfetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// filterTypes keeps the types selected by IncludeTypes and ExcludeTypes. Root operation
+// types and built-ins are only removed when excluded explicitly.
+func filterTypes(schema introspectionSchema, include, exclude []string) (introspectionSchema, error) {
+	for _, pattern := range append(append([]string(nil), include...), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return introspectionSchema{}, fmt.Errorf("invalid type pattern %q: %w", pattern, err)
+		}
+	}
+	roots := map[string]bool{schema.QueryType.Name: true, schema.MutationType.Name: true, schema.SubscriptionType.Name: true}
+
+	keep := map[string]bool{}
+	for _, typ := range schema.Types {
+		kept := len(include) == 0 || roots[typ.Name] || isBuiltinType(typ) || matchesAny(typ.Name, include)
+		keep[typ.Name] = kept && !matchesAny(typ.Name, exclude)
+	}
+	return pruneSchema(schema, keep)
+}
+
+// isBuiltinType reports whether typ is an introspection type or a built-in scalar.
+func isBuiltinType(typ introspectionTypeDefinition) bool {
+	return strings.HasPrefix(typ.Name, "__") || typ.Kind == ast.Scalar && containsStr(typ.Name, excludeScalarTypes)
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pruneSchema removes the types not in keep and every reference to them: fields and
+// optional arguments of a removed type, interfaces and union members. Fields with a
+// required argument of a removed type and types left empty are removed as well, which
+// repeats until nothing refers to a removed type.
+func pruneSchema(schema introspectionSchema, keep map[string]bool) (introspectionSchema, error) {
+	for {
+		types, removed, err := pruneTypes(schema.Types, keep)
+		if err != nil {
+			return introspectionSchema{}, err
+		}
+		schema.Types = types
+		if !removed {
+			break
+		}
+	}
+
+	if !keep[schema.QueryType.Name] {
+		return introspectionSchema{}, fmt.Errorf("query type %s was pruned from the schema", schema.QueryType.Name)
+	}
+	if !keep[schema.MutationType.Name] {
+		schema.MutationType = introspectionRootType{}
+	}
+	if !keep[schema.SubscriptionType.Name] {
+		schema.SubscriptionType = introspectionRootType{}
+	}
+
+	directives := make([]introspectionDirectiveDefinition, 0, len(schema.Directives))
+	for _, directive := range schema.Directives {
+		args, ok := pruneArgs(directive.Args, keep)
+		if ok {
+			directive.Args = args
+			directives = append(directives, directive)
+		}
+	}
+	schema.Directives = directives
+	return schema, nil
+}
+
+// pruneTypes does one pass of pruneSchema, reporting whether it removed more types.
+func pruneTypes(types []introspectionTypeDefinition, keep map[string]bool) ([]introspectionTypeDefinition, bool, error) {
+	removed := false
+	remove := func(name string) {
+		keep[name] = false
+		removed = true
+	}
+
+	var pruned []introspectionTypeDefinition
+	for _, typ := range types {
+		if !keep[typ.Name] {
+			continue
+		}
+
+		if typ.Fields != nil {
+			fields := []introspectedTypeField{}
+			for _, field := range typ.Fields {
+				args, ok := pruneArgs(field.Args, keep)
+				if ok && keep[namedType(field.Type)] {
+					field.Args = args
+					fields = append(fields, field)
+				}
+			}
+			if len(fields) == 0 && len(typ.Fields) > 0 {
+				remove(typ.Name)
+				continue
+			}
+			typ.Fields = fields
+		}
+
+		if typ.InputFields != nil {
+			fields, ok := pruneArgs(typ.InputFields, keep)
+			if !ok || len(fields) == 0 && len(typ.InputFields) > 0 {
+				remove(typ.Name)
+				continue
+			}
+			typ.InputFields = fields
+		}
+
+		if typ.Interfaces != nil {
+			interfaces := []introspectedType{}
+			for _, ref := range typ.Interfaces {
+				if keep[typeRefName(ref)] {
+					interfaces = append(interfaces, ref)
+				}
+			}
+			typ.Interfaces = interfaces
+		}
+
+		if len(typ.PossibleTypes) > 0 && string(typ.PossibleTypes) != "null" {
+			var possible []introspectedType
+			if err := json.Unmarshal(typ.PossibleTypes, &possible); err != nil {
+				return nil, false, fmt.Errorf("cannot unmarshal possible types: %w\n%v", err, typ.PossibleTypes)
+			}
+			kept := []introspectedType{}
+			for _, ref := range possible {
+				if keep[typeRefName(ref)] {
+					kept = append(kept, ref)
+				}
+			}
+			if typ.Kind == ast.Union && len(kept) == 0 && len(possible) > 0 {
+				remove(typ.Name)
+				continue
+			}
+			encoded, err := json.Marshal(kept)
+			if err != nil {
+				return nil, false, fmt.Errorf("cannot marshal possible types: %w", err)
+			}
+			typ.PossibleTypes = encoded
+		}
+
+		pruned = append(pruned, typ)
+	}
+	return pruned, removed, nil
+}
+
+// pruneArgs removes the arguments or input fields of a removed type, reporting false
+// when one of them is required.
+func pruneArgs(args []introspectionInputField, keep map[string]bool) ([]introspectionInputField, bool) {
+	if args == nil {
+		return nil, true
+	}
+	pruned := []introspectionInputField{}
+	for _, arg := range args {
+		if keep[namedType(arg.Type)] {
+			pruned = append(pruned, arg)
+			continue
+		}
+		if arg.Type != nil && arg.Type.Kind == NON_NULL && arg.DefaultValue == nil {
+			return nil, false
+		}
+	}
+	return pruned, true
+}
+
+// namedType returns the name of the type wrapped by lists and non-nulls.
+func namedType(ref *introspectedType) string {
+	for ref != nil && ref.OfType != nil {
+		ref = ref.OfType
+	}
+	if ref == nil {
+		return ""
+	}
+	return typeRefName(*ref)
+}
+
+// withoutUnreachableTypes removes the types that can't be reached from the root
+// operation types through fields, arguments, input fields, interfaces and possible
+// types, nor from directive arguments. Built-ins are kept.
+func withoutUnreachableTypes(schema introspectionSchema) (introspectionSchema, error) {
+	types := map[string]introspectionTypeDefinition{}
+	for _, typ := range schema.Types {
+		types[typ.Name] = typ
+	}
+
+	keep := map[string]bool{}
+	var queue []string
+	visit := func(name string) {
+		if _, ok := types[name]; ok && !keep[name] {
+			keep[name] = true
+			queue = append(queue, name)
+		}
+	}
+	visitArgs := func(args []introspectionInputField) {
+		for _, arg := range args {
+			visit(namedType(arg.Type))
+		}
+	}
+
+	for _, typ := range schema.Types {
+		if isBuiltinType(typ) {
+			visit(typ.Name)
+		}
+	}
+	visit(schema.QueryType.Name)
+	visit(schema.MutationType.Name)
+	visit(schema.SubscriptionType.Name)
+	for _, directive := range schema.Directives {
+		visitArgs(directive.Args)
+	}
+
+	for len(queue) > 0 {
+		typ := types[queue[0]]
+		queue = queue[1:]
+		for _, field := range typ.Fields {
+			visit(namedType(field.Type))
+			visitArgs(field.Args)
+		}
+		visitArgs(typ.InputFields)
+		for _, ref := range typ.Interfaces {
+			visit(typeRefName(ref))
+		}
+		if len(typ.PossibleTypes) > 0 && string(typ.PossibleTypes) != "null" {
+			var possible []introspectedType
+			if err := json.Unmarshal(typ.PossibleTypes, &possible); err != nil {
+				return introspectionSchema{}, fmt.Errorf("cannot unmarshal possible types: %w\n%v", err, typ.PossibleTypes)
+			}
+			for _, ref := range possible {
+				visit(typeRefName(ref))
+			}
+		}
+	}
+	return pruneSchema(schema, keep)
+}
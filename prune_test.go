@@ -0,0 +1,110 @@
+package gqlfetch
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildClientSchemaWithOptions_FilterTypes(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+
+	tests := map[string]struct {
+		include, exclude []string
+		expectContains   []string
+		expectMissing    []string
+		expectErr        string
+	}{
+		"exclude": {
+			exclude:        []string{"User"},
+			expectContains: []string{"interface Node", "): Node"},
+			expectMissing:  []string{"type User", "union SearchResult", "type Mutation", "search("},
+		},
+		"include": {
+			include:        []string{"Node"},
+			expectContains: []string{"type Query", "interface Node"},
+			expectMissing:  []string{"type User", "enum Role", "input CreateUserInput", "scalar DateTime", "type Mutation"},
+		},
+		"glob": {
+			exclude:        []string{"*Input"},
+			expectContains: []string{"type User", "enum Role"},
+			expectMissing:  []string{"input CreateUserInput", "createUser"},
+		},
+		"excluded query": {
+			exclude:   []string{"Query"},
+			expectErr: "query type Query was pruned",
+		},
+		"invalid pattern": {
+			exclude:   []string{"["},
+			expectErr: `invalid type pattern "["`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:     server.URL,
+				IncludeTypes: tt.include,
+				ExcludeTypes: tt.exclude,
+			})
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expect := range tt.expectContains {
+				if !strings.Contains(schema, expect) {
+					t.Errorf("expect schema to contain %q got:\n%v", expect, schema)
+				}
+			}
+			for _, expect := range tt.expectMissing {
+				if strings.Contains(schema, expect) {
+					t.Errorf("expect schema not to contain %q got:\n%v", expect, schema)
+				}
+			}
+		})
+	}
+}
+
+func Test_withoutUnreachableTypes(t *testing.T) {
+	file, err := os.Open("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer file.Close()
+	schema, err := decodeIntrospection(context.Background(), file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reachable := map[string]bool{}
+	for _, typ := range schema.Types {
+		reachable[typ.Name] = true
+	}
+	str := "String"
+	schema.Types = append(schema.Types,
+		introspectionTypeDefinition{Kind: "OBJECT", Name: "Orphan", Fields: []introspectedTypeField{
+			{Name: "filter", Type: &introspectedType{Kind: "SCALAR", Name: &str}},
+		}},
+		introspectionTypeDefinition{Kind: "INPUT_OBJECT", Name: "OrphanFilter", InputFields: []introspectionInputField{
+			{Name: "term", Type: &introspectedType{Kind: "SCALAR", Name: &str}},
+		}},
+	)
+
+	pruned, err := withoutUnreachableTypes(schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := map[string]bool{}
+	for _, typ := range pruned.Types {
+		got[typ.Name] = true
+	}
+	if !reflect.DeepEqual(got, reachable) {
+		t.Errorf("expect types: %v got: %v", reachable, got)
+	}
+}
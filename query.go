@@ -0,0 +1,50 @@
+package gqlfetch
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+//go:embed introspect.graphql
+var introspectSchema string
+
+// introspectionOperationName is the name of the operation in introspect.graphql.
+const introspectionOperationName = "IntrospectionQuery"
+
+var introspectionQueryTemplate = template.Must(template.New("introspect.graphql").Parse(introspectSchema))
+
+// introspectionQueryOptions toggles the parts of the introspection query that not every
+// server understands.
+type introspectionQueryOptions struct {
+	// InputValueDeprecation requests deprecation details for arguments and input fields.
+	InputValueDeprecation bool
+	// DirectiveIsRepeatable requests the isRepeatable field of directives.
+	DirectiveIsRepeatable bool
+	// SpecifiedByURL requests the specifiedByURL field of types.
+	SpecifiedByURL bool
+	// OneOf requests the isOneOf field of types.
+	OneOf bool
+	// AppliedDirectives requests the appliedDirectives extension field of types, fields,
+	// arguments, input fields and enum values.
+	AppliedDirectives bool
+	// WithoutDescriptions leaves out the descriptions of every schema member.
+	WithoutDescriptions bool
+	// WithoutDirectives leaves out the directive definitions.
+	WithoutDirectives bool
+	// WithoutDeprecated leaves out deprecated fields, enum values, arguments and input
+	// fields.
+	WithoutDeprecated bool
+	// TypeName queries __type(name:) for this type instead of the whole __schema. It
+	// must be a valid GraphQL name, as it is inlined in the query.
+	TypeName string
+}
+
+func introspectionQuery(options introspectionQueryOptions) (string, error) {
+	sb := &strings.Builder{}
+	if err := introspectionQueryTemplate.Execute(sb, options); err != nil {
+		return "", fmt.Errorf("failed to render introspection query: %w", err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,136 @@
+package gqlfetch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket bounding the rate of introspection requests. A single
+// RateLimit can be shared by the options of many fetches, such as the endpoints of
+// BuildClientSchemas hitting the same API, or a Watcher polling frequently.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained rate, no limit is applied when not positive.
+	RequestsPerSecond float64
+	// Burst is the number of requests that can be sent at once, at least 1.
+	Burst int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimit returns a RateLimit allowing requestsPerSecond with bursts of burst
+// requests.
+func NewRateLimit(requestsPerSecond float64, burst int) *RateLimit {
+	return &RateLimit{RequestsPerSecond: requestsPerSecond, Burst: burst}
+}
+
+// wait blocks until a request may be sent or ctx is done.
+func (l *RateLimit) wait(ctx context.Context) error {
+	if l == nil || l.RequestsPerSecond <= 0 {
+		return nil
+	}
+	return sleep(ctx, l.reserve(time.Now()))
+}
+
+// reserve takes a token, returning how long to wait before it's available.
+func (l *RateLimit) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	burst := float64(l.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	if l.last.IsZero() {
+		l.tokens = burst
+	} else if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.RequestsPerSecond
+		if l.tokens > burst {
+			l.tokens = burst
+		}
+	}
+	if now.After(l.last) {
+		l.last = now
+	}
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.RequestsPerSecond * float64(time.Second))
+}
+
+// RateLimitedError is returned when the endpoint answers 429 Too Many Requests and the
+// request isn't retried, or ran out of attempts.
+type RateLimitedError struct {
+	// RetryAfter is the delay requested by the Retry-After header, zero when absent.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited by the endpoint, retry after %s", e.RetryAfter)
+	}
+	return "rate limited by the endpoint"
+}
+
+// maxRateLimitWaits bounds the number of times a request waits for the rate limit of
+// the endpoint to reset.
+const maxRateLimitWaits = 5
+
+// rateLimitDelay returns how long a 429 or 503 response asks to wait before retrying,
+// according to its Retry-After or X-RateLimit-Reset header, zero when it doesn't say.
+func rateLimitDelay(res *http.Response) time.Duration {
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	if delay := retryAfter(res.Header); delay > 0 {
+		return delay
+	}
+	return rateLimitReset(res.Header)
+}
+
+// retryAfter parses the Retry-After header, given in seconds or as an HTTP date.
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// rateLimitReset parses the X-RateLimit-Reset header, which APIs such as GitHub's send
+// as a Unix timestamp, and others as a number of seconds.
+func rateLimitReset(header http.Header) time.Duration {
+	value, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || value <= 0 {
+		return 0
+	}
+	// Timestamps are told apart from delays by being after 2001.
+	if value > 1e9 {
+		if delay := time.Until(time.Unix(value, 0)); delay > 0 {
+			return delay
+		}
+		return 0
+	}
+	return time.Duration(value) * time.Second
+}
+
+// withinDeadline reports whether ctx lasts longer than delay.
+func withinDeadline(ctx context.Context, delay time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > delay
+}
@@ -0,0 +1,119 @@
+package gqlfetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRateLimit_reserve(t *testing.T) {
+	limit := NewRateLimit(2, 2)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		at     time.Duration
+		expect time.Duration
+	}{
+		{at: 0, expect: 0},
+		{at: 0, expect: 0},
+		{at: 0, expect: 500 * time.Millisecond},
+		{at: 0, expect: time.Second},
+		{at: 2 * time.Second, expect: 0},
+	}
+	for i, tt := range tests {
+		if got := limit.reserve(start.Add(tt.at)); got != tt.expect {
+			t.Errorf("request %d: expect delay %v got: %v", i, tt.expect, got)
+		}
+	}
+}
+
+func TestBuildClientSchemaWithOptions_TooManyRequests(t *testing.T) {
+	body, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	tests := map[string]struct {
+		status      int
+		header      http.Header
+		options     BuildClientSchemaOptions
+		timeout     time.Duration
+		expectCalls int
+		expectRetry time.Duration
+		expectErr   bool
+	}{
+		"waits for Retry-After": {
+			status:      http.StatusTooManyRequests,
+			header:      http.Header{"Retry-After": {"1"}},
+			expectCalls: 2,
+		},
+		"waits for X-RateLimit-Reset": {
+			status:      http.StatusServiceUnavailable,
+			header:      http.Header{"X-Ratelimit-Reset": {"1"}},
+			expectCalls: 2,
+		},
+		"retry disabled": {
+			status:      http.StatusTooManyRequests,
+			header:      http.Header{"Retry-After": {"1"}},
+			options:     BuildClientSchemaOptions{IgnoreRetryAfter: true},
+			expectCalls: 1,
+			expectRetry: time.Second,
+			expectErr:   true,
+		},
+		"wait exceeds the deadline": {
+			status:      http.StatusTooManyRequests,
+			header:      http.Header{"Retry-After": {"60"}},
+			timeout:     time.Second,
+			expectCalls: 1,
+			expectRetry: time.Minute,
+			expectErr:   true,
+		},
+		"policy retries without Retry-After": {
+			status:      http.StatusTooManyRequests,
+			options:     BuildClientSchemaOptions{RetryPolicy: &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}},
+			expectCalls: 2,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls == 1 {
+					for name, values := range tt.header {
+						w.Header()[name] = values
+					}
+					w.WriteHeader(tt.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = 10 * time.Second
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			options := tt.options
+			options.Endpoint = server.URL
+			_, err := BuildClientSchemaWithOptions(ctx, options)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if calls != tt.expectCalls {
+				t.Errorf("expect %d calls got: %d", tt.expectCalls, calls)
+			}
+			var limited *RateLimitedError
+			if tt.expectErr && (!errors.As(err, &limited) || limited.RetryAfter != tt.expectRetry) {
+				t.Errorf("expect a RateLimitedError retrying after %v got: %v", tt.expectRetry, err)
+			}
+		})
+	}
+}
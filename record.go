@@ -0,0 +1,62 @@
+package gqlfetch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// RecordTransport sends requests with next and writes the body of every successful
+// response to path, decompressed, so it can be served back by ReplayTransport in
+// hermetic tests. When several requests are sent, path holds the last response.
+func RecordTransport(next Transport, path string) Transport {
+	return TransportFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		res, err := next.Execute(ctx, req)
+		if err != nil || res.StatusCode < 200 || res.StatusCode > 299 {
+			return res, err
+		}
+		defer res.Body.Close()
+
+		reader, err := decompressResponse(res)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		if err := writeFileAtomic(path, body); err != nil {
+			return nil, fmt.Errorf("failed to record response: %w", err)
+		}
+
+		res.Header.Del("Content-Encoding")
+		res.ContentLength = int64(len(body))
+		res.Body = io.NopCloser(bytes.NewReader(body))
+		return res, nil
+	})
+}
+
+// ReplayTransport answers every request with the response recorded at path by
+// RecordTransport, without any network access.
+func ReplayTransport(path string) Transport {
+	return TransportFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recorded response: %w", err)
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	})
+}
@@ -0,0 +1,39 @@
+package gqlfetch
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordAndReplayTransport(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+	path := filepath.Join(t.TempDir(), "introspection.json")
+
+	recorded, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint:  server.URL,
+		Transport: RecordTransport(HTTPTransport{}, path),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.Close()
+
+	replayed, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint:  server.URL,
+		Transport: ReplayTransport(path),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replayed != recorded {
+		t.Errorf("expect the replayed schema:\n%v\ngot:\n%v", recorded, replayed)
+	}
+
+	if _, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint:  server.URL,
+		Transport: ReplayTransport(filepath.Join(t.TempDir(), "missing.json")),
+	}); err == nil {
+		t.Errorf("expect an error replaying a missing recording")
+	}
+}
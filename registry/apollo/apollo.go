@@ -0,0 +1,141 @@
+// Package apollo fetches schemas from and publishes schemas to the Apollo GraphOS
+// (Apollo Studio) schema registry, without needing rover.
+package apollo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/zucchinho/gqlfetch/internal/graphqlhttp"
+)
+
+// DefaultEndpoint is the Apollo Platform API.
+const DefaultEndpoint = "https://api.apollographql.com/api/graphql"
+
+// Client talks to the Apollo Platform API with a graph or user API key.
+type Client struct {
+	APIKey string
+	// Endpoint defaults to DefaultEndpoint.
+	Endpoint string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// ParseGraphRef splits a graph ref such as my-graph@staging into the graph ID and the
+// variant, which defaults to current.
+func ParseGraphRef(graphRef string) (graphID, variant string, err error) {
+	graphID, variant = graphRef, "current"
+	if i := strings.Index(graphRef, "@"); i >= 0 {
+		graphID, variant = graphRef[:i], graphRef[i+1:]
+	}
+	if graphID == "" || variant == "" {
+		return "", "", fmt.Errorf("invalid graph ref %q, expected graph@variant", graphRef)
+	}
+	return graphID, variant, nil
+}
+
+const fetchQuery = `query GraphFetchQuery($graphRef: ID!) {
+  variant(ref: $graphRef) {
+    __typename
+    ... on GraphVariant {
+      latestPublication {
+        schema {
+          document
+        }
+      }
+    }
+    ... on InvalidRefFormat {
+      message
+    }
+  }
+}`
+
+// FetchSchema returns the SDL of the latest schema published to the variant named by
+// graphRef.
+func (c *Client) FetchSchema(ctx context.Context, graphRef string) (string, error) {
+	graphID, variant, err := ParseGraphRef(graphRef)
+	if err != nil {
+		return "", err
+	}
+	graphRef = graphID + "@" + variant
+	var data struct {
+		Variant *struct {
+			Typename          string `json:"__typename"`
+			Message           string `json:"message"`
+			LatestPublication *struct {
+				Schema struct {
+					Document string `json:"document"`
+				} `json:"schema"`
+			} `json:"latestPublication"`
+		} `json:"variant"`
+	}
+	if err := c.do(ctx, fetchQuery, map[string]interface{}{"graphRef": graphRef}, &data); err != nil {
+		return "", fmt.Errorf("failed to fetch %s from Apollo: %w", graphRef, err)
+	}
+	switch {
+	case data.Variant == nil:
+		return "", fmt.Errorf("graph variant %s not found", graphRef)
+	case data.Variant.Typename == "InvalidRefFormat":
+		return "", fmt.Errorf("invalid graph ref %s: %s", graphRef, data.Variant.Message)
+	case data.Variant.LatestPublication == nil:
+		return "", fmt.Errorf("no schema has been published to %s", graphRef)
+	}
+	return data.Variant.LatestPublication.Schema.Document, nil
+}
+
+const publishMutation = `mutation GraphPublishMutation($graphId: ID!, $variant: String!, $schema: String!) {
+  graph(id: $graphId) {
+    uploadSchema(schemaDocument: $schema, tag: $variant) {
+      code
+      message
+      success
+    }
+  }
+}`
+
+// PublishSchema publishes sdl as the schema of the non-federated variant named by
+// graphRef.
+func (c *Client) PublishSchema(ctx context.Context, graphRef, sdl string) error {
+	graphID, variant, err := ParseGraphRef(graphRef)
+	if err != nil {
+		return err
+	}
+	var data struct {
+		Graph *struct {
+			UploadSchema *struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+				Success bool   `json:"success"`
+			} `json:"uploadSchema"`
+		} `json:"graph"`
+	}
+	variables := map[string]interface{}{"graphId": graphID, "variant": variant, "schema": sdl}
+	if err := c.do(ctx, publishMutation, variables, &data); err != nil {
+		return fmt.Errorf("failed to publish %s to Apollo: %w", graphRef, err)
+	}
+	if data.Graph == nil || data.Graph.UploadSchema == nil {
+		return fmt.Errorf("graph %s not found", graphID)
+	}
+	if !data.Graph.UploadSchema.Success {
+		return fmt.Errorf("failed to publish %s to Apollo: %s: %s", graphRef, data.Graph.UploadSchema.Code, data.Graph.UploadSchema.Message)
+	}
+	return nil
+}
+
+func (c *Client) do(ctx context.Context, query string, variables map[string]interface{}, data interface{}) error {
+	if c.APIKey == "" {
+		return errors.New("an Apollo API key is required")
+	}
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	header := http.Header{
+		"X-Api-Key":                 {c.APIKey},
+		"Apollographql-Client-Name": {"gqlfetch"},
+	}
+	return graphqlhttp.Do(ctx, c.HTTPClient, endpoint, header, query, variables, data)
+}
@@ -0,0 +1,97 @@
+package apollo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_FetchSchema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "service:key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Variables map[string]string `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch body.Variables["graphRef"] {
+		case "graph@current":
+			w.Write([]byte(`{"data": {"variant": {"__typename": "GraphVariant", "latestPublication": {"schema": {"document": "type Query { a: Int }"}}}}}`))
+		case "graph@empty":
+			w.Write([]byte(`{"data": {"variant": {"__typename": "GraphVariant", "latestPublication": null}}}`))
+		default:
+			w.Write([]byte(`{"data": {"variant": null}}`))
+		}
+	}))
+	defer server.Close()
+
+	tests := map[string]struct {
+		graphRef  string
+		apiKey    string
+		expect    string
+		expectErr string
+	}{
+		"default variant":    {graphRef: "graph", apiKey: "service:key", expect: "type Query { a: Int }"},
+		"unpublished":        {graphRef: "graph@empty", apiKey: "service:key", expectErr: "no schema has been published"},
+		"unknown variant":    {graphRef: "graph@missing", apiKey: "service:key", expectErr: "not found"},
+		"invalid graph ref":  {graphRef: "@current", apiKey: "service:key", expectErr: "invalid graph ref"},
+		"invalid API key":    {graphRef: "graph@current", apiKey: "wrong", expectErr: "401 Unauthorized"},
+		"API key is missing": {graphRef: "graph@current", expectErr: "API key is required"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := &Client{APIKey: tt.apiKey, Endpoint: server.URL}
+			got, err := client.FetchSchema(context.Background(), tt.graphRef)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing %q got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expect {
+				t.Errorf("expect %q got: %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestClient_PublishSchema(t *testing.T) {
+	var variables map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables map[string]string `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		variables = body.Variables
+		if variables["variant"] == "locked" {
+			w.Write([]byte(`{"data": {"graph": {"uploadSchema": {"code": "FORBIDDEN", "message": "variant is protected", "success": false}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"graph": {"uploadSchema": {"code": "UPLOAD_SUCCESS", "message": "", "success": true}}}}`))
+	}))
+	defer server.Close()
+
+	client := &Client{APIKey: "service:key", Endpoint: server.URL}
+	if err := client.PublishSchema(context.Background(), "graph@staging", "type Query { a: Int }"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := map[string]string{"graphId": "graph", "variant": "staging", "schema": "type Query { a: Int }"}
+	for key, value := range expect {
+		if variables[key] != value {
+			t.Errorf("expect %s: %q got: %q", key, value, variables[key])
+		}
+	}
+
+	err := client.PublishSchema(context.Background(), "graph@locked", "type Query { a: Int }")
+	if err == nil || !strings.Contains(err.Error(), "variant is protected") {
+		t.Errorf("expect the publish to be rejected got: %v", err)
+	}
+}
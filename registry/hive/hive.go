@@ -0,0 +1,160 @@
+// Package hive fetches schemas from the GraphQL Hive CDN and checks schemas against the
+// GraphQL Hive registry.
+package hive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/zucchinho/gqlfetch/internal/graphqlhttp"
+)
+
+// DefaultEndpoint is the GraphQL Hive registry API.
+const DefaultEndpoint = "https://app.graphql-hive.com/graphql"
+
+// CDN serves the latest valid schema of a target.
+type CDN struct {
+	// Endpoint is the CDN endpoint of the target, such as
+	// https://cdn.graphql-hive.com/artifacts/v1/<target id>.
+	Endpoint string
+	// Key is a CDN access key of the target.
+	Key string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// FetchSchema returns the SDL of the latest valid schema of the target.
+func (c *CDN) FetchSchema(ctx context.Context) (string, error) {
+	if c.Endpoint == "" || c.Key == "" {
+		return "", errors.New("a Hive CDN endpoint and key are required")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.Endpoint, "/")+"/sdl", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-Hive-CDN-Key", c.Key)
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch schema from Hive: %w", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read schema from Hive: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch schema from Hive: %s: %s", res.Status, bytes.TrimSpace(body))
+	}
+	return string(body), nil
+}
+
+// Registry checks schemas with a registry access token of a target.
+type Registry struct {
+	Token string
+	// Endpoint defaults to DefaultEndpoint.
+	Endpoint string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// SchemaChange is a difference between the checked schema and the latest valid one.
+type SchemaChange struct {
+	Message string `json:"message"`
+	// Criticality is BREAKING, DANGEROUS or SAFE.
+	Criticality string `json:"criticality"`
+}
+
+// CheckResult is the result of a schema check. The schema can be published when Valid.
+type CheckResult struct {
+	Valid   bool
+	Changes []SchemaChange
+	Errors  []string
+}
+
+const checkMutation = `mutation schemaCheck($input: SchemaCheckInput!) {
+  schemaCheck(input: $input) {
+    __typename
+    ... on SchemaCheckSuccess {
+      valid
+      changes {
+        nodes {
+          message
+          criticality
+        }
+      }
+    }
+    ... on SchemaCheckError {
+      valid
+      changes {
+        nodes {
+          message
+          criticality
+        }
+      }
+      errors {
+        nodes {
+          message
+        }
+      }
+    }
+  }
+}`
+
+// CheckSchema checks sdl against the latest valid schema of the target, for the
+// subgraph service when the target is federated.
+func (r *Registry) CheckSchema(ctx context.Context, sdl, service string) (CheckResult, error) {
+	if r.Token == "" {
+		return CheckResult{}, errors.New("a Hive registry token is required")
+	}
+	endpoint := r.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	input := map[string]interface{}{"sdl": sdl}
+	if service != "" {
+		input["service"] = service
+	}
+
+	var data struct {
+		SchemaCheck *struct {
+			Valid   bool `json:"valid"`
+			Changes *struct {
+				Nodes []SchemaChange `json:"nodes"`
+			} `json:"changes"`
+			Errors *struct {
+				Nodes []struct {
+					Message string `json:"message"`
+				} `json:"nodes"`
+			} `json:"errors"`
+		} `json:"schemaCheck"`
+	}
+	header := http.Header{"Authorization": {"Bearer " + r.Token}}
+	err := graphqlhttp.Do(ctx, r.HTTPClient, endpoint, header, checkMutation, map[string]interface{}{"input": input}, &data)
+	if err != nil {
+		return CheckResult{}, fmt.Errorf("failed to check schema with Hive: %w", err)
+	}
+	if data.SchemaCheck == nil {
+		return CheckResult{}, errors.New("failed to check schema with Hive: empty result")
+	}
+
+	result := CheckResult{Valid: data.SchemaCheck.Valid}
+	if data.SchemaCheck.Changes != nil {
+		result.Changes = data.SchemaCheck.Changes.Nodes
+	}
+	if data.SchemaCheck.Errors != nil {
+		for _, node := range data.SchemaCheck.Errors.Nodes {
+			result.Errors = append(result.Errors, node.Message)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package hive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCDN_FetchSchema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/artifacts/v1/target/sdl" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("X-Hive-CDN-Key") != "key" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("invalid CDN key"))
+			return
+		}
+		w.Write([]byte("type Query { a: Int }"))
+	}))
+	defer server.Close()
+
+	tests := map[string]struct {
+		key       string
+		expect    string
+		expectErr string
+	}{
+		"valid key":   {key: "key", expect: "type Query { a: Int }"},
+		"invalid key": {key: "wrong", expectErr: "403 Forbidden: invalid CDN key"},
+		"missing key": {expectErr: "key are required"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cdn := &CDN{Endpoint: server.URL + "/artifacts/v1/target/", Key: tt.key}
+			got, err := cdn.FetchSchema(context.Background())
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing %q got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expect {
+				t.Errorf("expect %q got: %q", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestRegistry_CheckSchema(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body struct {
+			Variables struct {
+				Input map[string]string `json:"input"`
+			} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Variables.Input["service"] == "users" {
+			w.Write([]byte(`{"data": {"schemaCheck": {"__typename": "SchemaCheckError", "valid": false,
+				"changes": {"nodes": [{"message": "Field 'a' was removed from object type 'Query'", "criticality": "BREAKING"}]},
+				"errors": {"nodes": [{"message": "Breaking change detected"}]}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"schemaCheck": {"__typename": "SchemaCheckSuccess", "valid": true, "changes": {"nodes": []}}}}`))
+	}))
+	defer server.Close()
+
+	tests := map[string]struct {
+		service string
+		expect  CheckResult
+	}{
+		"valid schema": {expect: CheckResult{Valid: true, Changes: []SchemaChange{}}},
+		"breaking change": {service: "users", expect: CheckResult{
+			Changes: []SchemaChange{{Message: "Field 'a' was removed from object type 'Query'", Criticality: "BREAKING"}},
+			Errors:  []string{"Breaking change detected"},
+		}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			registry := &Registry{Token: "token", Endpoint: server.URL}
+			got, err := registry.CheckSchema(context.Background(), "type Query { b: Int }", tt.service)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expect) {
+				t.Errorf("expect %+v got: %+v", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package gqlfetch
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// requestParams are the GraphQL request parameters sent to the endpoint.
+type requestParams struct {
+	// Query is left out of the first request of automatic persisted queries.
+	Query         string                 `json:"query,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// ResponseInfo describes an HTTP response received from the endpoint.
+type ResponseInfo struct {
+	// Attempt is the 1-based attempt the response belongs to.
+	Attempt    int
+	StatusCode int
+	Header     http.Header
+	// ContentLength is -1 when unknown.
+	ContentLength int64
+	// Duration is the time since the fetch started. For the final response it includes
+	// reading and decoding the body.
+	Duration time.Duration
+}
+
+// execute sends a GraphQL request to the endpoint described by options, retrying
+// according to its RetryPolicy, and hands the response body to decode.
+func execute(ctx context.Context, options BuildClientSchemaOptions, params requestParams, decode func(io.Reader) error) error {
+	ctx, cancel := withTimeout(ctx, options)
+	defer cancel()
+
+	params = withRequestOptions(params, options)
+	options, err := login(ctx, options)
+	if err != nil {
+		return err
+	}
+	if isWebSocketEndpoint(options.Endpoint) {
+		return executeWebSocket(ctx, options, params, decode)
+	}
+	if options.PersistedQueries {
+		return executePersistedQuery(ctx, options, params, decode)
+	}
+	return executeHTTP(ctx, options, params, decode)
+}
+
+// withTimeout bounds ctx by options.Timeout, or by DefaultTimeout when neither sets a
+// deadline.
+func withTimeout(ctx context.Context, options BuildClientSchemaOptions) (context.Context, context.CancelFunc) {
+	timeout := options.Timeout
+	if _, ok := ctx.Deadline(); !ok && timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// withRequestOptions adds options.Variables and options.Extensions to params, keeping
+// the extensions params already has.
+func withRequestOptions(params requestParams, options BuildClientSchemaOptions) requestParams {
+	if params.Variables == nil {
+		params.Variables = options.Variables
+	}
+	if len(options.Extensions) > 0 {
+		extensions := make(map[string]interface{}, len(options.Extensions)+len(params.Extensions))
+		for name, value := range options.Extensions {
+			extensions[name] = value
+		}
+		for name, value := range params.Extensions {
+			extensions[name] = value
+		}
+		params.Extensions = extensions
+	}
+	return params
+}
+
+// executeHTTP sends params over HTTP, retrying according to options.RetryPolicy.
+func executeHTTP(ctx context.Context, options BuildClientSchemaOptions, params requestParams, decode func(io.Reader) error) error {
+	// If no headers are provided, create an empty header map, so we can add the content type header
+	if options.Headers == nil {
+		options.Headers = make(http.Header)
+	}
+
+	transport, err := options.transport()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	onResponse := func(attempt int, res *http.Response) {
+		if options.OnResponse != nil {
+			options.OnResponse(ResponseInfo{
+				Attempt:       attempt,
+				StatusCode:    res.StatusCode,
+				Header:        res.Header,
+				ContentLength: res.ContentLength,
+				Duration:      time.Since(start),
+			})
+		}
+	}
+
+	attempts := options.RetryPolicy.attempts()
+	rateLimitWaits := 0
+	for attempt := 1; ; attempt++ {
+		if err := options.RateLimit.wait(ctx); err != nil {
+			return err
+		}
+		req, err := newRequest(ctx, options, params)
+		if err != nil {
+			return err
+		}
+		if options.Auth != nil {
+			if err := options.Auth.Apply(req); err != nil {
+				return fmt.Errorf("failed to authenticate request: %w", err)
+			}
+		}
+
+		options.debug("sending introspection request", "endpoint", options.Endpoint, "method", req.Method, "attempt", attempt)
+		requestCtx, span := options.startSpan(ctx, "gqlfetch.request")
+		span.SetAttribute("http.method", req.Method)
+		span.SetAttribute("gqlfetch.attempt", attempt)
+		res, err := transport.Execute(requestCtx, req.WithContext(requestCtx))
+		if err == nil {
+			span.SetAttribute("http.status_code", res.StatusCode)
+		}
+		span.End(err)
+
+		var delay time.Duration
+		if err != nil {
+			if attempt >= attempts || ctx.Err() != nil || !retryableError(err) {
+				return err
+			}
+			options.debug("retrying introspection request", "endpoint", options.Endpoint, "attempt", attempt, "error", err)
+			options.metrics().IncRetries(options.Endpoint)
+		} else if wait := rateLimitDelay(res); wait > 0 && !options.IgnoreRetryAfter && rateLimitWaits < maxRateLimitWaits && withinDeadline(ctx, wait) {
+			res.Body.Close()
+			onResponse(attempt, res)
+			options.debug("waiting for the rate limit to reset", "endpoint", options.Endpoint, "status", res.StatusCode, "delay", wait)
+			options.metrics().IncRetries(options.Endpoint)
+			// Waiting for the server to accept requests again doesn't use up an attempt.
+			rateLimitWaits++
+			attempt--
+			delay = wait
+		} else if options.RetryPolicy.retryableStatus(res.StatusCode) {
+			res.Body.Close()
+			onResponse(attempt, res)
+			if attempt >= attempts {
+				if res.StatusCode == http.StatusTooManyRequests {
+					return fmt.Errorf("introspection request failed after %d attempts: %w", attempt, &RateLimitedError{RetryAfter: rateLimitDelay(res)})
+				}
+				return fmt.Errorf("introspection request failed after %d attempts: %s", attempt, res.Status)
+			}
+			options.debug("retrying introspection request", "endpoint", options.Endpoint, "attempt", attempt, "status", res.StatusCode)
+			options.metrics().IncRetries(options.Endpoint)
+		} else {
+			defer res.Body.Close()
+			if res.StatusCode == http.StatusTooManyRequests {
+				onResponse(attempt, res)
+				return &RateLimitedError{RetryAfter: rateLimitDelay(res)}
+			}
+			if res.StatusCode == http.StatusNotModified {
+				onResponse(attempt, res)
+				options.debug("schema not modified", "endpoint", options.Endpoint)
+				return errNotModified
+			}
+			body, err := decompressResponse(res)
+			if err == nil {
+				body, err = graphQLResponseBody(res.StatusCode, res.Header, body)
+			}
+			if err == nil {
+				err = decodeResponse(ctx, options, body, decode)
+			}
+			onResponse(attempt, res)
+			options.debug("received introspection response", "endpoint", options.Endpoint, "status", res.StatusCode, "duration", time.Since(start), "error", err)
+			return err
+		}
+
+		if delay <= 0 {
+			delay = options.RetryPolicy.backoff(attempt)
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeResponse hands body to decode, tracing it as gqlfetch.decode.
+func decodeResponse(ctx context.Context, options BuildClientSchemaOptions, body io.Reader, decode func(io.Reader) error) error {
+	_, span := options.startSpan(ctx, "gqlfetch.decode")
+	counter := &countingReader{reader: body}
+	err := decode(limitResponse(options, counter))
+	span.SetAttribute("gqlfetch.response.size", counter.read)
+	span.End(err)
+	return err
+}
+
+// newRequest encodes params following the GraphQL-over-HTTP specification: GET requests
+// carry them as URL query parameters, any other method as a JSON body.
+func newRequest(ctx context.Context, options BuildClientSchemaOptions, params requestParams) (*http.Request, error) {
+	method := options.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	target, err := requestURL(options.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if method == http.MethodGet {
+		endpoint, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse endpoint: %w", err)
+		}
+		values := endpoint.Query()
+		if params.Query != "" {
+			values.Set("query", params.Query)
+		}
+		if params.OperationName != "" {
+			values.Set("operationName", params.OperationName)
+		}
+		for name, value := range map[string]map[string]interface{}{"variables": params.Variables, "extensions": params.Extensions} {
+			if len(value) == 0 {
+				continue
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode request %s: %w", name, err)
+			}
+			values.Set(name, string(encoded))
+		}
+		endpoint.RawQuery = values.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create query request: %w", err)
+		}
+		req.Header = options.Headers.Clone()
+		req.Header.Set("Accept", "application/graphql-response+json, application/json")
+		setAcceptEncoding(req)
+		return req, nil
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := json.NewEncoder(buffer).Encode(params); err != nil {
+		return nil, fmt.Errorf("failed to prepare introspection query request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, buffer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create query request: %w", err)
+	}
+	req.Header = options.Headers.Clone()
+	req.Header.Add("Content-Type", "application/json")
+	setAcceptEncoding(req)
+	return req, nil
+}
+
+// ErrNonGraphQLResponse is matched by every *NonGraphQLResponseError.
+var ErrNonGraphQLResponse = errors.New("endpoint did not respond with GraphQL")
+
+// NonGraphQLResponseError is returned when the endpoint responds with something other
+// than a JSON document, such as the HTML of a login page or of a firewall block.
+type NonGraphQLResponseError struct {
+	StatusCode  int
+	ContentType string
+	// Body is the beginning of the response body.
+	Body string
+}
+
+func (e *NonGraphQLResponseError) Error() string {
+	return fmt.Sprintf("%v: status %d, content type %q, body: %s", ErrNonGraphQLResponse, e.StatusCode, e.ContentType, e.Body)
+}
+
+func (e *NonGraphQLResponseError) Unwrap() error { return ErrNonGraphQLResponse }
+
+// responseSnippetSize is how much of a non-GraphQL response body is kept for errors.
+const responseSnippetSize = 512
+
+// graphQLResponseBody returns the response body, or a *NonGraphQLResponseError when it
+// is declared as markup or doesn't start like a JSON object. Other content types are
+// accepted, as some servers send JSON as text/plain.
+func graphQLResponseBody(statusCode int, header http.Header, reader io.Reader) (io.Reader, error) {
+	body := bufio.NewReaderSize(reader, responseSnippetSize)
+	peeked, _ := body.Peek(responseSnippetSize)
+
+	contentType := header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	isMarkup := mediaType == "text/html" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "xml")
+	trimmed := bytes.TrimSpace(peeked)
+	if isMarkup || len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, &NonGraphQLResponseError{
+			StatusCode:  statusCode,
+			ContentType: contentType,
+			Body:        strings.TrimSpace(string(peeked)),
+		}
+	}
+	return body, nil
+}
+
+// setAcceptEncoding asks for a compressed response unless the caller chose an encoding.
+// Setting the header disables the transparent gzip support of http.Transport, so the
+// response is decompressed by decompressResponse, whatever Transport sent it.
+func setAcceptEncoding(req *http.Request) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	}
+}
+
+// decompressResponse returns the body of res decoded according to its
+// Content-Encoding.
+func decompressResponse(res *http.Response) (io.Reader, error) {
+	encoding := strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "", "identity":
+		return res.Body, nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress gzip response: %w", err)
+		}
+		return reader, nil
+	case "deflate":
+		// Servers disagree on whether deflate means zlib or raw DEFLATE data.
+		body := bufio.NewReader(res.Body)
+		if header, err := body.Peek(2); err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			reader, err := zlib.NewReader(body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decompress deflate response: %w", err)
+			}
+			return reader, nil
+		}
+		return flate.NewReader(body), nil
+	case "br":
+		return brotli.NewReader(res.Body), nil
+	default:
+		return nil, fmt.Errorf("unsupported response content encoding %q", encoding)
+	}
+}
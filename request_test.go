@@ -0,0 +1,124 @@
+package gqlfetch
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestBuildClientSchemaWithOptions_ContentEncoding(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	compress := func(newWriter func(io.Writer) io.WriteCloser) []byte {
+		buffer := new(bytes.Buffer)
+		writer := newWriter(buffer)
+		writer.Write(fixture)
+		writer.Close()
+		return buffer.Bytes()
+	}
+
+	tests := map[string]struct {
+		encoding  string
+		body      []byte
+		expectErr string
+	}{
+		"identity": {body: fixture},
+		"gzip": {encoding: "gzip", body: compress(func(w io.Writer) io.WriteCloser {
+			return gzip.NewWriter(w)
+		})},
+		"zlib deflate": {encoding: "deflate", body: compress(func(w io.Writer) io.WriteCloser {
+			return zlib.NewWriter(w)
+		})},
+		"raw deflate": {encoding: "deflate", body: compress(func(w io.Writer) io.WriteCloser {
+			writer, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return writer
+		})},
+		"brotli": {encoding: "br", body: compress(func(w io.Writer) io.WriteCloser {
+			return brotli.NewWriter(w)
+		})},
+		"unsupported": {encoding: "zstd", body: []byte{0x28, 0xb5, 0x2f, 0xfd}, expectErr: `unsupported response content encoding "zstd"`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept-Encoding") != "gzip, deflate, br" {
+					t.Errorf("expect gzip, deflate and br to be accepted got: %v", r.Header.Get("Accept-Encoding"))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				w.Write(tt.body)
+			}))
+			defer server.Close()
+
+			schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{Endpoint: server.URL})
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(schema, "type User implements Node") {
+				t.Errorf("expect the introspected schema got:\n%v", schema)
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemaWithOptions_VariablesAndExtensions(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodGet} {
+		t.Run(method, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var params struct {
+					Variables  map[string]interface{} `json:"variables"`
+					Extensions map[string]interface{} `json:"extensions"`
+				}
+				if r.Method == http.MethodGet {
+					json.Unmarshal([]byte(r.URL.Query().Get("variables")), &params.Variables)
+					json.Unmarshal([]byte(r.URL.Query().Get("extensions")), &params.Extensions)
+				} else {
+					json.NewDecoder(r.Body).Decode(&params)
+				}
+				if params.Variables["tenant"] != "acme" || params.Extensions["traceparent"] != "00-abc-01" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(fixture)
+			}))
+			defer server.Close()
+
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:   server.URL,
+				Method:     method,
+				Variables:  map[string]interface{}{"tenant": "acme"},
+				Extensions: map[string]interface{}{"traceparent": "00-abc-01"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
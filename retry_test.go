@@ -0,0 +1,134 @@
+package gqlfetch
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestBuildClientSchemaWithOptions_RetryPolicy(t *testing.T) {
+	body, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	tests := map[string]struct {
+		failures  int
+		policy    *RetryPolicy
+		expectErr bool
+	}{
+		"no policy fails on first 503": {
+			failures:  1,
+			expectErr: true,
+		},
+		"recovers within max attempts": {
+			failures: 2,
+			policy:   &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		},
+		"gives up after max attempts": {
+			failures:  3,
+			policy:    &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			expectErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:    server.URL,
+				Method:      http.MethodPost,
+				RetryPolicy: tt.policy,
+			})
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expect error: %v got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	tests := map[string]struct {
+		retry  int
+		expect time.Duration
+	}{
+		"first retry":   {retry: 1, expect: 100 * time.Millisecond},
+		"second retry":  {retry: 2, expect: 200 * time.Millisecond},
+		"third retry":   {retry: 3, expect: 400 * time.Millisecond},
+		"capped by max": {retry: 10, expect: time.Second},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := policy.backoff(tt.retry); got != tt.expect {
+				t.Errorf("backoff expect: %v got: %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemaWithOptions_RetryTransportErrors(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+
+	tests := map[string]struct {
+		err         error
+		expectCalls int
+		expectErr   bool
+	}{
+		"connection reset is retried": {
+			err:         &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+			expectCalls: 2,
+		},
+		"permanent error fails fast": {
+			err:         errors.New("x509: certificate signed by unknown authority"),
+			expectCalls: 1,
+			expectErr:   true,
+		},
+		"unknown host fails fast": {
+			err:         &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true},
+			expectCalls: 1,
+			expectErr:   true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				if calls == 1 {
+					return nil, tt.err
+				}
+				return http.DefaultTransport.RoundTrip(req)
+			})}
+
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:    server.URL,
+				Method:      http.MethodPost,
+				HTTPClient:  client,
+				RetryPolicy: &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			})
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if calls != tt.expectCalls {
+				t.Errorf("expect %d calls got: %d", tt.expectCalls, calls)
+			}
+		})
+	}
+}
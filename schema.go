@@ -0,0 +1,460 @@
+package gqlfetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// LoadSchema parses and validates SDL such as the output of BuildClientSchema. Unlike
+// gqlparser.LoadSchema, built-in scalars and directives may be redeclared, as they are
+// printed unless WithoutBuiltins is set.
+func LoadSchema(sources ...*ast.Source) (*ast.Schema, error) {
+	prelude, gqlErr := parser.ParseSchema(validator.Prelude)
+	if gqlErr != nil {
+		return nil, fmt.Errorf("failed to parse prelude: %w", gqlErr)
+	}
+
+	doc, gqlErr := parser.ParseSchemas(sources...)
+	if gqlErr != nil {
+		return nil, gqlErr
+	}
+
+	var definitions ast.DefinitionList
+	for _, def := range doc.Definitions {
+		if prelude.Definitions.ForName(def.Name) == nil {
+			definitions = append(definitions, def)
+		}
+	}
+	doc.Definitions = definitions
+
+	var directives ast.DirectiveDefinitionList
+	for _, dir := range doc.Directives {
+		if prelude.Directives.ForName(dir.Name) == nil {
+			directives = append(directives, dir)
+		}
+	}
+	doc.Directives = directives
+
+	// gqlparser's prelude doesn't declare @oneOf, from the current specification draft.
+	if doc.Directives.ForName("oneOf") == nil {
+		prelude.Directives = append(prelude.Directives, oneOfDirective)
+	}
+
+	prelude.Merge(doc)
+	schema, gqlErr := validator.ValidateSchemaDocument(prelude)
+	if gqlErr != nil {
+		return nil, gqlErr
+	}
+	return schema, nil
+}
+
+func buildASTSchema(schema introspectionSchema) (*ast.Schema, error) {
+	prelude, gqlErr := parser.ParseSchema(validator.Prelude)
+	if gqlErr != nil {
+		return nil, fmt.Errorf("failed to parse prelude: %w", gqlErr)
+	}
+	schema, err := withDefinedAppliedDirectives(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := buildSchemaDocument(schema, prelude)
+	if err != nil {
+		return nil, err
+	}
+	prelude.Merge(doc)
+
+	astSchema, gqlErr := validator.ValidateSchemaDocument(prelude)
+	if gqlErr != nil {
+		return nil, fmt.Errorf("failed to validate introspected schema: %w", gqlErr)
+	}
+
+	// The validator falls back to types named after the default roots, undo that for
+	// roots the server didn't declare.
+	if schema.MutationType.Name == "" {
+		astSchema.Mutation = nil
+	}
+	if schema.SubscriptionType.Name == "" {
+		astSchema.Subscription = nil
+	}
+
+	return astSchema, nil
+}
+
+// buildSchemaDocument converts the introspection result into a schema document, leaving
+// out any type or directive already declared by the given prelude.
+func buildSchemaDocument(schema introspectionSchema, prelude *ast.SchemaDocument) (*ast.SchemaDocument, error) {
+	doc := &ast.SchemaDocument{}
+
+	for _, directive := range schema.Directives {
+		if prelude.Directives.ForName(directive.Name) != nil {
+			continue
+		}
+		def := &ast.DirectiveDefinition{
+			Description:  directive.Description,
+			Name:         directive.Name,
+			Locations:    directive.Locations,
+			IsRepeatable: directive.IsRepeatable,
+			Position:     introspectionPosition,
+		}
+		for _, arg := range directive.Args {
+			astType, err := introspectionTypeToAstType(arg.Type)
+			if err != nil {
+				return nil, fmt.Errorf("convert introspection type to AST type: %w\n%v", err, arg.Type)
+			}
+			defaultValue, err := parseDefaultValue(arg.DefaultValue)
+			if err != nil {
+				return nil, fmt.Errorf("parse default value of directive argument %s.%s: %w", directive.Name, arg.Name, err)
+			}
+			directives, err := memberDirectives(arg.IsDeprecated, arg.DeprecationReason, arg.AppliedDirectives)
+			if err != nil {
+				return nil, fmt.Errorf("convert directives of directive argument %s.%s: %w", directive.Name, arg.Name, err)
+			}
+			def.Arguments = append(def.Arguments, &ast.ArgumentDefinition{
+				Description:  arg.Description,
+				Name:         arg.Name,
+				DefaultValue: defaultValue,
+				Type:         astType,
+				Directives:   directives,
+			})
+		}
+		doc.Directives = append(doc.Directives, def)
+	}
+
+	for _, typ := range schema.Types {
+		if strings.HasPrefix(typ.Name, "__") || prelude.Definitions.ForName(typ.Name) != nil {
+			continue
+		}
+		def, err := introspectionTypeToAstDefinition(typ)
+		if err != nil {
+			return nil, err
+		}
+		doc.Definitions = append(doc.Definitions, def)
+	}
+
+	// gqlparser's prelude doesn't declare @oneOf, declare it for servers that use it
+	// without reporting its definition.
+	if doc.Directives.ForName("oneOf") == nil && usesOneOf(schema.Types) {
+		doc.Directives = append(doc.Directives, oneOfDirective)
+	}
+
+	var operations ast.OperationTypeDefinitionList
+	if schema.QueryType.Name != "" {
+		operations = append(operations, &ast.OperationTypeDefinition{Operation: ast.Query, Type: schema.QueryType.Name})
+	}
+	if schema.MutationType.Name != "" {
+		operations = append(operations, &ast.OperationTypeDefinition{Operation: ast.Mutation, Type: schema.MutationType.Name})
+	}
+	if schema.SubscriptionType.Name != "" {
+		operations = append(operations, &ast.OperationTypeDefinition{Operation: ast.Subscription, Type: schema.SubscriptionType.Name})
+	}
+	if len(operations) > 0 {
+		doc.Schema = append(doc.Schema, &ast.SchemaDefinition{OperationTypes: operations})
+	}
+
+	return doc, nil
+}
+
+// introspectionPosition is the position of directive definitions built from
+// introspection, which gqlparser's formatter requires.
+var introspectionPosition = &ast.Position{Src: &ast.Source{Name: "introspection"}}
+
+func introspectionTypeToAstDefinition(typ introspectionTypeDefinition) (*ast.Definition, error) {
+	def := &ast.Definition{
+		Kind:        typ.Kind,
+		Name:        typ.Name,
+		Description: typ.Description,
+	}
+
+	switch typ.Kind {
+	case ast.Object, ast.Interface:
+		interfaces, err := interfaceNames(typ)
+		if err != nil {
+			return nil, err
+		}
+		def.Interfaces = interfaces
+		for _, field := range typ.Fields {
+			astType, err := introspectionTypeToAstType(field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("convert introspection type to AST type: %w\n%v", err, field.Type)
+			}
+			directives, err := memberDirectives(field.IsDeprecated, field.DeprecationReason, field.AppliedDirectives)
+			if err != nil {
+				return nil, fmt.Errorf("convert directives of field %s.%s: %w", typ.Name, field.Name, err)
+			}
+			fieldDef := &ast.FieldDefinition{
+				Description: field.Description,
+				Name:        field.Name,
+				Type:        astType,
+				Directives:  directives,
+			}
+			for _, arg := range field.Args {
+				astType, err := introspectionTypeToAstType(arg.Type)
+				if err != nil {
+					return nil, fmt.Errorf("convert introspection type to AST type: %w\n%v", err, arg.Type)
+				}
+				defaultValue, err := parseDefaultValue(arg.DefaultValue)
+				if err != nil {
+					return nil, fmt.Errorf("parse default value of argument %s.%s(%s): %w", typ.Name, field.Name, arg.Name, err)
+				}
+				directives, err := memberDirectives(arg.IsDeprecated, arg.DeprecationReason, arg.AppliedDirectives)
+				if err != nil {
+					return nil, fmt.Errorf("convert directives of argument %s.%s(%s): %w", typ.Name, field.Name, arg.Name, err)
+				}
+				fieldDef.Arguments = append(fieldDef.Arguments, &ast.ArgumentDefinition{
+					Description:  arg.Description,
+					Name:         arg.Name,
+					DefaultValue: defaultValue,
+					Type:         astType,
+					Directives:   directives,
+				})
+			}
+			def.Fields = append(def.Fields, fieldDef)
+		}
+
+	case ast.InputObject:
+		if typ.IsOneOf {
+			def.Directives = ast.DirectiveList{{Name: "oneOf"}}
+		}
+		for _, field := range typ.InputFields {
+			astType, err := introspectionTypeToAstType(field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("convert introspection type to AST type: %w\n%v", err, field.Type)
+			}
+			defaultValue, err := parseDefaultValue(field.DefaultValue)
+			if err != nil {
+				return nil, fmt.Errorf("parse default value of input field %s.%s: %w", typ.Name, field.Name, err)
+			}
+			directives, err := memberDirectives(field.IsDeprecated, field.DeprecationReason, field.AppliedDirectives)
+			if err != nil {
+				return nil, fmt.Errorf("convert directives of input field %s.%s: %w", typ.Name, field.Name, err)
+			}
+			def.Fields = append(def.Fields, &ast.FieldDefinition{
+				Description:  field.Description,
+				Name:         field.Name,
+				DefaultValue: defaultValue,
+				Type:         astType,
+				Directives:   directives,
+			})
+		}
+
+	case ast.Union:
+		var possible []*introspectedType
+		if err := json.Unmarshal(typ.PossibleTypes, &possible); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal possible types: %w\n%v", err, typ.PossibleTypes)
+		}
+		for _, possibleType := range possible {
+			astType, err := introspectionTypeToAstType(possibleType)
+			if err != nil {
+				return nil, fmt.Errorf("convert introspection type to AST type: %w\n%v", err, possibleType)
+			}
+			def.Types = append(def.Types, astType.Name())
+		}
+
+	case ast.Enum:
+		var enumValues []introspectionEnumValue
+		if err := json.Unmarshal(typ.EnumValues, &enumValues); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal enum values: %w\n%v", err, typ.EnumValues)
+		}
+		for _, value := range enumValues {
+			directives, err := memberDirectives(value.IsDeprecated, value.DeprecationReason, value.AppliedDirectives)
+			if err != nil {
+				return nil, fmt.Errorf("convert directives of enum value %s.%s: %w", typ.Name, value.Name, err)
+			}
+			def.EnumValues = append(def.EnumValues, &ast.EnumValueDefinition{
+				Description: value.Description,
+				Name:        value.Name,
+				Directives:  directives,
+			})
+		}
+
+	case ast.Scalar:
+		if typ.SpecifiedByURL != nil {
+			def.Directives = ast.DirectiveList{{
+				Name: "specifiedBy",
+				Arguments: ast.ArgumentList{{
+					Name:  "url",
+					Value: &ast.Value{Kind: ast.StringValue, Raw: *typ.SpecifiedByURL},
+				}},
+			}}
+		}
+
+	default:
+		return nil, fmt.Errorf("not handling kind: %v", typ.Kind)
+	}
+
+	directives, err := appliedDirectiveList(typ.AppliedDirectives)
+	if err != nil {
+		return nil, fmt.Errorf("convert directives of type %s: %w", typ.Name, err)
+	}
+	def.Directives = append(def.Directives, directives...)
+
+	return def, nil
+}
+
+// oneOfDirective is built in like the prelude directives, gqlparser's formatter requires
+// every directive definition to have a position.
+var oneOfDirective = &ast.DirectiveDefinition{
+	Name:      "oneOf",
+	Locations: []ast.DirectiveLocation{ast.LocationInputObject},
+	Position:  &ast.Position{Src: &ast.Source{Name: "prelude.graphql", BuiltIn: true}},
+}
+
+func usesOneOf(types []introspectionTypeDefinition) bool {
+	for _, typ := range types {
+		if typ.IsOneOf {
+			return true
+		}
+	}
+	return false
+}
+
+// memberDirectives returns the deprecation and applied directives of a schema member.
+func memberDirectives(isDeprecated bool, reason *string, applied []introspectionAppliedDirective) (ast.DirectiveList, error) {
+	directives, err := appliedDirectiveList(applied)
+	if err != nil {
+		return nil, err
+	}
+	return append(deprecationDirectives(isDeprecated, reason), directives...), nil
+}
+
+func deprecationDirectives(isDeprecated bool, reason *string) ast.DirectiveList {
+	if !isDeprecated {
+		return nil
+	}
+	directive := &ast.Directive{Name: "deprecated"}
+	if reason != nil {
+		directive.Arguments = ast.ArgumentList{{
+			Name:  "reason",
+			Value: &ast.Value{Kind: ast.StringValue, Raw: *reason},
+		}}
+	}
+	return ast.DirectiveList{directive}
+}
+
+// parseDefaultValue parses the GraphQL literal reported by introspection as a default
+// value. The parser only exposes value parsing through documents, so the literal is
+// parsed as the default value of a variable definition.
+func parseDefaultValue(defaultValue *string) (*ast.Value, error) {
+	if defaultValue == nil {
+		return nil, nil
+	}
+	doc, gqlErr := parser.ParseQuery(&ast.Source{
+		Input: fmt.Sprintf("query ($value: Boolean = %s) { __typename }", *defaultValue),
+	})
+	if gqlErr != nil {
+		return nil, gqlErr
+	}
+	return doc.Operations[0].VariableDefinitions[0].DefaultValue, nil
+}
+
+var defaultRootNames = map[ast.Operation]string{
+	ast.Query:        "Query",
+	ast.Mutation:     "Mutation",
+	ast.Subscription: "Subscription",
+}
+
+// userSchemaDocument returns the definitions of schema that aren't built in, sorted by
+// name, leaving out the introspection fields the validator adds to the query type.
+func userSchemaDocument(schema *ast.Schema) *ast.SchemaDocument {
+	doc := &ast.SchemaDocument{}
+
+	var operations ast.OperationTypeDefinitionList
+	common := true
+	for _, root := range []struct {
+		operation ast.Operation
+		def       *ast.Definition
+	}{
+		{ast.Query, schema.Query},
+		{ast.Mutation, schema.Mutation},
+		{ast.Subscription, schema.Subscription},
+	} {
+		if root.def == nil {
+			continue
+		}
+		operations = append(operations, &ast.OperationTypeDefinition{Operation: root.operation, Type: root.def.Name})
+		if root.def.Name != defaultRootNames[root.operation] {
+			common = false
+		}
+	}
+	if !common {
+		doc.Schema = ast.SchemaDefinitionList{{OperationTypes: operations}}
+	}
+
+	names := make([]string, 0, len(schema.Directives))
+	for name, dir := range schema.Directives {
+		if dir.Position == nil || dir.Position.Src == nil || !dir.Position.Src.BuiltIn {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		doc.Directives = append(doc.Directives, schema.Directives[name])
+	}
+
+	names = names[:0]
+	for name, def := range schema.Types {
+		if !def.BuiltIn && !strings.HasPrefix(name, "__") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		doc.Definitions = append(doc.Definitions, withoutIntrospectionFields(schema.Types[name]))
+	}
+
+	return doc
+}
+
+func withoutIntrospectionFields(def *ast.Definition) *ast.Definition {
+	var fields ast.FieldList
+	for _, field := range def.Fields {
+		if !strings.HasPrefix(field.Name, "__") {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == len(def.Fields) {
+		return def
+	}
+	stripped := *def
+	stripped.Fields = fields
+	return &stripped
+}
+
+// formatSchema prints schema with gqlparser's formatter, once validated as an *ast.Schema,
+// keeping the order of the introspection result.
+func formatSchema(schema introspectionSchema) (string, error) {
+	astSchema, err := buildASTSchema(schema)
+	if err != nil {
+		return "", err
+	}
+	doc := userSchemaDocument(astSchema)
+
+	order := map[string]int{}
+	for i, directive := range schema.Directives {
+		order["@"+directive.Name] = i
+	}
+	for i, typ := range schema.Types {
+		order[typ.Name] = i
+	}
+	sort.SliceStable(doc.Directives, func(i, j int) bool {
+		return order["@"+doc.Directives[i].Name] < order["@"+doc.Directives[j].Name]
+	})
+	sort.SliceStable(doc.Definitions, func(i, j int) bool {
+		return order[doc.Definitions[i].Name] < order[doc.Definitions[j].Name]
+	})
+	return formatSchemaDocument(doc), nil
+}
+
+func formatSchemaDocument(doc *ast.SchemaDocument) string {
+	sb := &strings.Builder{}
+	formatter.NewFormatter(sb).FormatSchemaDocument(doc)
+	return sb.String()
+}
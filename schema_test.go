@@ -0,0 +1,294 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func newIntrospectionServer(t *testing.T, fixture string) *httptest.Server {
+	t.Helper()
+	body, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBuildClientSchemaAST(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+
+	schema, err := BuildClientSchemaAST(context.Background(), BuildClientSchemaOptions{
+		Endpoint: server.URL,
+		Method:   http.MethodPost,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if schema.Query == nil || schema.Query.Name != "Query" {
+		t.Errorf("expected Query root, got %v", schema.Query)
+	}
+	if schema.Mutation == nil || schema.Mutation.Name != "Mutation" {
+		t.Errorf("expected Mutation root, got %v", schema.Mutation)
+	}
+
+	user := schema.Types["User"]
+	if user == nil {
+		t.Fatalf("expected User type")
+	}
+	if got := user.Fields.ForName("friends").Type.String(); got != "[User!]" {
+		t.Errorf("expected friends to be [User!] got: %v", got)
+	}
+	if len(user.Interfaces) != 1 || user.Interfaces[0] != "Node" {
+		t.Errorf("expected User to implement Node got: %v", user.Interfaces)
+	}
+
+	if got := schema.Types["SearchResult"]; got == nil || got.Kind != ast.Union || len(got.Types) != 1 {
+		t.Errorf("expected SearchResult union of one type got: %v", got)
+	}
+	if got := schema.Types["CreateUserInput"].Fields.ForName("name").Description; got != "The name of the user." {
+		t.Errorf("expected input field description got: %v", got)
+	}
+	if got := schema.Directives["auth"]; got == nil || got.Arguments.ForName("requires") == nil {
+		t.Errorf("expected auth directive with requires argument got: %v", got)
+	}
+	if possible := schema.GetPossibleTypes(schema.Types["Node"]); len(possible) != 1 || possible[0].Name != "User" {
+		t.Errorf("expected Node to be implemented by User got: %v", possible)
+	}
+}
+
+func TestBuildClientSchemaAST_Deprecations(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+
+	schema, err := BuildClientSchemaAST(context.Background(), BuildClientSchemaOptions{
+		Endpoint: server.URL,
+		Method:   http.MethodPost,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deprecated := schema.Types["User"].Fields.ForName("username").Directives.ForName("deprecated")
+	if deprecated == nil {
+		t.Fatalf("expected username to be deprecated")
+	}
+	if got := deprecated.Arguments.ForName("reason").Value.Raw; got != "Use `name`." {
+		t.Errorf("expected deprecation reason got: %v", got)
+	}
+	if schema.Types["Role"].EnumValues.ForName("GUEST").Directives.ForName("deprecated") == nil {
+		t.Errorf("expected GUEST enum value to be deprecated")
+	}
+}
+
+func Test_parseDefaultValue(t *testing.T) {
+	tests := map[string]struct {
+		raw    string
+		expect string
+		kind   ast.ValueKind
+	}{
+		"int":    {raw: "10", expect: "10", kind: ast.IntValue},
+		"string": {raw: `"hello"`, expect: `"hello"`, kind: ast.StringValue},
+		"enum":   {raw: "USER", expect: "USER", kind: ast.EnumValue},
+		"list":   {raw: "[1, 2]", expect: "[1,2]", kind: ast.ListValue},
+		"object": {raw: `{name: "x", tags: [A]}`, expect: `{name:"x",tags:[A]}`, kind: ast.ObjectValue},
+		"null":   {raw: "null", expect: "null", kind: ast.NullValue},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			value, err := parseDefaultValue(strPtr(tt.raw))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if value.Kind != tt.kind || value.String() != tt.expect {
+				t.Errorf("parsing default value expect: %v (%v) got: %v (%v)", tt.expect, tt.kind, value.String(), value.Kind)
+			}
+		})
+	}
+}
+
+func TestLoadSchema_PrintedSchema(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+
+	sdl, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint: server.URL,
+		Method:   http.MethodPost,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	schema, err := LoadSchema(&ast.Source{Input: sdl})
+	if err != nil {
+		t.Fatalf("expected printed schema to load, got: %v\n%v", err, sdl)
+	}
+	if schema.Types["User"] == nil {
+		t.Errorf("expected User type in loaded schema")
+	}
+}
+
+func TestBuildASTSchema_SpecifiedBy(t *testing.T) {
+	var response introspectionResults
+	err := json.Unmarshal([]byte(`{"data": {"__schema": {
+		"queryType": {"name": "Query"},
+		"types": [
+			{"kind": "OBJECT", "name": "Query", "fields": [{"name": "now", "args": [], "type": {"kind": "SCALAR", "name": "DateTime"}}], "interfaces": []},
+			{"kind": "SCALAR", "name": "DateTime", "specifiedByURL": "https://scalars.graphql.org/andimarek/date-time"}
+		],
+		"directives": []
+	}}}`), &response)
+	if err != nil {
+		t.Fatalf("decode introspection: %v", err)
+	}
+
+	sdl, err := printSchema(context.Background(), response.Data.Schema, builtinFilter{without: true}, SDLFormat{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(sdl, `scalar DateTime @specifiedBy(url: "https://scalars.graphql.org/andimarek/date-time")`) {
+		t.Errorf("expect printed scalar to be specified by its URL got:\n%v", sdl)
+	}
+
+	schema, err := buildASTSchema(response.Data.Schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	specifiedBy := schema.Types["DateTime"].Directives.ForName("specifiedBy")
+	if specifiedBy == nil {
+		t.Fatalf("expect DateTime to be specified by a URL")
+	}
+	if got := specifiedBy.Arguments.ForName("url").Value.Raw; got != "https://scalars.graphql.org/andimarek/date-time" {
+		t.Errorf("expect specification URL got: %v", got)
+	}
+}
+
+func TestBuildASTSchema_AppliedDirectives(t *testing.T) {
+	var response introspectionResults
+	err := json.Unmarshal([]byte(`{"data": {"__schema": {
+		"queryType": {"name": "Query"},
+		"types": [
+			{"kind": "OBJECT", "name": "Query", "appliedDirectives": [{"name": "tag", "args": [{"name": "name", "value": "\"public\""}]}], "fields": [
+				{"name": "color", "args": [{"name": "hex", "type": {"kind": "SCALAR", "name": "Boolean"}, "appliedDirectives": [{"name": "tag", "args": [{"name": "name", "value": "\"arg\""}]}]}], "type": {"kind": "ENUM", "name": "Color"},
+					"appliedDirectives": [{"name": "tag", "args": [{"name": "name", "value": "\"field\""}]}, {"name": "internal", "args": []}]}
+			], "interfaces": []},
+			{"kind": "ENUM", "name": "Color", "enumValues": [{"name": "RED", "appliedDirectives": [{"name": "tag", "args": [{"name": "name", "value": "\"value\""}]}]}]},
+			{"kind": "SCALAR", "name": "Boolean"},
+			{"kind": "SCALAR", "name": "String"}
+		],
+		"directives": [{"name": "tag", "locations": ["OBJECT", "FIELD_DEFINITION", "ARGUMENT_DEFINITION", "ENUM_VALUE"], "args": [{"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}]}]
+	}}}`), &response)
+	if err != nil {
+		t.Fatalf("decode introspection: %v", err)
+	}
+
+	sdl, err := printSchema(context.Background(), response.Data.Schema, builtinFilter{without: true}, SDLFormat{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		`type Query @tag(name: "public") {`,
+		`hex: Boolean @tag(name: "arg")`,
+		`): Color @tag(name: "field")`,
+		`RED @tag(name: "value")`,
+	} {
+		if !strings.Contains(sdl, expected) {
+			t.Errorf("expect %v got:\n%v", expected, sdl)
+		}
+	}
+	if strings.Contains(sdl, "@internal") {
+		t.Errorf("expect directives the schema doesn't define to be left out got:\n%v", sdl)
+	}
+
+	schema, err := buildASTSchema(response.Data.Schema)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	field := schema.Types["Query"].Fields.ForName("color")
+	if tag := field.Directives.ForName("tag"); tag == nil || tag.Arguments.ForName("name").Value.Raw != "field" {
+		t.Errorf("expect field to be tagged got: %v", field.Directives)
+	}
+	if schema.Types["Query"].Directives.ForName("tag") == nil {
+		t.Errorf("expect Query to be tagged")
+	}
+	if schema.Types["Color"].EnumValues.ForName("RED").Directives.ForName("tag") == nil {
+		t.Errorf("expect RED to be tagged")
+	}
+}
+
+func TestBuildASTSchema_OneOf(t *testing.T) {
+	tests := map[string]struct {
+		input string
+	}{
+		"isOneOf": {
+			input: `{"kind": "INPUT_OBJECT", "name": "Filter", "isOneOf": true, "inputFields": [{"name": "id", "type": {"kind": "SCALAR", "name": "String"}}]}`,
+		},
+		"applied directive": {
+			input: `{"kind": "INPUT_OBJECT", "name": "Filter", "isOneOf": true, "appliedDirectives": [{"name": "oneOf", "args": []}], "inputFields": [{"name": "id", "type": {"kind": "SCALAR", "name": "String"}}]}`,
+		},
+		"applied directive only": {
+			input: `{"kind": "INPUT_OBJECT", "name": "Filter", "appliedDirectives": [{"name": "oneOf", "args": []}], "inputFields": [{"name": "id", "type": {"kind": "SCALAR", "name": "String"}}]}`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var response introspectionResults
+			err := json.Unmarshal([]byte(`{"data": {"__schema": {
+				"queryType": {"name": "Query"},
+				"types": [
+					{"kind": "OBJECT", "name": "Query", "fields": [{"name": "find", "args": [{"name": "filter", "type": {"kind": "INPUT_OBJECT", "name": "Filter"}}], "type": {"kind": "SCALAR", "name": "String"}}], "interfaces": []},
+					`+test.input+`,
+					{"kind": "SCALAR", "name": "String"}
+				],
+				"directives": []
+			}}}`), &response)
+			if err != nil {
+				t.Fatalf("decode introspection: %v", err)
+			}
+
+			sdl, err := printSchema(context.Background(), response.Data.Schema, builtinFilter{without: true}, SDLFormat{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Count(sdl, "@oneOf") != 1 || !strings.Contains(sdl, "input Filter @oneOf {") {
+				t.Errorf("expect Filter to be printed once as a oneOf input object got:\n%v", sdl)
+			}
+
+			schema, err := buildASTSchema(response.Data.Schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(schema.Types["Filter"].Directives.ForNames("oneOf")) != 1 {
+				t.Errorf("expect Filter to be a oneOf input object got: %v", schema.Types["Filter"].Directives)
+			}
+		})
+	}
+}
+
+func TestBuildASTSchema_RepeatableDirectives(t *testing.T) {
+	schema, err := buildASTSchema(introspectionSchema{
+		QueryType: introspectionRootType{Name: "Query"},
+		Types: []introspectionTypeDefinition{{
+			Kind:   "OBJECT",
+			Name:   "Query",
+			Fields: []introspectedTypeField{{Name: "version", Type: &introspectedType{Kind: "SCALAR", Name: strPtr("String")}}},
+		}},
+		Directives: []introspectionDirectiveDefinition{{Name: "tag", IsRepeatable: true, Locations: []ast.DirectiveLocation{ast.LocationObject}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !schema.Directives["tag"].IsRepeatable {
+		t.Errorf("expect @tag to be repeatable")
+	}
+}
@@ -0,0 +1,210 @@
+package gqlfetch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+	_ "github.com/vektah/gqlparser/v2/validator/rules"
+)
+
+// SliceSchemaForOperations fetches the schema described by options and trims it to the
+// types and fields selected by the operations, which are validated against it. Fragments
+// may be defined in any of the sources. Used fields keep all their arguments, and input
+// types are kept whole. The result is printed as SDL whatever OutputFormat is.
+func SliceSchemaForOperations(ctx context.Context, options BuildClientSchemaOptions, operations ...*ast.Source) (string, error) {
+	options, err := options.withPreset()
+	if err != nil {
+		return "", err
+	}
+	schema, err := fetchIntrospection(ctx, options)
+	if err != nil {
+		return "", err
+	}
+	astSchema, err := buildASTSchema(schema)
+	if err != nil {
+		return "", err
+	}
+
+	doc := &ast.QueryDocument{}
+	for _, source := range operations {
+		parsed, gqlErr := parser.ParseQuery(source)
+		if gqlErr != nil {
+			return "", gqlErr
+		}
+		doc.Operations = append(doc.Operations, parsed.Operations...)
+		doc.Fragments = append(doc.Fragments, parsed.Fragments...)
+	}
+	if errs := validator.Validate(astSchema, doc); len(errs) > 0 {
+		return "", fmt.Errorf("invalid operations: %w", errs)
+	}
+
+	used := newSchemaUsage(astSchema, doc)
+	for _, operation := range doc.Operations {
+		used.operation(operation)
+	}
+
+	sliced, err := used.slice(schema)
+	if err != nil {
+		return "", err
+	}
+	sdl, err := printSchema(ctx, sliced, builtinFilterOf(options), options.SDLFormat)
+	if err == nil && !options.SkipValidation {
+		err = validateSDL(sdl)
+	}
+	if err != nil {
+		return "", err
+	}
+	return sdl, nil
+}
+
+// schemaUsage collects the types and fields used by operations.
+type schemaUsage struct {
+	schema    *ast.Schema
+	doc       *ast.QueryDocument
+	types     map[string]bool
+	fields    map[string]map[string]bool
+	fragments map[string]bool
+}
+
+func newSchemaUsage(schema *ast.Schema, doc *ast.QueryDocument) *schemaUsage {
+	return &schemaUsage{
+		schema:    schema,
+		doc:       doc,
+		types:     map[string]bool{},
+		fields:    map[string]map[string]bool{},
+		fragments: map[string]bool{},
+	}
+}
+
+func (u *schemaUsage) operation(operation *ast.OperationDefinition) {
+	root := u.schema.Query
+	switch operation.Operation {
+	case ast.Mutation:
+		root = u.schema.Mutation
+	case ast.Subscription:
+		root = u.schema.Subscription
+	}
+	u.useType(root.Name)
+	for _, variable := range operation.VariableDefinitions {
+		u.useType(variable.Type.Name())
+	}
+	u.selectionSet(operation.SelectionSet)
+}
+
+func (u *schemaUsage) selectionSet(selections ast.SelectionSet) {
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") || selection.Definition == nil {
+				continue
+			}
+			parent := selection.ObjectDefinition.Name
+			if u.fields[parent] == nil {
+				u.fields[parent] = map[string]bool{}
+			}
+			u.fields[parent][selection.Name] = true
+			u.useType(selection.Definition.Type.Name())
+			for _, arg := range selection.Definition.Arguments {
+				u.useType(arg.Type.Name())
+			}
+			u.selectionSet(selection.SelectionSet)
+		case *ast.InlineFragment:
+			if selection.TypeCondition != "" {
+				u.useType(selection.TypeCondition)
+			}
+			u.selectionSet(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			if u.fragments[selection.Name] {
+				continue
+			}
+			u.fragments[selection.Name] = true
+			if fragment := u.doc.Fragments.ForName(selection.Name); fragment != nil {
+				u.useType(fragment.TypeCondition)
+				u.selectionSet(fragment.SelectionSet)
+			}
+		}
+	}
+}
+
+// useType marks a type as used, along with the types of the fields of input types.
+func (u *schemaUsage) useType(name string) {
+	if u.types[name] {
+		return
+	}
+	u.types[name] = true
+	if def := u.schema.Types[name]; def != nil && def.Kind == ast.InputObject {
+		for _, field := range def.Fields {
+			u.useType(field.Type.Name())
+		}
+	}
+}
+
+// slice keeps the used types of schema, trimming objects and interfaces to their used
+// fields and the fields of the used interfaces they implement. Types used without
+// selecting any of their fields, such as union members only queried for __typename,
+// keep a field of a built-in scalar type, or are removed when they have none.
+func (u *schemaUsage) slice(schema introspectionSchema) (introspectionSchema, error) {
+	if len(u.fields[schema.QueryType.Name]) == 0 {
+		return introspectionSchema{}, fmt.Errorf("the operations select no field of the query type %s", schema.QueryType.Name)
+	}
+
+	keep := map[string]bool{}
+	for _, typ := range schema.Types {
+		keep[typ.Name] = isBuiltinType(typ) || u.types[typ.Name]
+	}
+	builtinScalars := map[string]bool{}
+	for _, name := range excludeScalarTypes {
+		builtinScalars[name] = true
+	}
+
+	fields := map[string]map[string]bool{}
+	for _, typ := range schema.Types {
+		if !keep[typ.Name] || typ.Kind != ast.Object && typ.Kind != ast.Interface {
+			continue
+		}
+		fields[typ.Name] = map[string]bool{}
+		for name := range u.fields[typ.Name] {
+			fields[typ.Name][name] = true
+		}
+		if len(fields[typ.Name]) > 0 || isBuiltinType(typ) {
+			continue
+		}
+		for _, field := range typ.Fields {
+			if builtinScalars[namedType(field.Type)] {
+				fields[typ.Name][field.Name] = true
+				break
+			}
+		}
+		if len(fields[typ.Name]) == 0 {
+			keep[typ.Name] = false
+		}
+	}
+
+	types := make([]introspectionTypeDefinition, len(schema.Types))
+	for i, typ := range schema.Types {
+		if keep[typ.Name] && !isBuiltinType(typ) && (typ.Kind == ast.Object || typ.Kind == ast.Interface) {
+			used := fields[typ.Name]
+			for _, ref := range typ.Interfaces {
+				if keep[typeRefName(ref)] {
+					for name := range fields[typeRefName(ref)] {
+						used[name] = true
+					}
+				}
+			}
+			trimmed := []introspectedTypeField{}
+			for _, field := range typ.Fields {
+				if used[field.Name] {
+					trimmed = append(trimmed, field)
+				}
+			}
+			typ.Fields = trimmed
+		}
+		types[i] = typ
+	}
+	schema.Types = types
+	return pruneSchema(schema, keep)
+}
@@ -0,0 +1,78 @@
+package gqlfetch
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestSliceSchemaForOperations(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+
+	tests := map[string]struct {
+		operations     []string
+		expectContains []string
+		expectMissing  []string
+		expectErr      string
+	}{
+		"selected fields": {
+			operations:     []string{`query { user(id: "1") { name } }`},
+			expectContains: []string{"type Query {", "): User", "name: String"},
+			expectMissing:  []string{"node(", "search(", "type Mutation", "enum Role", "interface Node", "union SearchResult", "createdAt"},
+		},
+		"fragments and interfaces": {
+			operations:     []string{`query { node(id: "1") { id ...UserRole } }`, `fragment UserRole on User { role }`},
+			expectContains: []string{"interface Node", "type User implements Node", "id: ID!", "role: Role", "enum Role"},
+			expectMissing:  []string{"user(", "name: String", "friends("},
+		},
+		"union member without fields": {
+			operations:     []string{`query Search($term: String!) { search(term: $term) { ... on User { __typename } } }`},
+			expectContains: []string{"union SearchResult =User", "type User {\n\tid: ID!\n}"},
+		},
+		"mutation": {
+			operations:     []string{`query GetUser { user(id: "1") { name } }`, `mutation CreateUser { createUser(input: {name: "a"}) { id } }`},
+			expectContains: []string{"type Mutation", "input CreateUserInput", "enum Role", "id: ID!"},
+		},
+		"no query field": {
+			operations: []string{`mutation { createUser(input: {name: "a"}) { id } }`},
+			expectErr:  "select no field of the query type",
+		},
+		"invalid operation": {
+			operations: []string{`query { missing }`},
+			expectErr:  "invalid operations",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var sources []*ast.Source
+			for _, operation := range tt.operations {
+				sources = append(sources, &ast.Source{Name: name + ".graphql", Input: operation})
+			}
+			schema, err := SliceSchemaForOperations(context.Background(), BuildClientSchemaOptions{
+				Endpoint:        server.URL,
+				WithoutBuiltins: true,
+			}, sources...)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, expect := range tt.expectContains {
+				if !strings.Contains(schema, expect) {
+					t.Errorf("expect schema to contain %q got:\n%v", expect, schema)
+				}
+			}
+			for _, expect := range tt.expectMissing {
+				if strings.Contains(schema, expect) {
+					t.Errorf("expect schema not to contain %q got:\n%v", expect, schema)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package gqlfetch
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OAuth2Login logs a user in with an SSO provider, through the OAuth2 device
+// authorization grant or the authorization code grant with PKCE in a browser, for
+// developers fetching the schema of SSO-protected internal endpoints.
+type OAuth2Login struct {
+	// ClientID is the public client registered with the provider, no client secret
+	// being sent.
+	ClientID string
+	Scopes   []string
+	TokenURL string
+	// DeviceAuthorizationURL is where Device requests a user code.
+	DeviceAuthorizationURL string
+	// AuthorizationURL is the page Browser opens.
+	AuthorizationURL string
+	// HTTPClient sends the requests to the provider, http.DefaultClient when nil.
+	HTTPClient *http.Client
+}
+
+// DeviceAuthorization is the code the user enters at VerificationURI to approve a
+// device login.
+type DeviceAuthorization struct {
+	UserCode        string
+	VerificationURI string
+	// VerificationURIComplete includes the user code, when the provider supports it.
+	VerificationURIComplete string
+	ExpiresIn               time.Duration
+}
+
+// deviceInterval is how long Device waits between token requests when the provider
+// doesn't say, and deviceSlowDown what it adds when asked to slow down. Both are
+// replaced by tests.
+var (
+	deviceInterval = 5 * time.Second
+	deviceSlowDown = 5 * time.Second
+)
+
+// oauth2Error is the error response of an OAuth2 token endpoint.
+type oauth2Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *oauth2Error) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// Device logs in with the device authorization grant, calling prompt with the code
+// the user enters in a browser, possibly on another machine, and polling the token
+// endpoint until they approve or deny the login.
+func (l OAuth2Login) Device(ctx context.Context, prompt func(DeviceAuthorization) error) (Token, error) {
+	if l.DeviceAuthorizationURL == "" {
+		return Token{}, errors.New("no device authorization URL")
+	}
+	form := url.Values{"client_id": {l.ClientID}}
+	if len(l.Scopes) > 0 {
+		form.Set("scope", strings.Join(l.Scopes, " "))
+	}
+	var response struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int64  `json:"expires_in"`
+		Interval                int64  `json:"interval"`
+	}
+	if err := l.post(ctx, l.DeviceAuthorizationURL, form, &response); err != nil {
+		return Token{}, fmt.Errorf("failed to request device code: %w", err)
+	}
+	if response.DeviceCode == "" || response.UserCode == "" || response.VerificationURI == "" {
+		return Token{}, errors.New("device authorization response has no device_code, user_code or verification_uri")
+	}
+
+	authorization := DeviceAuthorization{
+		UserCode:                response.UserCode,
+		VerificationURI:         response.VerificationURI,
+		VerificationURIComplete: response.VerificationURIComplete,
+		ExpiresIn:               time.Duration(response.ExpiresIn) * time.Second,
+	}
+	if err := prompt(authorization); err != nil {
+		return Token{}, err
+	}
+	if authorization.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, authorization.ExpiresIn)
+		defer cancel()
+	}
+
+	interval := deviceInterval
+	if response.Interval > 0 {
+		interval = time.Duration(response.Interval) * time.Second
+	}
+	form = url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {response.DeviceCode},
+		"client_id":   {l.ClientID},
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return Token{}, fmt.Errorf("device login not approved in time: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+		token, err := l.requestToken(ctx, form)
+		var oauthErr *oauth2Error
+		switch {
+		case err == nil:
+			return token, nil
+		case errors.As(err, &oauthErr) && oauthErr.Code == "authorization_pending":
+		case errors.As(err, &oauthErr) && oauthErr.Code == "slow_down":
+			interval += deviceSlowDown
+		default:
+			return Token{}, fmt.Errorf("device login failed: %w", err)
+		}
+	}
+}
+
+// Browser logs in with the authorization code grant and PKCE, calling open with the
+// authorization page, which redirects the browser to a server listening on the
+// loopback interface once the user logged in.
+func (l OAuth2Login) Browser(ctx context.Context, open func(authorizationURL string) error) (Token, error) {
+	if l.AuthorizationURL == "" {
+		return Token{}, errors.New("no authorization URL")
+	}
+	authorizationURL, err := url.Parse(l.AuthorizationURL)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to parse authorization URL: %w", err)
+	}
+	state, err := randomString()
+	if err != nil {
+		return Token{}, err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return Token{}, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to listen for the login redirect: %w", err)
+	}
+	redirectURI := "http://" + listener.Addr().String() + "/callback"
+	codes := make(chan string, 1)
+	failures := make(chan error, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			http.Error(w, "Invalid login state.", http.StatusBadRequest)
+			return
+		}
+		if code := query.Get("error"); code != "" {
+			http.Error(w, "Login failed, you can close this window.", http.StatusForbidden)
+			select {
+			case failures <- &oauth2Error{Code: code, Description: query.Get("error_description")}:
+			default:
+			}
+			return
+		}
+		io.WriteString(w, "Logged in, you can close this window.")
+		select {
+		case codes <- query.Get("code"):
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	query := authorizationURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", l.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	if len(l.Scopes) > 0 {
+		query.Set("scope", strings.Join(l.Scopes, " "))
+	}
+	authorizationURL.RawQuery = query.Encode()
+	if err := open(authorizationURL.String()); err != nil {
+		return Token{}, err
+	}
+
+	var code string
+	select {
+	case <-ctx.Done():
+		return Token{}, fmt.Errorf("browser login not completed: %w", ctx.Err())
+	case err := <-failures:
+		return Token{}, fmt.Errorf("browser login failed: %w", err)
+	case code = <-codes:
+	}
+	token, err := l.requestToken(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {l.ClientID},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		return Token{}, fmt.Errorf("browser login failed: %w", err)
+	}
+	return token, nil
+}
+
+// Refresh exchanges a refresh token for a new token, which keeps refreshToken when the
+// provider doesn't rotate it.
+func (l OAuth2Login) Refresh(ctx context.Context, refreshToken string) (Token, error) {
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {l.ClientID},
+	}
+	if len(l.Scopes) > 0 {
+		form.Set("scope", strings.Join(l.Scopes, " "))
+	}
+	token, err := l.requestToken(ctx, form)
+	if err != nil {
+		return Token{}, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+func (l OAuth2Login) requestToken(ctx context.Context, form url.Values) (Token, error) {
+	var response struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := l.post(ctx, l.TokenURL, form, &response); err != nil {
+		return Token{}, err
+	}
+	if response.AccessToken == "" {
+		return Token{}, errors.New("token response has no access_token")
+	}
+	token := Token{AccessToken: response.AccessToken, RefreshToken: response.RefreshToken}
+	if response.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// post sends form to endpoint and decodes the JSON response into v, returning an
+// *oauth2Error for the error responses of the provider.
+func (l OAuth2Login) post(ctx context.Context, endpoint string, form url.Values, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	client := l.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		oauthErr := &oauth2Error{}
+		if json.Unmarshal(body, oauthErr) == nil && oauthErr.Code != "" {
+			return oauthErr
+		}
+		if len(body) > 1024 {
+			body = body[:1024]
+		}
+		return fmt.Errorf("request failed: %s: %s", res.Status, body)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// randomString returns 32 random bytes encoded for URLs, as PKCE code verifiers
+// require.
+func randomString() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", fmt.Errorf("failed to generate random string: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
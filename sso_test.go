@@ -0,0 +1,187 @@
+package gqlfetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newOAuth2Provider serves the device authorization and token endpoints of an SSO
+// provider, answering the device code polls with the given errors before issuing a
+// token, and checking the PKCE code verifier against the challenge of code "abc".
+func newOAuth2Provider(t *testing.T, polls []string, challenge *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		respond := func(status int, body interface{}) {
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		}
+		if r.Form.Get("client_id") != "gqlfetch" {
+			respond(http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+			return
+		}
+		switch {
+		case r.URL.Path == "/device":
+			respond(http.StatusOK, map[string]interface{}{"device_code": "device", "user_code": "ABCD-EFGH", "verification_uri": "https://sso.example.com/device", "expires_in": 60})
+		case r.Form.Get("grant_type") == "urn:ietf:params:oauth:grant-type:device_code":
+			if len(polls) > 0 {
+				code := polls[0]
+				polls = polls[1:]
+				respond(http.StatusBadRequest, map[string]string{"error": code})
+				return
+			}
+			respond(http.StatusOK, map[string]interface{}{"access_token": "device-token", "refresh_token": "refresh", "expires_in": 3600})
+		case r.Form.Get("grant_type") == "authorization_code":
+			verifier := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			if r.Form.Get("code") != "abc" || base64.RawURLEncoding.EncodeToString(verifier[:]) != *challenge {
+				respond(http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+				return
+			}
+			respond(http.StatusOK, map[string]interface{}{"access_token": "browser-token"})
+		case r.Form.Get("grant_type") == "refresh_token":
+			respond(http.StatusOK, map[string]interface{}{"access_token": "refreshed-" + r.Form.Get("refresh_token"), "expires_in": 3600})
+		default:
+			respond(http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		}
+	}))
+}
+
+func TestOAuth2Login_Device(t *testing.T) {
+	interval, slowDown := deviceInterval, deviceSlowDown
+	deviceInterval, deviceSlowDown = time.Millisecond, time.Millisecond
+	defer func() { deviceInterval, deviceSlowDown = interval, slowDown }()
+
+	tests := map[string]struct {
+		polls     []string
+		expectErr string
+	}{
+		"approved":         {},
+		"pending":          {polls: []string{"authorization_pending", "slow_down", "authorization_pending"}},
+		"denied":           {polls: []string{"authorization_pending", "access_denied"}, expectErr: "access_denied"},
+		"expired":          {polls: []string{"expired_token"}, expectErr: "expired_token"},
+		"unknown provider": {polls: nil, expectErr: "invalid_client"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider := newOAuth2Provider(t, tt.polls, nil)
+			defer provider.Close()
+			clientID := "gqlfetch"
+			if name == "unknown provider" {
+				clientID = "unknown"
+			}
+			var prompted DeviceAuthorization
+			token, err := OAuth2Login{
+				ClientID:               clientID,
+				TokenURL:               provider.URL + "/token",
+				DeviceAuthorizationURL: provider.URL + "/device",
+			}.Device(context.Background(), func(authorization DeviceAuthorization) error {
+				prompted = authorization
+				return nil
+			})
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if prompted.UserCode != "ABCD-EFGH" || prompted.VerificationURI != "https://sso.example.com/device" || prompted.ExpiresIn != time.Minute {
+				t.Errorf("expect the user code to be prompted got: %+v", prompted)
+			}
+			if token.AccessToken != "device-token" || token.RefreshToken != "refresh" || token.Expiry.IsZero() {
+				t.Errorf("expect the device token got: %+v", token)
+			}
+		})
+	}
+}
+
+func TestOAuth2Login_Browser(t *testing.T) {
+	var challenge string
+	provider := newOAuth2Provider(t, nil, &challenge)
+	defer provider.Close()
+	login := OAuth2Login{
+		ClientID:         "gqlfetch",
+		Scopes:           []string{"openid", "graphql"},
+		TokenURL:         provider.URL + "/token",
+		AuthorizationURL: provider.URL + "/authorize?audience=graphql",
+	}
+
+	tests := map[string]struct {
+		redirect  func(query url.Values) url.Values
+		expectErr string
+	}{
+		"logged in": {
+			redirect: func(query url.Values) url.Values {
+				return url.Values{"code": {"abc"}, "state": {query.Get("state")}}
+			},
+		},
+		"denied": {
+			redirect: func(query url.Values) url.Values {
+				return url.Values{"error": {"access_denied"}, "state": {query.Get("state")}}
+			},
+			expectErr: "access_denied",
+		},
+		"wrong code": {
+			redirect: func(query url.Values) url.Values {
+				return url.Values{"code": {"xyz"}, "state": {query.Get("state")}}
+			},
+			expectErr: "invalid_grant",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			token, err := login.Browser(context.Background(), func(authorizationURL string) error {
+				parsed, err := url.Parse(authorizationURL)
+				if err != nil {
+					return err
+				}
+				query := parsed.Query()
+				for param, expected := range map[string]string{"audience": "graphql", "response_type": "code", "client_id": "gqlfetch", "scope": "openid graphql", "code_challenge_method": "S256"} {
+					if query.Get(param) != expected {
+						t.Errorf("expect %s %q got: %q", param, expected, query.Get(param))
+					}
+				}
+				challenge = query.Get("code_challenge")
+
+				// A redirect with the wrong state is rejected without ending the login.
+				res, err := http.Get(query.Get("redirect_uri") + "?code=abc&state=forged")
+				if err != nil {
+					return err
+				}
+				res.Body.Close()
+				if res.StatusCode != http.StatusBadRequest {
+					t.Errorf("expect the forged redirect to be rejected got: %s", res.Status)
+				}
+				res, err = http.Get(query.Get("redirect_uri") + "?" + tt.redirect(query).Encode())
+				if err != nil {
+					return err
+				}
+				return res.Body.Close()
+			})
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if token.AccessToken != "browser-token" {
+				t.Errorf("expect the browser token got: %+v", token)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package gqlfetch
+
+import (
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// SchemaStats summarizes the size of a schema, built-in types and directives excluded.
+type SchemaStats struct {
+	// Types counts the types by kind, such as OBJECT or INPUT_OBJECT.
+	Types       map[ast.DefinitionKind]int `json:"types"`
+	Fields      int                        `json:"fields"`
+	Arguments   int                        `json:"arguments"`
+	InputFields int                        `json:"inputFields"`
+	EnumValues  int                        `json:"enumValues"`
+	// Deprecated counts the deprecated fields, arguments, input fields and enum values.
+	Deprecated int `json:"deprecated"`
+	Directives int `json:"directives"`
+	// MaxDepth is the number of nested selections needed to reach the deepest type
+	// reachable from the query type, which has a depth of 1.
+	MaxDepth int `json:"maxDepth"`
+}
+
+// TypeCount returns the number of types of every kind.
+func (s SchemaStats) TypeCount() int {
+	count := 0
+	for _, n := range s.Types {
+		count += n
+	}
+	return count
+}
+
+// Stats computes the statistics of schema, as returned by BuildClientSchemaAST or
+// LoadSchema.
+func Stats(schema *ast.Schema) SchemaStats {
+	doc := userSchemaDocument(schema)
+	stats := SchemaStats{
+		Types:      map[ast.DefinitionKind]int{},
+		Directives: len(doc.Directives),
+	}
+	deprecated := func(directives ast.DirectiveList) {
+		if directives.ForName("deprecated") != nil {
+			stats.Deprecated++
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		stats.Types[def.Kind]++
+		for _, field := range def.Fields {
+			deprecated(field.Directives)
+			if def.Kind == ast.InputObject {
+				stats.InputFields++
+				continue
+			}
+			stats.Fields++
+			for _, arg := range field.Arguments {
+				stats.Arguments++
+				deprecated(arg.Directives)
+			}
+		}
+		for _, value := range def.EnumValues {
+			stats.EnumValues++
+			deprecated(value.Directives)
+		}
+	}
+
+	stats.MaxDepth = maxDepth(schema)
+	return stats
+}
+
+// maxDepth walks the schema breadth first from the query type, following the fields of
+// objects and interfaces and the members of unions.
+func maxDepth(schema *ast.Schema) int {
+	if schema.Query == nil {
+		return 0
+	}
+	depth := 0
+	seen := map[string]bool{schema.Query.Name: true}
+	for level := []*ast.Definition{schema.Query}; len(level) > 0; depth++ {
+		var next []*ast.Definition
+		visit := func(name string) {
+			def := schema.Types[name]
+			if def == nil || seen[name] || def.BuiltIn {
+				return
+			}
+			seen[name] = true
+			next = append(next, def)
+		}
+		for _, def := range level {
+			for _, field := range def.Fields {
+				if !strings.HasPrefix(field.Name, "__") {
+					visit(field.Type.Name())
+				}
+			}
+			if def.Kind == ast.Union || def.Kind == ast.Interface {
+				for _, possible := range schema.GetPossibleTypes(def) {
+					visit(possible.Name)
+				}
+			}
+		}
+		level = next
+	}
+	return depth
+}
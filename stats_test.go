@@ -0,0 +1,75 @@
+package gqlfetch
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestStats(t *testing.T) {
+	schema, err := LoadSchema(&ast.Source{Name: "schema.graphql", Input: `
+directive @cached on FIELD_DEFINITION
+
+type Query {
+	user(id: ID!): User
+	search(term: String): [SearchResult!]!
+}
+
+interface Node {
+	id: ID!
+}
+
+type User implements Node {
+	id: ID!
+	name: String @deprecated(reason: "Use fullName.")
+	posts(first: Int): [Post]
+}
+
+type Post {
+	title: String
+	comments: [Comment]
+}
+
+type Comment {
+	text: String
+}
+
+union SearchResult = User | Post
+
+enum Role {
+	ADMIN
+	GUEST @deprecated
+}
+
+input Filter {
+	term: String
+}
+`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expect := SchemaStats{
+		Types: map[ast.DefinitionKind]int{
+			ast.Object:      4,
+			ast.Interface:   1,
+			ast.Union:       1,
+			ast.Enum:        1,
+			ast.InputObject: 1,
+		},
+		Fields:      9,
+		Arguments:   3,
+		InputFields: 1,
+		EnumValues:  2,
+		Deprecated:  2,
+		Directives:  1,
+		MaxDepth:    4,
+	}
+	if got := Stats(schema); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expect stats:\n%+v\ngot:\n%+v", expect, got)
+	}
+	if got := expect.TypeCount(); got != 8 {
+		t.Errorf("expect 8 types got: %d", got)
+	}
+}
@@ -0,0 +1,104 @@
+package gqlfetch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// BuildClientSchemaTo writes the schema BuildClientSchemaWithOptions returns to w. SDL is
+// written a type at a time as it is printed rather than accumulated, so memory use
+// doesn't grow with the printed schema. As validation needs the whole SDL, the streamed
+// SDL isn't validated, as with SkipValidation. Other outputs, such as cached schemas,
+// federation SDL, introspection JSON or GQLParserFormatter, are written once built.
+func BuildClientSchemaTo(ctx context.Context, w io.Writer, options BuildClientSchemaOptions) error {
+	options, err := options.withPreset()
+	if err != nil {
+		return err
+	}
+	if options.Cache != nil || options.Source != SourceIntrospection || options.FederationSDL ||
+		options.OutputFormat != OutputSDL || options.GQLParserFormatter {
+		sdl, err := BuildClientSchemaWithOptions(ctx, options)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, sdl)
+		return err
+	}
+
+	metrics, start := options.metrics(), time.Now()
+	metrics.IncFetches(options.Endpoint)
+	ctx, span := options.startSpan(ctx, "gqlfetch.fetch")
+	counter := &countingWriter{writer: w}
+	err = streamClientSchema(ctx, counter, options)
+	span.End(err)
+
+	metrics.ObserveDuration(options.Endpoint, time.Since(start))
+	if err != nil {
+		metrics.IncErrors(options.Endpoint)
+		return err
+	}
+	metrics.ObserveSchemaSize(options.Endpoint, counter.written)
+	return nil
+}
+
+func streamClientSchema(ctx context.Context, w io.Writer, options BuildClientSchemaOptions) error {
+	schema, err := fetchIntrospection(ctx, options)
+	if errors.Is(err, ErrIntrospectionDisabled) && options.FallbackSchemaURL != "" {
+		sdl, err := fetchFallbackSchema(ctx, options)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, sdl)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	_, span := options.startSpan(ctx, "gqlfetch.print")
+	span.SetAttribute("gqlfetch.schema.types", len(schema.Types))
+	err = writeSchema(ctx, w, schema, builtinFilterOf(options), options.SDLFormat)
+	span.End(err)
+	return err
+}
+
+// writeSchema prints schema as SDL to w, writing each type once printed.
+func writeSchema(ctx context.Context, w io.Writer, schema introspectionSchema, builtins builtinFilter, format SDLFormat) error {
+	schema, err := withDefinedAppliedDirectives(schema)
+	if err != nil {
+		return err
+	}
+	sb := &strings.Builder{}
+
+	printSchemaDefinition(sb, schema, format)
+	if err := printDirectives(sb, schema.Directives, builtins, format); err != nil {
+		return err
+	}
+	printWarnings(sb, schema.warnings)
+	for i := range schema.Types {
+		if _, err := io.WriteString(w, sb.String()); err != nil {
+			return err
+		}
+		sb.Reset()
+		if err := printTypeRange(ctx, sb, schema.Types[i:i+1], builtins, format); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// countingWriter counts the bytes written to writer.
+type countingWriter struct {
+	writer  io.Writer
+	written int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.written += n
+	return n, err
+}
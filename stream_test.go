@@ -0,0 +1,44 @@
+package gqlfetch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestBuildClientSchemaTo(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+
+	tests := map[string]struct {
+		options BuildClientSchemaOptions
+	}{
+		"streamed":           {options: BuildClientSchemaOptions{Endpoint: server.URL, WithoutBuiltins: true}},
+		"formatted":          {options: BuildClientSchemaOptions{Endpoint: server.URL, SDLFormat: SDLFormat{Indent: "  ", WrapWidth: 80}}},
+		"introspection JSON": {options: BuildClientSchemaOptions{Endpoint: server.URL, OutputFormat: OutputIntrospectionJSON}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			expected, err := BuildClientSchemaWithOptions(context.Background(), tt.options)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			written := &strings.Builder{}
+			if err := BuildClientSchemaTo(context.Background(), written, tt.options); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if written.String() != expected {
+				t.Errorf("expect:\n%v\ngot:\n%v", expected, written)
+			}
+		})
+	}
+
+	err := BuildClientSchemaTo(context.Background(), failingWriter{}, BuildClientSchemaOptions{Endpoint: server.URL})
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("expect the write error got: %v", err)
+	}
+}
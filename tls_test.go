@@ -0,0 +1,212 @@
+package gqlfetch
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBuildClientSchemaWithOptions_TLS(t *testing.T) {
+	dir := t.TempDir()
+	clientCert, clientPool := writeClientCertificate(t, dir)
+
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientPool}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatalf("write CA bundle: %v", err)
+	}
+
+	tests := map[string]struct {
+		tls       *TLSOptions
+		expectErr bool
+	}{
+		"mutual TLS":            {tls: &TLSOptions{CertFile: clientCert + ".crt", KeyFile: clientCert + ".key", CAFile: caFile}},
+		"skipping verification": {tls: &TLSOptions{CertFile: clientCert + ".crt", KeyFile: clientCert + ".key", InsecureSkipVerify: true}},
+		"untrusted server":      {tls: &TLSOptions{CertFile: clientCert + ".crt", KeyFile: clientCert + ".key"}, expectErr: true},
+		"no client certificate": {tls: &TLSOptions{CAFile: caFile}, expectErr: true},
+		"missing key":           {tls: &TLSOptions{CertFile: clientCert + ".crt", CAFile: caFile}, expectErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint: server.URL,
+				TLS:      tt.tls,
+			})
+			if tt.expectErr != (err != nil) {
+				t.Errorf("expect error: %v got: %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemaWithOptions_Connection(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	newServer := func(http2 bool, protos chan<- string) *httptest.Server {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			protos <- r.Proto
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(fixture)
+		}))
+		server.EnableHTTP2 = http2
+		server.StartTLS()
+		return server
+	}
+
+	tests := map[string]struct {
+		http2       bool
+		connection  *ConnectionOptions
+		expectProto string
+		expectErr   bool
+	}{
+		"negotiated HTTP/2":   {http2: true, connection: &ConnectionOptions{}, expectProto: "HTTP/2.0"},
+		"forced HTTP/1.1":     {http2: true, connection: &ConnectionOptions{HTTPVersion: HTTP1}, expectProto: "HTTP/1.1"},
+		"required HTTP/2":     {http2: true, connection: &ConnectionOptions{HTTPVersion: HTTP2}, expectProto: "HTTP/2.0"},
+		"HTTP/2 unsupported":  {connection: &ConnectionOptions{HTTPVersion: HTTP2}, expectProto: "HTTP/1.1", expectErr: true},
+		"unknown version":     {connection: &ConnectionOptions{HTTPVersion: 3}, expectErr: true},
+		"timeouts":            {connection: &ConnectionOptions{DialTimeout: time.Second, KeepAlive: -1, IdleConnTimeout: time.Second}, expectProto: "HTTP/1.1"},
+		"without keep-alives": {http2: true, connection: &ConnectionOptions{DisableKeepAlives: true, HTTPVersion: HTTP1}, expectProto: "HTTP/1.1"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			protos := make(chan string, 1)
+			server := newServer(tt.http2, protos)
+			defer server.Close()
+
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:   server.URL,
+				TLS:        &TLSOptions{InsecureSkipVerify: true},
+				Connection: tt.connection,
+			})
+			if tt.expectErr != (err != nil) {
+				t.Errorf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			select {
+			case proto := <-protos:
+				if proto != tt.expectProto {
+					t.Errorf("expect %s got: %s", tt.expectProto, proto)
+				}
+			default:
+				if tt.expectProto != "" {
+					t.Errorf("expect a %s request got none", tt.expectProto)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemaWithOptions_ConnectionReuse(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params requestParams
+		json.NewDecoder(r.Body).Decode(&params)
+		w.Header().Set("Content-Type", "application/json")
+		if params.OperationName == "ProbeTypename" {
+			w.Write([]byte(`{"data": {"__typename": "Query"}}`))
+			return
+		}
+		w.Write(fixture)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	options := BuildClientSchemaOptions{
+		Endpoint:   server.URL,
+		TLS:        &TLSOptions{InsecureSkipVerify: true},
+		Connection: &ConnectionOptions{HTTPVersion: HTTP1},
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := BuildClientSchemaWithOptions(context.Background(), options); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result := Probe(context.Background(), options); !result.Introspectable {
+			t.Fatalf("unexpected probe failure: %v", result.Err)
+		}
+	}
+	if got := atomic.LoadInt32(&connections); got != 1 {
+		t.Errorf("expect every request to reuse one connection got: %d connections", got)
+	}
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key to
+// <path>.crt and <path>.key, returning path and a pool trusting the certificate.
+func writeClientCertificate(t *testing.T, dir string) (string, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gqlfetch"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	path := filepath.Join(dir, "client")
+	if err := os.WriteFile(path+".crt", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	if err := os.WriteFile(path+".key", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+	return path, pool
+}
@@ -0,0 +1,68 @@
+package gqlfetch
+
+import (
+	"context"
+	"io"
+)
+
+// Tracer starts the spans describing a fetch: gqlfetch.fetch for the whole fetch,
+// gqlfetch.request for every HTTP attempt, gqlfetch.decode for reading the response and
+// gqlfetch.print for printing the SDL. It is small enough to be adapted to an
+// OpenTelemetry trace.Tracer in a few lines, without gqlfetch depending on it:
+//
+//	type otelTracer struct{ tracer trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, gqlfetch.Span) {
+//		ctx, span := t.tracer.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ span trace.Span }
+//
+//	func (s otelSpan) SetAttribute(key string, value interface{}) {
+//		s.span.SetAttributes(attribute.String(key, fmt.Sprint(value)))
+//	}
+//
+//	func (s otelSpan) End(err error) {
+//		if err != nil {
+//			s.span.RecordError(err)
+//			s.span.SetStatus(codes.Error, err.Error())
+//		}
+//		s.span.End()
+//	}
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer. Attribute values are strings, ints or int64s.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	// End ends the span, recording err when the traced operation failed.
+	End(err error)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) End(error)                        {}
+
+func (options BuildClientSchemaOptions) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if options.Tracer == nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := options.Tracer.Start(ctx, name)
+	span.SetAttribute("gqlfetch.endpoint", options.Endpoint)
+	return ctx, span
+}
+
+// countingReader counts the bytes read, to report response sizes.
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	return n, err
+}
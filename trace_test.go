@@ -0,0 +1,65 @@
+package gqlfetch
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	name       string
+	attributes map[string]interface{}
+	ended      bool
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &recordingSpan{name: name, attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func (s *recordingSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (s *recordingSpan) End(err error)                              { s.ended = true }
+
+func TestBuildClientSchemaWithOptions_Tracer(t *testing.T) {
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+
+	tracer := &recordingTracer{}
+	_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint: server.URL,
+		Tracer:   tracer,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	spans := map[string]*recordingSpan{}
+	for _, span := range tracer.spans {
+		names = append(names, span.name)
+		spans[span.name] = span
+		if !span.ended {
+			t.Errorf("expect span %s to be ended", span.name)
+		}
+		if span.attributes["gqlfetch.endpoint"] != server.URL {
+			t.Errorf("expect span %s to have the endpoint got: %v", span.name, span.attributes)
+		}
+	}
+	expect := "gqlfetch.fetch,gqlfetch.request,gqlfetch.decode,gqlfetch.print"
+	if got := strings.Join(names, ","); got != expect {
+		t.Fatalf("expect spans: %v got: %v", expect, got)
+	}
+	if status := spans["gqlfetch.request"].attributes["http.status_code"]; status != 200 {
+		t.Errorf("expect status code 200 got: %v", status)
+	}
+	if size, _ := spans["gqlfetch.decode"].attributes["gqlfetch.response.size"].(int64); size == 0 {
+		t.Errorf("expect the response size got: %v", size)
+	}
+	if types, _ := spans["gqlfetch.print"].attributes["gqlfetch.schema.types"].(int); types == 0 {
+		t.Errorf("expect the type count got: %v", types)
+	}
+}
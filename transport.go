@@ -0,0 +1,121 @@
+package gqlfetch
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// Transport sends the GraphQL requests built by gqlfetch. The request is already
+// encoded following the GraphQL-over-HTTP specification and authenticated, so
+// transports that don't speak HTTP, such as a Lambda invocation or a gRPC bridge, can
+// forward its body and describe the result as an *http.Response.
+type Transport interface {
+	Execute(ctx context.Context, req *http.Request) (*http.Response, error)
+}
+
+// TransportFunc adapts a function to the Transport interface.
+type TransportFunc func(ctx context.Context, req *http.Request) (*http.Response, error)
+
+func (f TransportFunc) Execute(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return f(ctx, req)
+}
+
+// HTTPTransport sends requests with Client, http.DefaultClient when nil.
+type HTTPTransport struct {
+	Client *http.Client
+}
+
+func (t HTTPTransport) Execute(ctx context.Context, req *http.Request) (*http.Response, error) {
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req.WithContext(ctx))
+}
+
+// HandlerTransport serves requests in-process with handler, which is handy to
+// introspect a server from its own tests or build steps without listening on a port.
+func HandlerTransport(handler http.Handler) Transport {
+	return TransportFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		req = req.WithContext(ctx)
+		req.RequestURI = req.URL.RequestURI()
+		if req.Host == "" {
+			req.Host = req.URL.Host
+		}
+
+		w := &handlerResponseWriter{header: make(http.Header)}
+		handler.ServeHTTP(w, req)
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		return &http.Response{
+			Status:        http.StatusText(w.status),
+			StatusCode:    w.status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        w.header,
+			Body:          io.NopCloser(&w.body),
+			ContentLength: int64(w.body.Len()),
+			Request:       req,
+		}, nil
+	})
+}
+
+// handlerResponseWriter buffers the response written by an in-process handler.
+type handlerResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *handlerResponseWriter) Header() http.Header { return w.header }
+
+func (w *handlerResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *handlerResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+// Middleware wraps a Transport, to modify requests and responses, record them or
+// answer without calling next.
+type Middleware func(next Transport) Transport
+
+// transport returns options.Transport, or an HTTPTransport using options.httpClient,
+// wrapped by options.Middleware.
+func (options BuildClientSchemaOptions) transport() (Transport, error) {
+	transport := options.Transport
+	if transport == nil {
+		client, err := options.httpClient()
+		if err != nil {
+			return nil, err
+		}
+		transport = HTTPTransport{Client: client}
+	}
+	for i := len(options.Middleware) - 1; i >= 0; i-- {
+		transport = options.Middleware[i](transport)
+	}
+	return transport, nil
+}
+
+// handlerEndpoint is the endpoint BuildSchemaFromHandler uses when none is set.
+const handlerEndpoint = "http://localhost/graphql"
+
+// BuildSchemaFromHandler introspects the GraphQL server implemented by handler without
+// listening on a port, so servers can snapshot their own schema in unit tests. The
+// request is sent to options.Endpoint, http://localhost/graphql when empty, and any
+// Transport set in options is replaced.
+func BuildSchemaFromHandler(ctx context.Context, handler http.Handler, options BuildClientSchemaOptions) (string, error) {
+	if options.Endpoint == "" {
+		options.Endpoint = handlerEndpoint
+	}
+	options.Transport = HandlerTransport(handler)
+	return BuildClientSchemaWithOptions(ctx, options)
+}
@@ -0,0 +1,111 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBuildClientSchemaWithOptions_HandlerTransport(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params requestParams
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil || !strings.Contains(params.Query, "__schema") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if r.URL.Path != "/graphql" || r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	})
+
+	schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint:  "http://in-process/graphql",
+		Auth:      APIKey{Key: "secret"},
+		Transport: HandlerTransport(handler),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(schema, "type User implements Node") {
+		t.Errorf("expect the introspected schema got:\n%v", schema)
+	}
+}
+
+func TestBuildSchemaFromHandler(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	server := newIntrospectionServer(t, "testdata/introspection.json")
+	expect, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var path string
+	schema, err := BuildSchemaFromHandler(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}), BuildClientSchemaOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schema != expect {
+		t.Errorf("expect:\n%v\ngot:\n%v", expect, schema)
+	}
+	if path != "/graphql" {
+		t.Errorf("expect request to /graphql got: %v", path)
+	}
+}
+
+func TestBuildClientSchemaWithOptions_Middleware(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Transport) Transport {
+			return TransportFunc(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" request")
+				req.Header.Add("X-Middleware", name)
+				res, err := next.Execute(ctx, req)
+				calls = append(calls, name+" response")
+				return res, err
+			})
+		}
+	}
+	shortCircuit := func(next Transport) Transport {
+		return HandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got := strings.Join(r.Header.Values("X-Middleware"), ","); got != "outer,inner" {
+				t.Errorf("expect requests to go through outer then inner got: %v", got)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(fixture)
+		}))
+	}
+
+	_, err = BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint:   "http://unreachable.invalid/graphql",
+		Middleware: []Middleware{record("outer"), record("inner"), shortCircuit},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := "outer request,inner request,inner response,outer response"
+	if got := strings.Join(calls, ","); got != expect {
+		t.Errorf("expect calls: %v got: %v", expect, got)
+	}
+}
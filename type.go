@@ -0,0 +1,103 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ErrTypeNotFound is returned by GetTypeSDL when the schema has no type of that name.
+var ErrTypeNotFound = errors.New("type not found")
+
+var graphQLName = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// GetTypeSDL prints the definition of a single type, introspecting only that type with
+// __type(name:) instead of downloading the whole schema. Local endpoints are read whole
+// and the type picked from them.
+func GetTypeSDL(ctx context.Context, options BuildClientSchemaOptions, typeName string) (string, error) {
+	if !graphQLName.MatchString(typeName) {
+		return "", fmt.Errorf("invalid type name %q", typeName)
+	}
+	options, err := options.withPreset()
+	if err != nil {
+		return "", err
+	}
+
+	typ, err := fetchType(ctx, options, typeName)
+	if err != nil {
+		return "", err
+	}
+	if options.WithoutDescriptions {
+		schema, err := withoutDescriptions(introspectionSchema{Types: []introspectionTypeDefinition{typ}})
+		if err != nil {
+			return "", err
+		}
+		typ = schema.Types[0]
+	}
+
+	sb := &strings.Builder{}
+	printDescription(sb, typ.Description)
+	if err := printType(sb, typ, options.SDLFormat); err != nil {
+		return "", &PrintError{Path: typ.Name, Err: err}
+	}
+	sb.WriteString("\n")
+	return sb.String(), nil
+}
+
+func fetchType(ctx context.Context, options BuildClientSchemaOptions, typeName string) (introspectionTypeDefinition, error) {
+	notFound := fmt.Errorf("%w: %s", ErrTypeNotFound, typeName)
+	if isLocalEndpoint(options.Endpoint) {
+		schema, err := readLocalIntrospection(ctx, options.Endpoint)
+		if err != nil {
+			return introspectionTypeDefinition{}, err
+		}
+		for _, typ := range schema.Types {
+			if typ.Name == typeName {
+				return typ, nil
+			}
+		}
+		return introspectionTypeDefinition{}, notFound
+	}
+
+	query, err := introspectionQuery(introspectionQueryOptions{
+		InputValueDeprecation: options.InputValueDeprecation,
+		SpecifiedByURL:        options.SpecifiedByURL,
+		OneOf:                 options.OneOf,
+		AppliedDirectives:     options.AppliedDirectives,
+		WithoutDescriptions:   options.WithoutDescriptions,
+		WithoutDeprecated:     options.WithoutDeprecated,
+		TypeName:              typeName,
+	})
+	if err != nil {
+		return introspectionTypeDefinition{}, err
+	}
+
+	var typ *introspectionTypeDefinition
+	err = execute(ctx, options, requestParams{Query: query, OperationName: introspectionOperationName}, func(body io.Reader) error {
+		var response struct {
+			Errors GraphQLErrors `json:"errors"`
+			Data   struct {
+				Type *introspectionTypeDefinition `json:"__type"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(body).Decode(&response); err != nil {
+			return err
+		}
+		if len(response.Errors) != 0 {
+			return response.Errors
+		}
+		typ = response.Data.Type
+		return nil
+	})
+	if err != nil {
+		return introspectionTypeDefinition{}, err
+	}
+	if typ == nil {
+		return introspectionTypeDefinition{}, notFound
+	}
+	return *typ, nil
+}
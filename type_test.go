@@ -0,0 +1,77 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGetTypeSDL(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var introspection introspectionResults
+	if err := json.Unmarshal(fixture, &introspection); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params requestParams
+		json.NewDecoder(r.Body).Decode(&params)
+		if strings.Contains(params.Query, "__schema") {
+			t.Errorf("expect only the type to be introspected got:\n%v", params.Query)
+		}
+		var found *introspectionTypeDefinition
+		for i, typ := range introspection.Data.Schema.Types {
+			if strings.Contains(params.Query, `__type(name: "`+typ.Name+`")`) {
+				found = &introspection.Data.Schema.Types[i]
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"__type": found}})
+	}))
+	defer server.Close()
+
+	full, err := BuildSchemaFromIntrospectionJSON(strings.NewReader(string(fixture)), BuildClientSchemaOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		endpoint  string
+		typeName  string
+		expectErr error
+	}{
+		"object":         {endpoint: server.URL, typeName: "User"},
+		"enum":           {endpoint: server.URL, typeName: "Role"},
+		"local endpoint": {endpoint: "file://testdata/introspection.json", typeName: "CreateUserInput"},
+		"unknown type":   {endpoint: server.URL, typeName: "Missing", expectErr: ErrTypeNotFound},
+		"unknown local":  {endpoint: "file://testdata/introspection.json", typeName: "Missing", expectErr: ErrTypeNotFound},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sdl, err := GetTypeSDL(context.Background(), BuildClientSchemaOptions{Endpoint: tt.endpoint}, tt.typeName)
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expect error: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(sdl, " "+tt.typeName+" ") || !strings.Contains(full, sdl) {
+				t.Errorf("expect the definition of %s as printed in the schema got:\n%v", tt.typeName, sdl)
+			}
+		})
+	}
+
+	if _, err := GetTypeSDL(context.Background(), BuildClientSchemaOptions{Endpoint: server.URL}, `User") { name } #`); err == nil {
+		t.Errorf("expect invalid type names to be rejected")
+	}
+}
@@ -0,0 +1,59 @@
+package gqlfetch
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// unixEndpointPrefix marks endpoints served over a unix domain socket, such as
+// unix:///var/run/app.sock:/graphql, the HTTP path following the socket path.
+const unixEndpointPrefix = "unix://"
+
+// unixEndpointHost is the host of the requests sent over a unix domain socket.
+const unixEndpointHost = "localhost"
+
+func isUnixEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, unixEndpointPrefix)
+}
+
+// parseUnixEndpoint splits a unix:// endpoint into the path of its socket and the URL
+// of the requests sent over it, the HTTP path being / when left out.
+func parseUnixEndpoint(endpoint string) (socket string, requestURL string, err error) {
+	socket = strings.TrimPrefix(endpoint, unixEndpointPrefix)
+	path := "/"
+	if i := strings.Index(socket, ":"); i >= 0 {
+		socket, path = socket[:i], socket[i+1:]
+	}
+	if socket == "" {
+		return "", "", fmt.Errorf("no socket path in unix endpoint %s", endpoint)
+	}
+	if !strings.HasPrefix(path, "/") {
+		return "", "", fmt.Errorf("invalid HTTP path %q in unix endpoint %s, expected it to start with /", path, endpoint)
+	}
+	return socket, "http://" + unixEndpointHost + path, nil
+}
+
+// requestURL returns the URL the requests to endpoint are sent to.
+func requestURL(endpoint string) (string, error) {
+	if !isUnixEndpoint(endpoint) {
+		return endpoint, nil
+	}
+	_, requestURL, err := parseUnixEndpoint(endpoint)
+	return requestURL, err
+}
+
+// dialUnixSocket makes transport connect to socket with its own dialer, so the
+// connection options still apply, and never through a proxy.
+func dialUnixSocket(transport *http.Transport, socket string) {
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dial(ctx, "unix", socket)
+	}
+	transport.Proxy = nil
+}
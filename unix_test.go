@@ -0,0 +1,83 @@
+package gqlfetch
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildClientSchemaWithOptions_UnixSocket(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	// Socket paths are limited to about 100 bytes, which t.TempDir can exceed.
+	dir, err := os.MkdirTemp("", "gqlfetch")
+	if err != nil {
+		t.Fatalf("create socket dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "app.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	var paths []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	tests := map[string]struct {
+		endpoint   string
+		method     string
+		connection *ConnectionOptions
+		proxyURL   string
+		expectPath string
+		expectErr  string
+	}{
+		"with path":          {endpoint: "unix://" + socket + ":/graphql", expectPath: "/graphql"},
+		"without path":       {endpoint: "unix://" + socket, expectPath: "/"},
+		"get request":        {endpoint: "unix://" + socket + ":/graphql", method: http.MethodGet, expectPath: "/graphql"},
+		"connection options": {endpoint: "unix://" + socket + ":/graphql", connection: &ConnectionOptions{HTTPVersion: HTTP1, DisableKeepAlives: true}, expectPath: "/graphql"},
+		"proxy ignored":      {endpoint: "unix://" + socket + ":/graphql", proxyURL: "http://127.0.0.1:1", expectPath: "/graphql"},
+		"missing socket":     {endpoint: "unix://:/graphql", expectErr: "no socket path"},
+		"relative HTTP path": {endpoint: "unix://" + socket + ":graphql", expectErr: "expected it to start with /"},
+		"nonexistent socket": {endpoint: "unix://" + filepath.Join(dir, "missing.sock") + ":/graphql", expectErr: "missing.sock"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			paths = nil
+			schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:   tt.endpoint,
+				Method:     tt.method,
+				Connection: tt.connection,
+				ProxyURL:   tt.proxyURL,
+			})
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(schema, "type Query {") {
+				t.Errorf("expect the schema got:\n%s", schema)
+			}
+			if len(paths) != 1 || paths[0] != tt.expectPath {
+				t.Errorf("expect a request to %s got: %v", tt.expectPath, paths)
+			}
+		})
+	}
+}
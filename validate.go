@@ -0,0 +1,44 @@
+package gqlfetch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// SchemaValidationError is returned when the printed SDL doesn't load with LoadSchema,
+// which points at a bug in the printer or an introspection result it can't represent.
+type SchemaValidationError struct {
+	Err error
+	// Line is the SDL line the error was reported at, empty when unknown.
+	Line string
+	SDL  string
+}
+
+func (e *SchemaValidationError) Error() string {
+	if e.Line == "" {
+		return fmt.Sprintf("generated schema is invalid: %v", e.Err)
+	}
+	return fmt.Sprintf("generated schema is invalid: %v: %q", e.Err, e.Line)
+}
+
+func (e *SchemaValidationError) Unwrap() error { return e.Err }
+
+// validateSDL loads the printed SDL.
+func validateSDL(sdl string) error {
+	if _, err := LoadSchema(&ast.Source{Name: "generated schema", Input: sdl}); err != nil {
+		validationErr := &SchemaValidationError{Err: err, SDL: sdl}
+		var gqlErr *gqlerror.Error
+		if errors.As(err, &gqlErr) && len(gqlErr.Locations) > 0 {
+			lines := strings.Split(sdl, "\n")
+			if line := gqlErr.Locations[0].Line; line > 0 && line <= len(lines) {
+				validationErr.Line = lines[line-1]
+			}
+		}
+		return validationErr
+	}
+	return nil
+}
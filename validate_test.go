@@ -0,0 +1,74 @@
+package gqlfetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestBuildClientSchemaWithOptions_Validation(t *testing.T) {
+	// The server reports a field of a type it doesn't define.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"__schema": {
+			"queryType": {"name": "Query"},
+			"types": [
+				{"kind": "OBJECT", "name": "Query", "fields": [{"name": "me", "args": [], "type": {"kind": "OBJECT", "name": "Missing"}}], "interfaces": []}
+			],
+			"directives": []
+		}}}`))
+	}))
+	defer server.Close()
+
+	tests := map[string]struct {
+		skipValidation bool
+		expectErr      bool
+	}{
+		"validated by default": {expectErr: true},
+		"skip validation":      {skipValidation: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint:       server.URL,
+				SkipValidation: tt.skipValidation,
+			})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expect error: %v got: %v", tt.expectErr, err)
+			}
+			if !tt.expectErr {
+				return
+			}
+			var validationErr *SchemaValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expect a SchemaValidationError got: %v", err)
+			}
+			if validationErr.Line != "\tme: Missing" {
+				t.Errorf("expect the offending line got: %q", validationErr.Line)
+			}
+		})
+	}
+}
+
+func Test_validateSDL_RepeatableDirectives(t *testing.T) {
+	schema := introspectionSchema{
+		QueryType: introspectionRootType{Name: "Query"},
+		Types: []introspectionTypeDefinition{{
+			Kind:   "OBJECT",
+			Name:   "Query",
+			Fields: []introspectedTypeField{{Name: "version", Type: &introspectedType{Kind: "SCALAR", Name: strPtr("String")}}},
+		}},
+		Directives: []introspectionDirectiveDefinition{{Name: "tag", IsRepeatable: true, Locations: []ast.DirectiveLocation{ast.LocationObject}}},
+	}
+	sdl, err := printSchema(context.Background(), schema, builtinFilter{without: true}, SDLFormat{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateSDL(sdl); err != nil {
+		t.Errorf("expect repeatable directives to validate got: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package gqlfetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Warning reports part of the introspection result changed or left out to print it as
+// SDL, such as a type of unknown kind left out by a Lenient fetch.
+type Warning struct {
+	// Path is the schema member, such as User, User.name, User.name(first), Role.ADMIN,
+	// @auth or @auth(requires).
+	Path    string
+	Message string
+}
+
+func (w Warning) String() string { return w.Path + ": " + w.Message }
+
+// Result is a schema along with the warnings reported while building it.
+type Result struct {
+	Schema   string
+	Warnings []Warning
+}
+
+// BuildClientSchemaResult is BuildClientSchemaWithOptions returning the warnings it
+// reports to OnWarning alongside the schema, so tooling can surface them without
+// failing the build. Schemas served from Cache come without warnings.
+func BuildClientSchemaResult(ctx context.Context, options BuildClientSchemaOptions) (Result, error) {
+	var result Result
+	onWarning := options.OnWarning
+	options.OnWarning = func(warning Warning) {
+		result.Warnings = append(result.Warnings, warning)
+		if onWarning != nil {
+			onWarning(warning)
+		}
+	}
+	schema, err := BuildClientSchemaWithOptions(ctx, options)
+	result.Schema = schema
+	return result, err
+}
+
+func (options BuildClientSchemaOptions) warn(warning Warning) {
+	options.debug("schema warning", "path", warning.Path, "message", warning.Message)
+	if options.OnWarning != nil {
+		options.OnWarning(warning)
+	}
+}
+
+// knownKinds are the kinds of the type definitions gqlfetch prints.
+var knownKinds = map[ast.DefinitionKind]bool{
+	ast.Scalar: true, ast.Object: true, ast.Interface: true, ast.Union: true, ast.Enum: true, ast.InputObject: true,
+}
+
+// withoutUnknownKinds removes the types of kinds gqlfetch doesn't know, such as the ones
+// of server extensions, along with the fields, arguments, input fields and members
+// referring to them and the types they leave empty. Every removed type is reported to
+// warn and kept in the schema to be printed as a comment.
+func withoutUnknownKinds(schema introspectionSchema, warn func(Warning)) (introspectionSchema, error) {
+	keep := map[string]bool{}
+	unknown := false
+	for _, typ := range schema.Types {
+		keep[typ.Name] = knownKinds[typ.Kind]
+		unknown = unknown || !keep[typ.Name]
+	}
+	if !unknown {
+		return schema, nil
+	}
+
+	pruned, err := pruneSchema(schema, keep)
+	if err != nil {
+		return introspectionSchema{}, err
+	}
+	kept := map[string]bool{}
+	for _, typ := range pruned.Types {
+		kept[typ.Name] = true
+	}
+	for _, typ := range schema.Types {
+		if kept[typ.Name] {
+			continue
+		}
+		warning := Warning{Path: typ.Name, Message: fmt.Sprintf("left out type of unknown kind %s", typ.Kind)}
+		if knownKinds[typ.Kind] {
+			warning.Message = "left out as it only refers to types of unknown kinds"
+		}
+		warn(warning)
+		pruned.warnings = append(pruned.warnings, warning)
+	}
+	return pruned, nil
+}
+
+// printWarnings prints a comment in place of every type a Lenient fetch left out. Line
+// breaks, which a server could send in a kind, are replaced so the comment stays one line.
+func printWarnings(sb *strings.Builder, warnings []Warning) {
+	for _, warning := range warnings {
+		sb.WriteString("# ")
+		sb.WriteString(strings.NewReplacer("\r", " ", "\n", " ").Replace(warning.String()))
+		sb.WriteString("\n\n")
+	}
+}
+
+// withPrintableSchema removes what can't be printed as SDL from a freshly decoded
+// schema, in place: the control characters of descriptions, which GraphQL strings can't
+// hold, and the directive locations gqlfetch doesn't know, along with the directives
+// left without locations. Every removal is reported to warn.
+func withPrintableSchema(schema introspectionSchema, warn func(Warning)) (introspectionSchema, error) {
+	description := func(path string, description *string) {
+		if printable, ok := printableDescription(*description); !ok {
+			*description = printable
+			warn(Warning{Path: path, Message: "removed control characters from the description"})
+		}
+	}
+	args := func(path string, args []introspectionInputField) {
+		for i := range args {
+			description(path+"("+args[i].Name+")", &args[i].Description)
+		}
+	}
+
+	directives := schema.Directives[:0]
+	for _, directive := range schema.Directives {
+		path := "@" + directive.Name
+		description(path, &directive.Description)
+		args(path, directive.Args)
+		locations := directive.Locations[:0]
+		for _, location := range directive.Locations {
+			if knownLocations[location] {
+				locations = append(locations, location)
+			} else {
+				warn(Warning{Path: path, Message: fmt.Sprintf("left out unknown location %s", location)})
+			}
+		}
+		directive.Locations = locations
+		if len(locations) == 0 {
+			warn(Warning{Path: path, Message: "left out as none of its locations are known"})
+			continue
+		}
+		directives = append(directives, directive)
+	}
+	if schema.Directives != nil {
+		schema.Directives = directives
+	}
+
+	for i := range schema.Types {
+		typ := &schema.Types[i]
+		description(typ.Name, &typ.Description)
+		for j := range typ.Fields {
+			field := &typ.Fields[j]
+			description(typ.Name+"."+field.Name, &field.Description)
+			args(typ.Name+"."+field.Name, field.Args)
+		}
+		for j := range typ.InputFields {
+			description(typ.Name+"."+typ.InputFields[j].Name, &typ.InputFields[j].Description)
+		}
+
+		// Control characters are escaped in JSON, which saves decoding most enums.
+		if !bytes.Contains(typ.EnumValues, []byte(`\u00`)) && !bytes.Contains(typ.EnumValues, []byte(`\b`)) && !bytes.Contains(typ.EnumValues, []byte(`\f`)) {
+			continue
+		}
+		var values []introspectionEnumValue
+		if err := json.Unmarshal(typ.EnumValues, &values); err != nil {
+			return introspectionSchema{}, fmt.Errorf("cannot unmarshal enum values: %w\n%v", err, typ.EnumValues)
+		}
+		for j := range values {
+			description(typ.Name+"."+values[j].Name, &values[j].Description)
+		}
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			return introspectionSchema{}, fmt.Errorf("cannot marshal enum values: %w", err)
+		}
+		typ.EnumValues = encoded
+	}
+	return schema, nil
+}
+
+// printableDescription removes the control characters other than tabs and line breaks
+// from description, reporting false when there were any.
+func printableDescription(description string) (string, bool) {
+	for i := 0; i < len(description); i++ {
+		if isControlCharacter(rune(description[i])) {
+			return strings.Map(func(r rune) rune {
+				if isControlCharacter(r) {
+					return -1
+				}
+				return r
+			}, description), false
+		}
+	}
+	return description, true
+}
+
+func isControlCharacter(r rune) bool {
+	return r < 0x20 && r != '\t' && r != '\n' && r != '\r'
+}
+
+// knownLocations are the directive locations gqlfetch prints.
+var knownLocations = map[ast.DirectiveLocation]bool{
+	ast.LocationQuery: true, ast.LocationMutation: true, ast.LocationSubscription: true, ast.LocationField: true,
+	ast.LocationFragmentDefinition: true, ast.LocationFragmentSpread: true, ast.LocationInlineFragment: true,
+	ast.LocationVariableDefinition: true, ast.LocationSchema: true, ast.LocationScalar: true, ast.LocationObject: true,
+	ast.LocationFieldDefinition: true, ast.LocationArgumentDefinition: true, ast.LocationInterface: true,
+	ast.LocationUnion: true, ast.LocationEnum: true, ast.LocationEnumValue: true, ast.LocationInputObject: true,
+	ast.LocationInputFieldDefinition: true,
+}
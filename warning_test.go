@@ -0,0 +1,124 @@
+package gqlfetch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const unknownKindIntrospection = `{"data": {"__schema": {"queryType": {"name": "Query"}, "types": [
+	{"kind": "OBJECT", "name": "Query", "interfaces": [], "fields": [
+		{"name": "user", "args": [], "type": {"kind": "OBJECT", "name": "User"}},
+		{"name": "stream", "args": [], "type": {"kind": "STREAM", "name": "Ticks"}},
+		{"name": "feed", "args": [], "type": {"kind": "OBJECT", "name": "Feed"}}
+	]},
+	{"kind": "OBJECT", "name": "User", "interfaces": [], "fields": [
+		{"name": "name", "args": [{"name": "ticks", "type": {"kind": "STREAM", "name": "Ticks"}}], "type": {"kind": "SCALAR", "name": "String"}}
+	]},
+	{"kind": "OBJECT", "name": "Feed", "interfaces": [], "fields": [{"name": "ticks", "args": [], "type": {"kind": "STREAM", "name": "Ticks"}}]},
+	{"kind": "STREAM", "name": "Ticks"},
+	{"kind": "SCALAR", "name": "String"}
+], "directives": []}}}`
+
+func TestBuildClientSchemaWithOptions_Lenient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "introspection.json")
+	if err := os.WriteFile(path, []byte(unknownKindIntrospection), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	expectWarnings := []Warning{
+		{Path: "Feed", Message: "left out as it only refers to types of unknown kinds"},
+		{Path: "Ticks", Message: "left out type of unknown kind STREAM"},
+	}
+
+	if _, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{Endpoint: "file://" + path}); err == nil || !strings.Contains(err.Error(), "STREAM") {
+		t.Errorf("expect the unknown kind to fail the strict fetch got: %v", err)
+	}
+
+	var warnings []Warning
+	schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+		Endpoint:        "file://" + path,
+		WithoutBuiltins: true,
+		Lenient:         true,
+		OnWarning:       func(warning Warning) { warnings = append(warnings, warning) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := `# Feed: left out as it only refers to types of unknown kinds
+
+# Ticks: left out type of unknown kind STREAM
+
+type Query {
+	user: User
+}
+
+type User {
+	name: String
+}
+
+`
+	if schema != expect {
+		t.Errorf("expect:\n%s\ngot:\n%s", expect, schema)
+	}
+	if !reflect.DeepEqual(warnings, expectWarnings) {
+		t.Errorf("expect warnings %v got: %v", expectWarnings, warnings)
+	}
+
+	results := BuildClientSchemas(context.Background(), []BuildClientSchemaOptions{{Endpoint: "file://" + path, Lenient: true}})
+	if results[0].Err != nil {
+		t.Fatalf("unexpected error: %v", results[0].Err)
+	}
+	if !reflect.DeepEqual(results[0].Warnings, expectWarnings) {
+		t.Errorf("expect result warnings %v got: %v", expectWarnings, results[0].Warnings)
+	}
+}
+
+func TestBuildClientSchemaResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "introspection.json")
+	introspection := `{"data": {"__schema": {"queryType": {"name": "Query"}, "types": [
+		{"kind": "OBJECT", "name": "Query", "description": "Root\u0007 query.", "interfaces": [], "fields": [
+			{"name": "user", "description": "A\u001b[1m user.", "args": [{"name": "id", "description": "\u0000ID", "type": {"kind": "SCALAR", "name": "String"}}], "type": {"kind": "ENUM", "name": "Role"}}
+		]},
+		{"kind": "ENUM", "name": "Role", "enumValues": [{"name": "ADMIN", "description": "Line\ntab\tbell\b.", "isDeprecated": false}]},
+		{"kind": "SCALAR", "name": "String"}
+	], "directives": [
+		{"name": "cached", "locations": ["FIELD_DEFINITION", "EXTENSION"], "args": []},
+		{"name": "internal", "locations": ["EXTENSION"], "args": []}
+	]}}}`
+	if err := os.WriteFile(path, []byte(introspection), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	result, err := BuildClientSchemaResult(context.Background(), BuildClientSchemaOptions{Endpoint: "file://" + path, WithoutBuiltins: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectWarnings := []Warning{
+		{Path: "@cached", Message: "left out unknown location EXTENSION"},
+		{Path: "@internal", Message: "left out unknown location EXTENSION"},
+		{Path: "@internal", Message: "left out as none of its locations are known"},
+		{Path: "Query", Message: "removed control characters from the description"},
+		{Path: "Query.user", Message: "removed control characters from the description"},
+		{Path: "Query.user(id)", Message: "removed control characters from the description"},
+		{Path: "Role.ADMIN", Message: "removed control characters from the description"},
+	}
+	if !reflect.DeepEqual(result.Warnings, expectWarnings) {
+		t.Errorf("expect warnings %v got: %v", expectWarnings, result.Warnings)
+	}
+	for _, expect := range []string{
+		`"""Root query."""`,
+		`"""A[1m user."""`,
+		"directive @cached on FIELD_DEFINITION\n",
+		"\"\"\"\nLine\ntab\tbell.\n\"\"\"\n\tADMIN",
+	} {
+		if !strings.Contains(result.Schema, expect) {
+			t.Errorf("expect schema to contain %q got:\n%s", expect, result.Schema)
+		}
+	}
+	if strings.Contains(result.Schema, "@internal") {
+		t.Errorf("expect @internal to be left out got:\n%s", result.Schema)
+	}
+}
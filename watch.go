@@ -0,0 +1,97 @@
+package gqlfetch
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultWatchInterval is the polling interval of a Watcher without one.
+const DefaultWatchInterval = time.Minute
+
+// SchemaChange is reported by a Watcher when the introspected schema changed.
+type SchemaChange struct {
+	Schema string
+	// Hash is the SchemaHash of Schema, PreviousHash is empty for the first fetch.
+	Hash         string
+	PreviousHash string
+}
+
+// Watcher periodically re-introspects an endpoint and reports schema changes, which is
+// detected by comparing the SchemaHash of the printed schema between fetches. When the
+// endpoint rate limits a fetch, the next one waits for its Retry-After if longer than
+// Interval.
+type Watcher struct {
+	Options  BuildClientSchemaOptions
+	Interval time.Duration
+
+	// OnChange is called with the first schema fetched and then on every change.
+	OnChange func(SchemaChange)
+	// OnError is called when a fetch fails, the watcher keeps polling afterwards.
+	OnError func(error)
+
+	hash string
+}
+
+// Run polls until ctx is done, returning the context error.
+func (w *Watcher) Run(ctx context.Context) error {
+	return w.run(ctx, w.OnChange)
+}
+
+func (w *Watcher) run(ctx context.Context, onChange func(SchemaChange)) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	for {
+		delay := interval
+		var limited *RateLimitedError
+		if err := w.poll(ctx, onChange); errors.As(err, &limited) && limited.RetryAfter > delay {
+			delay = limited.RetryAfter
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Watch runs the watcher in the background and sends every change on the returned
+// channel, which is closed once ctx is done. OnChange is still called if set.
+func (w *Watcher) Watch(ctx context.Context) <-chan SchemaChange {
+	changes := make(chan SchemaChange)
+	go func() {
+		defer close(changes)
+		w.run(ctx, func(change SchemaChange) {
+			if w.OnChange != nil {
+				w.OnChange(change)
+			}
+			select {
+			case changes <- change:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return changes
+}
+
+func (w *Watcher) poll(ctx context.Context, onChange func(SchemaChange)) error {
+	schema, err := BuildClientSchemaWithOptions(ctx, w.Options)
+	if err != nil {
+		if w.OnError != nil && ctx.Err() == nil {
+			w.OnError(err)
+		}
+		return err
+	}
+
+	hash := SchemaHash(schema)
+	if hash == w.hash {
+		return nil
+	}
+	change := SchemaChange{Schema: schema, Hash: hash, PreviousHash: w.hash}
+	w.hash = hash
+	if onChange != nil {
+		onChange(change)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package gqlfetch
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Watch(t *testing.T) {
+	body, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	changed := bytes.Replace(body, []byte(`"The user's display name."`), []byte(`"The user's full name."`), 1)
+
+	// The schema changes once, from the third fetch onwards.
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&fetches, 1) < 3 {
+			w.Write(body)
+			return
+		}
+		w.Write(changed)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watcher := &Watcher{
+		Options:  BuildClientSchemaOptions{Endpoint: server.URL, Method: http.MethodPost},
+		Interval: 5 * time.Millisecond,
+	}
+	changes := watcher.Watch(ctx)
+
+	first := <-changes
+	if first.PreviousHash != "" || first.Hash == "" {
+		t.Errorf("expect first change to have no previous hash got: %+v", first)
+	}
+	second := <-changes
+	if second.PreviousHash != first.Hash || second.Hash == first.Hash {
+		t.Errorf("expect second change to follow the first got: %+v", second)
+	}
+	if atomic.LoadInt32(&fetches) < 3 {
+		t.Errorf("expect unchanged fetches to be skipped")
+	}
+}
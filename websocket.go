@@ -0,0 +1,205 @@
+package gqlfetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/zucchinho/gqlfetch/internal/websocket"
+)
+
+// The GraphQL over WebSocket subprotocols, the graphql-transport-ws protocol of the
+// graphql-ws library and the legacy protocol of subscriptions-transport-ws.
+const (
+	graphqlTransportWS = "graphql-transport-ws"
+	graphqlWS          = "graphql-ws"
+)
+
+// webSocketOperationID identifies the single operation sent over the connection.
+const webSocketOperationID = "1"
+
+// webSocketEnvelopeBytes is the room left for the id and type of the message carrying
+// the response when MaxResponseBytes bounds the messages read.
+const webSocketEnvelopeBytes = 1 << 10
+
+type webSocketMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+func isWebSocketEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "ws://") || strings.HasPrefix(endpoint, "wss://")
+}
+
+// executeWebSocket sends the GraphQL request over a WebSocket connection, using the
+// graphql-transport-ws protocol or, when the server only speaks it, the legacy
+// graphql-ws protocol. Headers are sent with the handshake and as the payload of
+// connection_init, where most servers expect credentials.
+func executeWebSocket(ctx context.Context, options BuildClientSchemaOptions, params requestParams, decode func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, options.Endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create websocket request: %w", err)
+	}
+	req.Header = options.Headers.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	if options.CookieJar != nil {
+		cookieURL := *req.URL
+		cookieURL.Scheme = strings.Replace(cookieURL.Scheme, "ws", "http", 1)
+		for _, cookie := range options.CookieJar.Cookies(&cookieURL) {
+			req.AddCookie(cookie)
+		}
+	}
+	if options.Auth != nil {
+		if err := options.Auth.Apply(req); err != nil {
+			return fmt.Errorf("failed to authenticate request: %w", err)
+		}
+	}
+
+	tlsConfig, err := options.TLS.config()
+	if err != nil {
+		return err
+	}
+	proxy, err := webSocketProxy(options.ProxyURL, req.URL)
+	if err != nil {
+		return err
+	}
+	conn, protocol, err := websocket.Dial(ctx, req, []string{graphqlTransportWS, graphqlWS}, tlsConfig, proxy)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if options.MaxResponseBytes > 0 {
+		conn.SetReadLimit(options.MaxResponseBytes + webSocketEnvelopeBytes)
+	}
+	if protocol == "" {
+		protocol = graphqlWS
+	}
+	options.debug("sending introspection request", "endpoint", options.Endpoint, "protocol", protocol)
+
+	initPayload := map[string]string{}
+	for name := range req.Header {
+		initPayload[name] = req.Header.Get(name)
+	}
+	if err := writeWebSocketMessage(conn, "", "connection_init", initPayload); err != nil {
+		return err
+	}
+	if _, err := readWebSocketMessage(conn, protocol, "connection_ack"); err != nil {
+		return err
+	}
+
+	subscribe, complete := "subscribe", "complete"
+	if protocol == graphqlWS {
+		subscribe, complete = "start", "stop"
+	}
+	if err := writeWebSocketMessage(conn, webSocketOperationID, subscribe, params); err != nil {
+		return err
+	}
+	result, err := readWebSocketMessage(conn, protocol, "next", "data")
+	if errors.Is(err, websocket.ErrMessageTooLarge) {
+		return fmt.Errorf("%w: exceeds %d bytes", ErrResponseTooLarge, options.MaxResponseBytes)
+	}
+	if err != nil {
+		return err
+	}
+	writeWebSocketMessage(conn, webSocketOperationID, complete, nil)
+	if protocol == graphqlWS {
+		writeWebSocketMessage(conn, "", "connection_terminate", nil)
+	}
+
+	return decode(limitResponse(options, bytes.NewReader(result.Payload)))
+}
+
+// webSocketProxy returns the proxy of the endpoint, proxyURL or the one of the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables. Only http and https
+// proxies tunnel WebSocket connections.
+func webSocketProxy(proxyURL string, endpoint *url.URL) (*url.URL, error) {
+	var proxy *url.URL
+	if proxyURL != "" {
+		parsed, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
+		}
+		proxy = parsed
+	} else {
+		target := *endpoint
+		target.Scheme = strings.Replace(target.Scheme, "ws", "http", 1)
+		fromEnvironment, err := http.ProxyFromEnvironment(&http.Request{URL: &target})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the proxy environment variables: %w", err)
+		}
+		proxy = fromEnvironment
+	}
+	if proxy != nil && proxy.Scheme != "http" && proxy.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported proxy scheme %q for websocket endpoints, expected http or https", proxy.Scheme)
+	}
+	return proxy, nil
+}
+
+func writeWebSocketMessage(conn *websocket.Conn, id, typ string, payload interface{}) error {
+	message := webSocketMessage{ID: id, Type: typ}
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s message: %w", typ, err)
+		}
+		message.Payload = encoded
+	}
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", typ, err)
+	}
+	return conn.WriteMessage(encoded)
+}
+
+// readWebSocketMessage reads messages until one of the expected types arrives,
+// answering pings and skipping keep-alives.
+func readWebSocketMessage(conn *websocket.Conn, protocol string, expected ...string) (webSocketMessage, error) {
+	for {
+		raw, err := conn.ReadMessage()
+		if err != nil {
+			return webSocketMessage{}, err
+		}
+		var message webSocketMessage
+		if err := json.Unmarshal(raw, &message); err != nil {
+			return webSocketMessage{}, fmt.Errorf("failed to decode websocket message: %w", err)
+		}
+
+		for _, typ := range expected {
+			if message.Type == typ {
+				return message, nil
+			}
+		}
+		switch message.Type {
+		case "ping":
+			if protocol == graphqlTransportWS {
+				if err := writeWebSocketMessage(conn, "", "pong", nil); err != nil {
+					return webSocketMessage{}, err
+				}
+			}
+		case "pong", "ka":
+		case "error":
+			var gqlErrs GraphQLErrors
+			if err := json.Unmarshal(message.Payload, &gqlErrs); err != nil {
+				var gqlErr GraphQLError
+				json.Unmarshal(message.Payload, &gqlErr)
+				gqlErrs = GraphQLErrors{gqlErr}
+			}
+			return webSocketMessage{}, gqlErrs
+		case "connection_error":
+			return webSocketMessage{}, fmt.Errorf("websocket connection rejected: %s", message.Payload)
+		case "complete":
+			return webSocketMessage{}, fmt.Errorf("websocket operation completed without a result")
+		default:
+			return webSocketMessage{}, fmt.Errorf("unexpected websocket message %q", message.Type)
+		}
+	}
+}
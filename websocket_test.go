@@ -0,0 +1,220 @@
+package gqlfetch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zucchinho/gqlfetch/internal/websocket"
+)
+
+func TestBuildClientSchemaWithOptions_WebSocket(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	tests := map[string]struct {
+		protocol  string
+		subscribe string
+		next      string
+		complete  string
+		keepAlive string
+	}{
+		"graphql-transport-ws": {protocol: graphqlTransportWS, subscribe: "subscribe", next: "next", complete: "complete", keepAlive: "ping"},
+		"legacy graphql-ws":    {protocol: graphqlWS, subscribe: "start", next: "data", complete: "stop", keepAlive: "ka"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			done := make(chan []string, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				conn, err := websocket.Upgrade(w, r, tt.protocol)
+				if err != nil {
+					t.Errorf("upgrade: %v", err)
+					return
+				}
+				defer conn.Close()
+
+				var received []string
+				read := func() webSocketMessage {
+					raw, err := conn.ReadMessage()
+					if err != nil {
+						t.Errorf("read message: %v", err)
+						return webSocketMessage{}
+					}
+					var message webSocketMessage
+					json.Unmarshal(raw, &message)
+					received = append(received, message.Type)
+					return message
+				}
+				write := func(message webSocketMessage) {
+					encoded, _ := json.Marshal(message)
+					conn.WriteMessage(encoded)
+				}
+
+				if init := read(); !strings.Contains(string(init.Payload), "Bearer secret") {
+					t.Errorf("expect headers in the connection_init payload got: %s", init.Payload)
+				}
+				write(webSocketMessage{Type: tt.keepAlive})
+				write(webSocketMessage{Type: "connection_ack"})
+				if tt.keepAlive == "ping" {
+					read()
+				}
+				if subscribe := read(); !strings.Contains(string(subscribe.Payload), "__schema") {
+					t.Errorf("expect the introspection query got: %s", subscribe.Payload)
+				}
+				var response struct {
+					Data json.RawMessage `json:"data"`
+				}
+				json.Unmarshal(fixture, &response)
+				payload, _ := json.Marshal(response)
+				write(webSocketMessage{ID: webSocketOperationID, Type: tt.next, Payload: payload})
+				read()
+				done <- received
+			}))
+			defer server.Close()
+
+			schema, err := BuildClientSchemaWithOptions(context.Background(), BuildClientSchemaOptions{
+				Endpoint: "ws" + strings.TrimPrefix(server.URL, "http"),
+				Headers:  http.Header{"Authorization": {"Bearer secret"}},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(schema, "type User implements Node") {
+				t.Errorf("expect the introspected schema got:\n%v", schema)
+			}
+
+			expected := []string{"connection_init", tt.subscribe, tt.complete}
+			if tt.keepAlive == "ping" {
+				expected = []string{"connection_init", "pong", tt.subscribe, tt.complete}
+			}
+			if received := <-done; strings.Join(received, ",") != strings.Join(expected, ",") {
+				t.Errorf("expect messages: %v got: %v", expected, received)
+			}
+		})
+	}
+}
+
+func TestBuildClientSchemaWithOptions_WebSocketConnection(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	// The server acknowledges the connection and answers the operation with the fixture,
+	// unless silent.
+	newServer := func(silent bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := websocket.Upgrade(w, r, graphqlTransportWS)
+			if err != nil {
+				t.Errorf("upgrade: %v", err)
+				return
+			}
+			defer conn.Close()
+			if silent {
+				conn.ReadMessage()
+				conn.ReadMessage()
+				return
+			}
+			conn.ReadMessage()
+			conn.WriteMessage([]byte(`{"type": "connection_ack"}`))
+			conn.ReadMessage()
+			encoded, _ := json.Marshal(webSocketMessage{ID: webSocketOperationID, Type: "next", Payload: fixture})
+			conn.WriteMessage(encoded)
+			conn.ReadMessage()
+		}))
+	}
+	var tunneled []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		tunneled = append(tunneled, r.Host)
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer target.Close()
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go io.Copy(target, rw)
+		io.Copy(conn, target)
+	}))
+	defer proxy.Close()
+
+	tests := map[string]struct {
+		silent           bool
+		proxyURL         string
+		maxResponseBytes int64
+		expectTunnel     bool
+		expectErr        error
+		expectErrString  string
+	}{
+		"canceled without deadline": {silent: true, expectErr: context.Canceled},
+		"response too large":        {maxResponseBytes: 1 << 10, expectErr: ErrResponseTooLarge},
+		"http proxy":                {proxyURL: proxy.URL, expectTunnel: true},
+		"socks5 proxy":              {proxyURL: "socks5://localhost:1080", expectErrString: `unsupported proxy scheme "socks5"`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := newServer(tt.silent)
+			defer server.Close()
+			tunneled = nil
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.silent {
+				time.AfterFunc(50*time.Millisecond, cancel)
+			}
+			errs := make(chan error, 1)
+			go func() {
+				_, err := BuildClientSchemaWithOptions(ctx, BuildClientSchemaOptions{
+					Endpoint:         "ws" + strings.TrimPrefix(server.URL, "http"),
+					ProxyURL:         tt.proxyURL,
+					MaxResponseBytes: tt.maxResponseBytes,
+				})
+				errs <- err
+			}()
+			select {
+			case err = <-errs:
+			case <-time.After(5 * time.Second):
+				t.Fatal("expect the fetch to end")
+			}
+
+			switch {
+			case tt.expectErr != nil:
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("expect error: %v got: %v", tt.expectErr, err)
+				}
+			case tt.expectErrString != "":
+				if err == nil || !strings.Contains(err.Error(), tt.expectErrString) {
+					t.Errorf("expect error containing: %v got: %v", tt.expectErrString, err)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expect := strings.TrimPrefix(server.URL, "http://"); tt.expectTunnel && (len(tunneled) != 1 || tunneled[0] != expect) {
+				t.Errorf("expect a tunnel to %s got: %v", expect, tunneled)
+			}
+		})
+	}
+}